@@ -6,26 +6,38 @@
 package main
 
 import (
+	"expvar"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/coseguera/kanban-to-do/internal/auth"
+	"github.com/coseguera/kanban-to-do/internal/board"
 	"github.com/coseguera/kanban-to-do/internal/handlers"
+	"github.com/coseguera/kanban-to-do/internal/notify"
+	"github.com/coseguera/kanban-to-do/internal/ordering"
+	"github.com/coseguera/kanban-to-do/internal/service"
+	"github.com/coseguera/kanban-to-do/internal/service/local"
 	"github.com/coseguera/kanban-to-do/internal/templates"
 	"github.com/coseguera/kanban-to-do/pkg/microsoft"
 )
 
 func main() {
-	// Get Microsoft OAuth configuration from environment variables
-	clientID := os.Getenv("MS_CLIENT_ID")
-	clientSecret := os.Getenv("MS_CLIENT_SECRET")
-
-	// Check if environment variables are set
-	if clientID == "" || clientSecret == "" {
-		log.Fatal("Please set MS_CLIENT_ID and MS_CLIENT_SECRET environment variables")
-	}
+	// Parse command-line flags
+	backend := flag.String("backend", "microsoft", "to-do backend to use: microsoft or local (offline, no Microsoft account needed)")
+	localFile := flag.String("local-file", filepath.Join("data", "todo.json"), "path of the JSON file used by the local backend")
+	publicClient := flag.Bool("public-client", false, "authenticate as a public client using PKCE only, without MS_CLIENT_SECRET")
+	sessionStoreKind := flag.String("session-store", "memory", "session store to use: memory or file")
+	sessionFile := flag.String("session-file", filepath.Join("data", "sessions.json"), "path of the encrypted session file used by the file session store")
+	orderFile := flag.String("order-file", filepath.Join("data", "order.json"), "path of the JSON file that stores manual card order")
+	boardsFile := flag.String("boards", "", "path of a JSON file defining Kanban columns (default: Not Started / Doing / Done)")
+	syncInterval := flag.Duration("sync-interval", 30*time.Second, "how often open lists are synced in the background")
+	notificationURL := flag.String("notification-url", "", "public HTTPS URL of /api/graph/notifications; enables Graph change notifications for open lists")
+	debugAddr := flag.String("debug-addr", "", "address of a separate listener serving /debug/vars, such as localhost:6060 (default: off)")
+	flag.Parse()
 
 	// Create directories if they don't exist
 	if err := os.MkdirAll("templates", 0755); err != nil {
@@ -41,41 +53,144 @@ func main() {
 		log.Fatalf("Failed to load templates: %v", err)
 	}
 
-	// Create Microsoft client
-	msConfig := microsoft.Config{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		RedirectURI:  "https://localhost:8443/auth/callback",
-		AuthURL:      "https://login.microsoftonline.com/consumers/oauth2/v2.0/authorize",
-		TokenURL:     "https://login.microsoftonline.com/consumers/oauth2/v2.0/token",
-		Scope:        "offline_access User.Read Tasks.ReadWrite",
-		GraphURL:     "https://graph.microsoft.com/v1.0/me/todo/lists",
+	// Create the to-do backend
+	var todoService service.TodoService
+	switch *backend {
+	case "microsoft":
+		// Get Microsoft OAuth configuration from environment variables
+		clientID := os.Getenv("MS_CLIENT_ID")
+		clientSecret := os.Getenv("MS_CLIENT_SECRET")
+
+		// Check if environment variables are set
+		if clientID == "" {
+			log.Fatal("Please set the MS_CLIENT_ID environment variable, or run with -backend=local")
+		}
+		if clientSecret == "" && !*publicClient {
+			log.Fatal("Please set the MS_CLIENT_SECRET environment variable, or run with -public-client")
+		}
+
+		msConfig := microsoft.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			PublicClient: *publicClient,
+			RedirectURI:  "https://localhost:8443/auth/callback",
+			AuthURL:      "https://login.microsoftonline.com/consumers/oauth2/v2.0/authorize",
+			TokenURL:     "https://login.microsoftonline.com/consumers/oauth2/v2.0/token",
+			Scope:        "offline_access User.Read Tasks.ReadWrite",
+			GraphURL:     "https://graph.microsoft.com/v1.0/me/todo/lists",
+		}
+		cachingClient := microsoft.NewCachingClient(microsoft.NewClient(msConfig))
+		expvar.Publish("taskCache", expvar.Func(func() interface{} { return cachingClient.Stats() }))
+		todoService = cachingClient
+	case "local":
+		localService, err := local.NewService(*localFile)
+		if err != nil {
+			log.Fatalf("Failed to open local data file: %v", err)
+		}
+		log.Printf("Using the local backend stored in %s", *localFile)
+		todoService = localService
+	default:
+		log.Fatalf("Unknown backend %q (expected microsoft or local)", *backend)
+	}
+
+	// Create session store
+	var sessionStore auth.SessionStore
+	switch *sessionStoreKind {
+	case "memory":
+		sessionStore = auth.NewMemoryStore()
+	case "file":
+		// The key must be shared by every instance that uses the same session file
+		sessionKey := os.Getenv("SESSION_KEY")
+		if sessionKey == "" {
+			log.Fatal("Please set the SESSION_KEY environment variable to use the file session store")
+		}
+		fileStore, err := auth.NewFileStore(*sessionFile, sessionKey)
+		if err != nil {
+			log.Fatalf("Failed to open session file: %v", err)
+		}
+		sessionStore = fileStore
+	default:
+		log.Fatalf("Unknown session store %q (expected memory or file)", *sessionStoreKind)
+	}
+
+	// Create session manager and sweep expired sessions in the background
+	sessionManager := auth.NewSessionManager(todoService, sessionStore)
+	sessionManager.StartSweeper(time.Hour)
+
+	// Load board definitions
+	boards := board.DefaultConfig()
+	if *boardsFile != "" {
+		loaded, err := board.LoadConfig(*boardsFile)
+		if err != nil {
+			log.Fatalf("Failed to load board config: %v", err)
+		}
+		boards = loaded
 	}
-	msClient := microsoft.NewClient(msConfig)
 
-	// Create session manager
-	sessionManager := auth.NewSessionManager(msClient)
+	// Open the card order store
+	orderStore, err := ordering.NewFileStore(*orderFile)
+	if err != nil {
+		log.Fatalf("Failed to open card order file: %v", err)
+	}
 
 	// Create handlers
-	h := handlers.NewHandler(msClient, sessionManager)
+	h := handlers.NewHandler(todoService, sessionManager, boards, orderStore)
+
+	// Keep the tasks of open lists up to date in the background
+	h.Cache.Start(*syncInterval)
+
+	// Routes get their own mux, as the default one serves /debug/vars to anyone once expvar is imported
+	mux := http.NewServeMux()
+
+	// Subscribe to change notifications for open lists if Graph can reach this server
+	if *notificationURL != "" {
+		subscriber, ok := todoService.(service.SubscriptionService)
+		if !ok {
+			log.Fatalf("The %s backend does not support change notifications", *backend)
+		}
+		notifier := notify.NewGraphNotifier(subscriber, *notificationURL, sessionManager.AccessToken, h.ListChangedElsewhere)
+		notifier.Start(15 * time.Minute)
+		h.Notifier = notifier
+		mux.Handle("/api/graph/notifications", notifier) // Graph change notification webhook
+	}
 
 	// Set up routes
-	http.HandleFunc("/", h.HomeHandler)
-	http.HandleFunc("/login", h.LoginHandler)
-	http.HandleFunc("/auth/callback", h.CallbackHandler)
-	http.HandleFunc("/todoLists", h.TodoListsHandler)
-	http.HandleFunc("/list/", h.TasksHandler)                               // New route for tasks
-	http.HandleFunc("/api/updateTask", h.UpdateTaskHandler)                 // API endpoint for updating tasks
-	http.HandleFunc("/api/toggleImportance", h.ToggleTaskImportanceHandler) // API endpoint for toggling importance
-	http.HandleFunc("/api/getTaskDetails", h.GetTaskDetailsHandler)         // API endpoint for getting task details
-	http.HandleFunc("/api/updateTaskDetails", h.UpdateTaskDetailsHandler)   // API endpoint for updating task details
-	http.HandleFunc("/api/createTask", h.CreateTaskHandler)                 // API endpoint for creating a new task
-	http.HandleFunc("/api/deleteTask", h.DeleteTaskHandler)                 // API endpoint for deleting a task
-	http.HandleFunc("/logout", h.LogoutHandler)
+	mux.HandleFunc("/", h.HomeHandler)
+	mux.HandleFunc("/login", h.LoginHandler)
+	mux.HandleFunc("/auth/callback", h.CallbackHandler)
+	mux.HandleFunc("/todoLists", h.TodoListsHandler)
+	mux.HandleFunc("/api/lists", h.ListsHandler)                               // API endpoint for listing the user's lists
+	mux.HandleFunc("/api/createList", h.CreateListHandler)                     // API endpoint for creating a list
+	mux.HandleFunc("/api/renameList", h.RenameListHandler)                     // API endpoint for renaming a list
+	mux.HandleFunc("/api/deleteList", h.DeleteListHandler)                     // API endpoint for deleting a list
+	mux.HandleFunc("/list/", h.TasksHandler)                                   // New route for tasks
+	mux.HandleFunc("/board", h.AggregateBoardHandler)                          // Board with the tasks of all, or selected, lists
+	mux.HandleFunc("/api/updateTask", h.UpdateTaskHandler)                     // API endpoint for updating tasks
+	mux.HandleFunc("/api/toggleImportance", h.ToggleTaskImportanceHandler)     // API endpoint for toggling importance
+	mux.HandleFunc("/api/getTaskDetails", h.GetTaskDetailsHandler)             // API endpoint for getting task details
+	mux.HandleFunc("/api/updateTaskDetails", h.UpdateTaskDetailsHandler)       // API endpoint for updating task details
+	mux.HandleFunc("/api/createTask", h.CreateTaskHandler)                     // API endpoint for creating a new task
+	mux.HandleFunc("/api/deleteTask", h.DeleteTaskHandler)                     // API endpoint for deleting a task
+	mux.HandleFunc("/api/moveTask", h.MoveTaskHandler)                         // API endpoint for moving a task to another list
+	mux.HandleFunc("/api/reorderTask", h.ReorderTaskHandler)                   // API endpoint for reordering a task within its column
+	mux.HandleFunc("/api/checklistItems", h.ChecklistItemsHandler)             // API endpoint for listing a task's checklist items
+	mux.HandleFunc("/api/createChecklistItem", h.CreateChecklistItemHandler)   // API endpoint for adding a checklist item
+	mux.HandleFunc("/api/updateChecklistItem", h.UpdateChecklistItemHandler)   // API endpoint for renaming a checklist item
+	mux.HandleFunc("/api/toggleChecklistItem", h.ToggleChecklistItemHandler)   // API endpoint for checking or unchecking a checklist item
+	mux.HandleFunc("/api/deleteChecklistItem", h.DeleteChecklistItemHandler)   // API endpoint for deleting a checklist item
+	mux.HandleFunc("/api/linkedResources", h.LinkedResourcesHandler)           // API endpoint for listing a task's links
+	mux.HandleFunc("/api/createLinkedResource", h.CreateLinkedResourceHandler) // API endpoint for adding a link to a task
+	mux.HandleFunc("/api/deleteLinkedResource", h.DeleteLinkedResourceHandler) // API endpoint for removing a link from a task
+	mux.HandleFunc("/api/attachments", h.AttachmentsHandler)                   // API endpoint for listing a task's attachments
+	mux.HandleFunc("/api/uploadAttachment", h.UploadAttachmentHandler)         // API endpoint for attaching a file to a task
+	mux.HandleFunc("/api/downloadAttachment", h.DownloadAttachmentHandler)     // API endpoint for downloading an attachment
+	mux.HandleFunc("/api/deleteAttachment", h.DeleteAttachmentHandler)         // API endpoint for removing an attachment
+	mux.HandleFunc("/api/events", h.EventsHandler)                             // Server-Sent Events stream of board changes
+	mux.HandleFunc("/logout", h.LogoutHandler)
 
 	// Serve static files
 	fs := http.FileServer(http.Dir("static"))
-	http.Handle("/static/", http.StripPrefix("/static/", fs))
+	mux.Handle("/static/", http.StripPrefix("/static/", fs))
 
 	// Set up HTTPS server
 	certFile := filepath.Join("certs", "server.crt")
@@ -88,6 +203,16 @@ func main() {
 		log.Fatal("Certificate files required for HTTPS")
 	}
 
+	// Serve the published stats, such as the task cache's, only where asked
+	if *debugAddr != "" {
+		debugMux := http.NewServeMux()
+		debugMux.Handle("/debug/vars", expvar.Handler())
+		go func() {
+			log.Printf("Serving stats on http://%s/debug/vars", *debugAddr)
+			log.Fatal(http.ListenAndServe(*debugAddr, debugMux))
+		}()
+	}
+
 	log.Println("Starting HTTPS server on :8443...")
-	log.Fatal(http.ListenAndServeTLS(":8443", certFile, keyFile, nil))
+	log.Fatal(http.ListenAndServeTLS(":8443", certFile, keyFile, handlers.WithSession(mux)))
 }
@@ -0,0 +1,15 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+//go:build !unix
+
+package auth
+
+import "os"
+
+// lockFile does nothing where flock isn't available, so on these systems
+// only one instance at a time should use a session file
+func lockFile(f *os.File, exclusive bool) error {
+	return nil
+}
@@ -0,0 +1,26 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+//go:build unix
+
+package auth
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile blocks until it holds an flock on f, shared or exclusive
+func lockFile(f *os.File, exclusive bool) error {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
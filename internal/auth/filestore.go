@@ -0,0 +1,276 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// FileStore is a SessionStore that keeps sessions in an AES-GCM encrypted JSON file,
+// so sessions survive restarts and can be shared by instances using the same file and key.
+// Instances take a lock on a ".lock" file next to it, so one instance's change can't overwrite another's,
+// and on a file per session in a ".locks" directory while refreshing that session's tokens.
+type FileStore struct {
+	path string
+	aead cipher.AEAD
+	mu   sync.Mutex
+}
+
+// NewFileStore creates a file-backed session store. The encryption key is derived from
+// the given secret, which must be the same for every instance sharing the file.
+func NewFileStore(path string, secret string) (*FileStore, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("a session encryption secret is required for the file session store")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("error creating session cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating session cipher: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("error creating session directory: %w", err)
+	}
+
+	s := &FileStore{
+		path: path,
+		aead: aead,
+	}
+
+	// Fail fast if the file exists but cannot be decrypted with this key
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if _, err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Get retrieves a session by ID
+func (s *FileStore) Get(sessionID string) (models.Session, bool, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return models.Session{}, false, err
+	}
+	defer unlock()
+
+	sessions, err := s.load()
+	if err != nil {
+		return models.Session{}, false, err
+	}
+
+	session, ok := sessions[sessionID]
+	return session, ok, nil
+}
+
+// Save creates or replaces a session
+func (s *FileStore) Save(sessionID string, session models.Session) error {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sessions, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	sessions[sessionID] = session
+	return s.write(sessions)
+}
+
+// Delete removes a session by ID
+func (s *FileStore) Delete(sessionID string) error {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sessions, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := sessions[sessionID]; !ok {
+		return nil
+	}
+
+	delete(sessions, sessionID)
+	if err := s.write(sessions); err != nil {
+		return err
+	}
+	s.removeSessionLock(sessionID)
+	return nil
+}
+
+// DeleteCreatedBefore removes sessions created before cutoff
+func (s *FileStore) DeleteCreatedBefore(cutoff time.Time) (int, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	sessions, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+
+	var removed []string
+	for id, session := range sessions {
+		if session.CreatedAt.Before(cutoff) {
+			delete(sessions, id)
+			removed = append(removed, id)
+		}
+	}
+
+	if len(removed) == 0 {
+		return 0, nil
+	}
+	if err := s.write(sessions); err != nil {
+		return 0, err
+	}
+	for _, id := range removed {
+		s.removeSessionLock(id)
+	}
+	return len(removed), nil
+}
+
+// LockSession takes an exclusive lock on one session that every instance sharing the file respects
+func (s *FileStore) LockSession(sessionID string) (func(), error) {
+	if err := os.MkdirAll(s.path+".locks", 0700); err != nil {
+		return nil, fmt.Errorf("error creating session lock directory: %w", err)
+	}
+	f, err := os.OpenFile(s.sessionLockPath(sessionID), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening session lock file: %w", err)
+	}
+	if err := lockFile(f, true); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error locking session: %w", err)
+	}
+
+	// Closing the file releases the lock
+	return func() { f.Close() }, nil
+}
+
+// sessionLockPath returns the path of a session's lock file, named by a hash so session IDs don't appear on disk
+func (s *FileStore) sessionLockPath(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return filepath.Join(s.path+".locks", hex.EncodeToString(sum[:16]))
+}
+
+// removeSessionLock removes the lock file of a session that no longer exists
+func (s *FileStore) removeSessionLock(sessionID string) {
+	os.Remove(s.sessionLockPath(sessionID))
+}
+
+// lock takes the store's lock, shared for reading or exclusive for changing the file,
+// and returns the function that releases it
+func (s *FileStore) lock(exclusive bool) (func(), error) {
+	s.mu.Lock()
+
+	f, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("error opening session lock file: %w", err)
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		s.mu.Unlock()
+		return nil, fmt.Errorf("error locking session file: %w", err)
+	}
+
+	return func() {
+		// Closing the file releases the lock
+		f.Close()
+		s.mu.Unlock()
+	}, nil
+}
+
+// load reads the sessions from the file; a missing file holds no sessions.
+// The file is read every time, as other instances may have changed or removed it.
+func (s *FileStore) load() (map[string]models.Session, error) {
+	sessions := make(map[string]models.Session)
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return sessions, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading session file: %w", err)
+	}
+
+	nonceSize := s.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("session file is corrupt")
+	}
+
+	plaintext, err := s.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting session file (wrong key?): %w", err)
+	}
+
+	if err := json.Unmarshal(plaintext, &sessions); err != nil {
+		return nil, fmt.Errorf("error parsing session file: %w", err)
+	}
+	return sessions, nil
+}
+
+// write encrypts the sessions and atomically replaces the file
+func (s *FileStore) write(sessions map[string]models.Session) error {
+	plaintext, err := json.Marshal(sessions)
+	if err != nil {
+		return fmt.Errorf("error encoding sessions: %w", err)
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("error generating nonce: %w", err)
+	}
+	data := s.aead.Seal(nonce, nonce, plaintext, nil)
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".sessions-*")
+	if err != nil {
+		return fmt.Errorf("error writing session file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing session file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing session file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("error writing session file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/auth"
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	store, err := auth.NewFileStore(path, "secret")
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	session := models.Session{
+		AccessToken:  "access-token",
+		RefreshToken: "refresh-token",
+		ExpiresAt:    time.Now().Add(time.Hour).Round(0),
+		CreatedAt:    time.Now().Round(0),
+	}
+	if err := store.Save("s1", session); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// The file doesn't hold the tokens in the clear
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading the session file: %v", err)
+	}
+	if bytes.Contains(data, []byte("access-token")) || bytes.Contains(data, []byte("refresh-token")) {
+		t.Errorf("the session file isn't encrypted")
+	}
+
+	// A new store with the same key reads it back
+	reopened, err := auth.NewFileStore(path, "secret")
+	if err != nil {
+		t.Fatalf("reopening the store failed: %v", err)
+	}
+	got, ok, err := reopened.Get("s1")
+	if err != nil || !ok {
+		t.Fatalf("Get = %v, %v", ok, err)
+	}
+	if got.AccessToken != session.AccessToken || got.RefreshToken != session.RefreshToken || !got.ExpiresAt.Equal(session.ExpiresAt) || !got.CreatedAt.Equal(session.CreatedAt) {
+		t.Errorf("session = %+v, want %+v", got, session)
+	}
+
+	if err := reopened.Delete("s1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok, _ := store.Get("s1"); ok {
+		t.Errorf("a deleted session is still there")
+	}
+}
+
+func TestFileStoreWrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	store, err := auth.NewFileStore(path, "secret")
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	if err := store.Save("s1", models.Session{AccessToken: "token", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if _, err := auth.NewFileStore(path, "another secret"); err == nil {
+		t.Errorf("opening the file with the wrong key succeeded")
+	}
+	if _, err := auth.NewFileStore(path, ""); err == nil {
+		t.Errorf("opening the file without a key succeeded")
+	}
+}
+
+func TestFileStoreSweep(t *testing.T) {
+	store, err := auth.NewFileStore(filepath.Join(t.TempDir(), "sessions.json"), "secret")
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	now := time.Now()
+	store.Save("old", models.Session{CreatedAt: now.Add(-2 * auth.SessionMaxAge)})
+	store.Save("new", models.Session{CreatedAt: now})
+
+	manager := auth.NewSessionManager(nil, store)
+	removed, err := manager.SweepExpiredSessions()
+	if err != nil || removed != 1 {
+		t.Fatalf("SweepExpiredSessions = %d, %v, want 1", removed, err)
+	}
+	if _, ok, _ := store.Get("old"); ok {
+		t.Errorf("the expired session wasn't removed")
+	}
+	if _, ok, _ := store.Get("new"); !ok {
+		t.Errorf("the current session was removed")
+	}
+
+	// Nothing left to sweep
+	if removed, err := manager.SweepExpiredSessions(); err != nil || removed != 0 {
+		t.Errorf("second sweep = %d, %v, want 0", removed, err)
+	}
+}
+
+func TestFileStoreSharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	first, err := auth.NewFileStore(path, "secret")
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	second, err := auth.NewFileStore(path, "secret")
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	// Both stores write at once, and neither loses the other's sessions
+	const count = 20
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		for name, store := range map[string]*auth.FileStore{"first": first, "second": second} {
+			wg.Add(1)
+			go func(id string, store *auth.FileStore) {
+				defer wg.Done()
+				if err := store.Save(id, models.Session{AccessToken: id, CreatedAt: time.Now()}); err != nil {
+					t.Errorf("Save %s failed: %v", id, err)
+				}
+			}(fmt.Sprintf("%s-%d", name, i), store)
+		}
+	}
+	wg.Wait()
+
+	for i := 0; i < count; i++ {
+		for _, id := range []string{fmt.Sprintf("first-%d", i), fmt.Sprintf("second-%d", i)} {
+			if session, ok, err := first.Get(id); err != nil || !ok || session.AccessToken != id {
+				t.Errorf("Get %s = %+v, %v, %v", id, session, ok, err)
+			}
+		}
+	}
+
+	// One store sees the other's deletes, even when the file is removed
+	if err := second.Delete("first-0"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok, _ := first.Get("first-0"); ok {
+		t.Errorf("a session deleted by the other store is still there")
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("removing the session file: %v", err)
+	}
+	if _, ok, err := first.Get("second-0"); ok || err != nil {
+		t.Errorf("Get after the file was removed = %v, %v, want no session", ok, err)
+	}
+}
+
+// countingRefresher counts token refreshes, each taking delay
+type countingRefresher struct {
+	mu    sync.Mutex
+	calls int
+	delay time.Duration
+}
+
+func (r *countingRefresher) RefreshToken(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
+	r.mu.Lock()
+	r.calls++
+	n := r.calls
+	r.mu.Unlock()
+
+	time.Sleep(r.delay)
+	return &models.TokenResponse{AccessToken: fmt.Sprintf("access-%d", n), RefreshToken: fmt.Sprintf("refresh-%d", n), ExpiresIn: 3600}, nil
+}
+
+func TestFileStoreRefreshesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	refresher := &countingRefresher{delay: 20 * time.Millisecond}
+	var managers []*auth.SessionManager
+	for i := 0; i < 2; i++ {
+		store, err := auth.NewFileStore(path, "secret")
+		if err != nil {
+			t.Fatalf("NewFileStore failed: %v", err)
+		}
+		managers = append(managers, auth.NewSessionManager(refresher, store))
+	}
+
+	sessionID, err := managers[0].CreateSession(&models.TokenResponse{AccessToken: "expired", RefreshToken: "refresh-0", ExpiresIn: -60})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	// Instances sharing the file, each with several requests, refresh the session's tokens once between them
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(manager *auth.SessionManager) {
+			defer wg.Done()
+			if token, err := manager.AccessToken(context.Background(), sessionID); err != nil || token != "access-1" {
+				t.Errorf("AccessToken = %q, %v, want access-1", token, err)
+			}
+		}(managers[i%2])
+	}
+	wg.Wait()
+
+	if refresher.calls != 1 {
+		t.Errorf("tokens refreshed %d times, want once", refresher.calls)
+	}
+
+	// The session's lock file goes with it
+	managers[1].DeleteSession(sessionID)
+	if entries, _ := os.ReadDir(path + ".locks"); len(entries) != 0 {
+		t.Errorf("%d lock files left after the session was deleted", len(entries))
+	}
+}
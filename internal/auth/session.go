@@ -6,41 +6,84 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"fmt"
+	"log"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/coseguera/kanban-to-do/internal/models"
-	"github.com/coseguera/kanban-to-do/pkg/microsoft"
 )
 
+// Names of the short-lived cookies that hold the OAuth state and PKCE code verifier during login
+const (
+	stateCookieName    = "oauth_state"
+	verifierCookieName = "oauth_verifier"
+)
+
+// StateMaxAge is how long a login attempt has to complete before its state expires
+const StateMaxAge = 10 * time.Minute
+
+// SessionMaxAge is how long a session lives, matching the session cookie lifetime
+const SessionMaxAge = 24 * time.Hour
+
+// TokenRefresher exchanges a refresh token for a new access token
+type TokenRefresher interface {
+	RefreshToken(ctx context.Context, refreshToken string) (*models.TokenResponse, error)
+}
+
 // SessionManager manages user sessions
 type SessionManager struct {
-	sessions map[string]models.Session
-	mu       sync.RWMutex
-	client   *microsoft.Client
+	store  SessionStore
+	client TokenRefresher
+
+	// refreshing holds a lock for each session whose tokens are being checked or refreshed
+	mu         sync.Mutex
+	refreshing map[string]*sessionLock
 }
 
-// NewSessionManager creates a new session manager
-func NewSessionManager(client *microsoft.Client) *SessionManager {
+// sessionLock serializes token refreshes of one session; waiters counts its holder and those waiting for it
+type sessionLock struct {
+	mu      sync.Mutex
+	waiters int
+}
+
+// NewSessionManager creates a new session manager backed by the given store.
+// If store is nil, sessions are kept in memory.
+func NewSessionManager(client TokenRefresher, store SessionStore) *SessionManager {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
 	return &SessionManager{
-		sessions: make(map[string]models.Session),
-		client:   client,
+		store:      store,
+		client:     client,
+		refreshing: make(map[string]*sessionLock),
 	}
 }
 
 // CreateSession creates a new session from a token response
 func (sm *SessionManager) CreateSession(tokenResp *models.TokenResponse) (string, error) {
-	sessionID := fmt.Sprintf("%d", time.Now().UnixNano())
-
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	sessionID, err := GenerateRandomID()
+	if err != nil {
+		return "", fmt.Errorf("error generating session ID: %w", err)
+	}
 
-	sm.sessions[sessionID] = models.Session{
+	now := time.Now()
+	session := models.Session{
 		AccessToken:  tokenResp.AccessToken,
 		RefreshToken: tokenResp.RefreshToken,
-		ExpiresAt:    time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+		ExpiresAt:    now.Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+		CreatedAt:    now,
+	}
+
+	if err := sm.store.Save(sessionID, session); err != nil {
+		return "", err
 	}
 
 	return sessionID, nil
@@ -48,26 +91,41 @@ func (sm *SessionManager) CreateSession(tokenResp *models.TokenResponse) (string
 
 // GetSession retrieves a session by ID
 func (sm *SessionManager) GetSession(sessionID string) (models.Session, bool) {
-	sm.mu.RLock()
-	defer sm.mu.RUnlock()
+	session, ok, err := sm.store.Get(sessionID)
+	if err != nil {
+		log.Printf("Error reading session: %v", err)
+		return models.Session{}, false
+	}
+
+	if ok && time.Since(session.CreatedAt) > SessionMaxAge {
+		return models.Session{}, false
+	}
 
-	session, ok := sm.sessions[sessionID]
 	return session, ok
 }
 
-// RefreshSessionIfNeeded refreshes a session if the token is expired
-func (sm *SessionManager) RefreshSessionIfNeeded(sessionID string) error {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
+// RefreshSessionIfNeeded refreshes a session if the token is expired. Only one refresh of a session
+// runs at a time, in this instance or any other sharing a store that implements SessionLocker,
+// so a rotated refresh token is never used twice; other sessions aren't held up.
+func (sm *SessionManager) RefreshSessionIfNeeded(ctx context.Context, sessionID string) error {
+	unlock, err := sm.lockSession(sessionID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	session, ok := sm.sessions[sessionID]
+	// Read the session under the lock, as it may have just been refreshed elsewhere
+	session, ok, err := sm.store.Get(sessionID)
+	if err != nil {
+		return err
+	}
 	if !ok {
 		return fmt.Errorf("session not found")
 	}
 
 	// Check if the token is expired
 	if time.Now().After(session.ExpiresAt) {
-		tokenResp, err := sm.client.RefreshToken(session.RefreshToken)
+		tokenResp, err := sm.client.RefreshToken(ctx, session.RefreshToken)
 		if err != nil {
 			return err
 		}
@@ -78,18 +136,99 @@ func (sm *SessionManager) RefreshSessionIfNeeded(sessionID string) error {
 			session.RefreshToken = tokenResp.RefreshToken
 		}
 		session.ExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-		sm.sessions[sessionID] = session
+		if err := sm.store.Save(sessionID, session); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// lockSession takes the lock on refreshing a session and returns the function that releases it
+func (sm *SessionManager) lockSession(sessionID string) (func(), error) {
+	sm.mu.Lock()
+	l := sm.refreshing[sessionID]
+	if l == nil {
+		l = &sessionLock{}
+		sm.refreshing[sessionID] = l
+	}
+	l.waiters++
+	sm.mu.Unlock()
+
+	release := func() {
+		sm.mu.Lock()
+		if l.waiters--; l.waiters == 0 {
+			delete(sm.refreshing, sessionID)
+		}
+		sm.mu.Unlock()
+	}
+
+	l.mu.Lock()
+	unlockStore := func() {}
+	if locker, ok := sm.store.(SessionLocker); ok {
+		var err error
+		if unlockStore, err = locker.LockSession(sessionID); err != nil {
+			l.mu.Unlock()
+			release()
+			return nil, err
+		}
+	}
+
+	return func() {
+		unlockStore()
+		l.mu.Unlock()
+		release()
+	}, nil
+}
+
+// AccessToken returns a session's access token, refreshing it first if needed
+func (sm *SessionManager) AccessToken(ctx context.Context, sessionID string) (string, error) {
+	if err := sm.RefreshSessionIfNeeded(ctx, sessionID); err != nil {
+		return "", err
+	}
+
+	session, ok := sm.GetSession(sessionID)
+	if !ok {
+		return "", fmt.Errorf("session not found")
+	}
+	return session.AccessToken, nil
+}
+
 // DeleteSession deletes a session by ID
 func (sm *SessionManager) DeleteSession(sessionID string) {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	if err := sm.store.Delete(sessionID); err != nil {
+		log.Printf("Error deleting session: %v", err)
+	}
+}
 
-	delete(sm.sessions, sessionID)
+// SweepExpiredSessions removes sessions older than SessionMaxAge
+func (sm *SessionManager) SweepExpiredSessions() (int, error) {
+	return sm.store.DeleteCreatedBefore(time.Now().Add(-SessionMaxAge))
+}
+
+// StartSweeper periodically removes expired sessions until the returned stop function is called
+func (sm *SessionManager) StartSweeper(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				removed, err := sm.SweepExpiredSessions()
+				if err != nil {
+					log.Printf("Error sweeping expired sessions: %v", err)
+				} else if removed > 0 {
+					log.Printf("Removed %d expired sessions", removed)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
 }
 
 // SetSessionCookie sets a session cookie
@@ -100,7 +239,7 @@ func SetSessionCookie(w http.ResponseWriter, sessionID string) {
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   true,
-		MaxAge:   86400, // 1 day
+		MaxAge:   int(SessionMaxAge.Seconds()),
 	}
 	http.SetCookie(w, &cookie)
 }
@@ -126,3 +265,74 @@ func GetSessionFromRequest(r *http.Request) (string, error) {
 	}
 	return cookie.Value, nil
 }
+
+// GenerateRandomID returns a URL-safe identifier built from 32 bytes of crypto/rand
+func GenerateRandomID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GenerateCodeVerifier returns a random PKCE code verifier
+func GenerateCodeVerifier() (string, error) {
+	return GenerateRandomID()
+}
+
+// CodeChallengeS256 derives the S256 PKCE code challenge for a code verifier
+func CodeChallengeS256(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// SetPreLoginCookies stores the OAuth state and PKCE code verifier in short-lived cookies
+// that only the callback can read
+func SetPreLoginCookies(w http.ResponseWriter, state string, codeVerifier string) {
+	for name, value := range map[string]string{stateCookieName: state, verifierCookieName: codeVerifier} {
+		cookie := http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     "/auth/callback",
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode, // sent on the top-level redirect back from the identity provider
+			MaxAge:   int(StateMaxAge.Seconds()),
+		}
+		http.SetCookie(w, &cookie)
+	}
+}
+
+// ClearPreLoginCookies clears the OAuth state and PKCE code verifier cookies
+func ClearPreLoginCookies(w http.ResponseWriter) {
+	for _, name := range []string{stateCookieName, verifierCookieName} {
+		expiredCookie := http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/auth/callback",
+			HttpOnly: true,
+			Secure:   true,
+			MaxAge:   -1,
+		}
+		http.SetCookie(w, &expiredCookie)
+	}
+}
+
+// ValidateState reports whether the state returned by the identity provider
+// matches the one stored in the request's pre-login cookie
+func ValidateState(r *http.Request, state string) bool {
+	cookie, err := r.Cookie(stateCookieName)
+	if err != nil || cookie.Value == "" || state == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) == 1
+}
+
+// GetCodeVerifierFromRequest gets the PKCE code verifier from the pre-login cookie
+func GetCodeVerifierFromRequest(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(verifierCookieName)
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package auth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/auth"
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// callbackURL is where the identity provider sends the browser back to
+var callbackURL, _ = url.Parse("https://localhost:8443/auth/callback")
+
+// callbackRequest returns the request a browser holding jar's cookies makes to the callback
+func callbackRequest(jar http.CookieJar) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, callbackURL.String(), nil)
+	for _, cookie := range jar.Cookies(callbackURL) {
+		r.AddCookie(cookie)
+	}
+	return r
+}
+
+// storeCookies saves the cookies a response sets, as a browser would
+func storeCookies(t *testing.T, jar http.CookieJar, w *httptest.ResponseRecorder) {
+	t.Helper()
+	jar.SetCookies(callbackURL, w.Result().Cookies())
+}
+
+func TestValidateState(t *testing.T) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to create cookie jar: %v", err)
+	}
+
+	// No pre-login cookie
+	if auth.ValidateState(callbackRequest(jar), "state") {
+		t.Errorf("state accepted without a pre-login cookie")
+	}
+
+	w := httptest.NewRecorder()
+	auth.SetPreLoginCookies(w, "state", "verifier")
+	storeCookies(t, jar, w)
+
+	for _, tc := range []struct {
+		name  string
+		state string
+		want  bool
+	}{
+		{"matching", "state", true},
+		{"mismatched", "other-state", false},
+		{"prefix", "stat", false},
+		{"empty", "", false},
+	} {
+		if got := auth.ValidateState(callbackRequest(jar), tc.state); got != tc.want {
+			t.Errorf("%s state: ValidateState = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// The cookie expires with the login attempt; once it has, the browser no longer sends it
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge <= 0 || cookie.MaxAge > int(auth.StateMaxAge.Seconds()) {
+			t.Errorf("cookie %s lives for %ds, want up to %v", cookie.Name, cookie.MaxAge, auth.StateMaxAge)
+		}
+	}
+	w = httptest.NewRecorder()
+	auth.ClearPreLoginCookies(w)
+	storeCookies(t, jar, w)
+	if auth.ValidateState(callbackRequest(jar), "state") {
+		t.Errorf("state accepted after the pre-login cookie expired")
+	}
+}
+
+func TestGenerateRandomID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id, err := auth.GenerateRandomID()
+		if err != nil {
+			t.Fatalf("GenerateRandomID failed: %v", err)
+		}
+
+		// 32 random bytes, URL-safe base64 without padding
+		raw, err := base64.RawURLEncoding.DecodeString(id)
+		if err != nil || len(raw) != 32 {
+			t.Errorf("ID %q is not 32 bytes of URL-safe base64: %v", id, err)
+		}
+		if seen[id] {
+			t.Fatalf("ID %q generated twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestCodeChallengeS256(t *testing.T) {
+	// The example from RFC 7636, Appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	if got := auth.CodeChallengeS256(verifier); got != want {
+		t.Errorf("CodeChallengeS256(%q) = %q, want %q", verifier, got, want)
+	}
+}
+
+func TestGenerateCodeVerifier(t *testing.T) {
+	verifier, err := auth.GenerateCodeVerifier()
+	if err != nil {
+		t.Fatalf("GenerateCodeVerifier failed: %v", err)
+	}
+
+	// RFC 7636 allows 43 to 128 characters from the unreserved set
+	if len(verifier) < 43 || len(verifier) > 128 {
+		t.Errorf("verifier has %d characters, want 43 to 128", len(verifier))
+	}
+	for _, c := range verifier {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.ContainsRune("-._~", c)) {
+			t.Errorf("verifier %q has the character %q, outside the unreserved set", verifier, c)
+			break
+		}
+	}
+
+	if other, _ := auth.GenerateCodeVerifier(); other == verifier {
+		t.Errorf("two verifiers were the same")
+	}
+}
+
+// blockingRefresher refreshes tokens once release is closed
+type blockingRefresher struct {
+	release chan struct{}
+}
+
+func (r *blockingRefresher) RefreshToken(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
+	<-r.release
+	return &models.TokenResponse{AccessToken: "refreshed", RefreshToken: refreshToken, ExpiresIn: 3600}, nil
+}
+
+func TestRefreshDoesNotBlockOtherSessions(t *testing.T) {
+	refresher := &blockingRefresher{release: make(chan struct{})}
+	manager := auth.NewSessionManager(refresher, nil)
+	expired, _ := manager.CreateSession(&models.TokenResponse{AccessToken: "expired", ExpiresIn: -60})
+	current, _ := manager.CreateSession(&models.TokenResponse{AccessToken: "current", ExpiresIn: 3600})
+
+	refreshed := make(chan error, 1)
+	go func() { refreshed <- manager.RefreshSessionIfNeeded(context.Background(), expired) }()
+
+	// While one session waits on the identity provider, another carries on
+	done := make(chan error, 1)
+	go func() { done <- manager.RefreshSessionIfNeeded(context.Background(), current) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("RefreshSessionIfNeeded failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("a session with a current token waited for another session's refresh")
+	}
+
+	close(refresher.release)
+	if err := <-refreshed; err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if token, err := manager.AccessToken(context.Background(), expired); err != nil || token != "refreshed" {
+		t.Errorf("AccessToken = %q, %v, want the refreshed token", token, err)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// SessionStore persists user sessions by session ID
+type SessionStore interface {
+	// Get retrieves a session, reporting whether it exists
+	Get(sessionID string) (models.Session, bool, error)
+	// Save creates or replaces a session
+	Save(sessionID string, session models.Session) error
+	// Delete removes a session; deleting a missing session is not an error
+	Delete(sessionID string) error
+	// DeleteCreatedBefore removes every session created before cutoff and returns how many were removed
+	DeleteCreatedBefore(cutoff time.Time) (int, error)
+}
+
+// SessionLocker is implemented by stores that instances can share, so that only one of them
+// refreshes a session's tokens at a time
+type SessionLocker interface {
+	// LockSession blocks until it holds the lock on one session and returns the function that releases it.
+	// Other sessions can be read, changed and locked meanwhile.
+	LockSession(sessionID string) (func(), error)
+}
+
+// MemoryStore is a SessionStore that keeps sessions in process memory.
+// Sessions are lost when the server restarts.
+type MemoryStore struct {
+	sessions map[string]models.Session
+	mu       sync.RWMutex
+}
+
+// NewMemoryStore creates a new in-memory session store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string]models.Session),
+	}
+}
+
+// Get retrieves a session by ID
+func (s *MemoryStore) Get(sessionID string) (models.Session, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[sessionID]
+	return session, ok, nil
+}
+
+// Save creates or replaces a session
+func (s *MemoryStore) Save(sessionID string, session models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[sessionID] = session
+	return nil
+}
+
+// Delete removes a session by ID
+func (s *MemoryStore) Delete(sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+// DeleteCreatedBefore removes sessions created before cutoff
+func (s *MemoryStore) DeleteCreatedBefore(cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for id, session := range s.sessions {
+		if session.CreatedAt.Before(cutoff) {
+			delete(s.sessions, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
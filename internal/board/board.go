@@ -0,0 +1,226 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+// Package board maps tasks onto configurable Kanban columns
+package board
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// Config holds the default board definition and any per-list overrides
+type Config struct {
+	Default models.BoardDefinition            `json:"default"`
+	Lists   map[string]models.BoardDefinition `json:"lists,omitempty"`
+}
+
+// DefaultDefinition returns the built-in Not Started / Doing / Done board
+func DefaultDefinition() models.BoardDefinition {
+	return models.BoardDefinition{
+		Columns: []models.ColumnDefinition{
+			{Title: "Not Started"},
+			{Title: "Doing", Category: "Doing"},
+			{Title: "Done", Status: "completed"},
+		},
+	}
+}
+
+// DefaultConfig returns a config that uses the built-in board for every list
+func DefaultConfig() *Config {
+	return &Config{Default: DefaultDefinition()}
+}
+
+// LoadConfig reads a board config from a JSON file.
+// If the file has no default board, the built-in board is used.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading board config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing board config: %w", err)
+	}
+
+	if len(config.Default.Columns) == 0 {
+		config.Default = DefaultDefinition()
+	}
+
+	if err := Validate(config.Default); err != nil {
+		return nil, fmt.Errorf("invalid default board: %w", err)
+	}
+	for listID, def := range config.Lists {
+		if err := Validate(def); err != nil {
+			return nil, fmt.Errorf("invalid board for list %s: %w", listID, err)
+		}
+	}
+
+	return &config, nil
+}
+
+// ForList returns the board definition for a list
+func (c *Config) ForList(listID string) models.BoardDefinition {
+	if def, ok := c.Lists[listID]; ok {
+		return def
+	}
+	return c.Default
+}
+
+// Validate checks that a board has columns with unique, non-empty titles
+func Validate(def models.BoardDefinition) error {
+	if len(def.Columns) == 0 {
+		return fmt.Errorf("board has no columns")
+	}
+
+	seen := make(map[string]bool)
+	for _, col := range def.Columns {
+		if col.Title == "" {
+			return fmt.Errorf("column title is required")
+		}
+		if seen[col.Title] {
+			return fmt.Errorf("duplicate column %q", col.Title)
+		}
+		if col.WIPLimit < 0 {
+			return fmt.Errorf("column %q has a negative WIP limit", col.Title)
+		}
+		if col.Status != "" && !models.IsTaskStatus(col.Status) {
+			return fmt.Errorf("column %q has an unknown status %q", col.Title, col.Status)
+		}
+		seen[col.Title] = true
+	}
+
+	return nil
+}
+
+// Statuses returns the statuses a task on the given boards can be set to: not started, the statuses
+// of the boards' columns and completed, in the order of TaskStatuses, so no column is out of reach
+func Statuses(defs ...models.BoardDefinition) []models.StatusOption {
+	used := map[string]bool{"notStarted": true, "completed": true}
+	for _, def := range defs {
+		for _, col := range def.Columns {
+			used[col.Status] = true
+		}
+	}
+
+	var statuses []models.StatusOption
+	for _, status := range models.TaskStatuses {
+		if used[status.Value] {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// ColumnIndex returns the index of the column a task belongs in.
+// A status match outranks a category match, so a completed task marked "Doing" lands in a
+// "completed" column. Ties go to the earlier column, and tasks matching nothing go to the
+// catch-all column, or the first column if there is none.
+func ColumnIndex(def models.BoardDefinition, task models.Task) int {
+	best, bestScore := -1, -1
+	catchAll := -1
+
+	for i, col := range def.Columns {
+		if col.Status == "" && col.Category == "" {
+			if catchAll < 0 {
+				catchAll = i
+			}
+			continue
+		}
+
+		if col.Status != "" && task.Status != col.Status {
+			continue
+		}
+		if col.Category != "" && !hasCategory(task.Categories, col.Category) {
+			continue
+		}
+
+		score := 0
+		if col.Status != "" {
+			score += 2
+		}
+		if col.Category != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	if best >= 0 {
+		return best
+	}
+	if catchAll >= 0 {
+		return catchAll
+	}
+	return 0
+}
+
+// CountInColumn counts the tasks that belong in the column at index, ignoring the task with excludeID
+func CountInColumn(def models.BoardDefinition, tasks []models.Task, index int, excludeID string) int {
+	count := 0
+	for _, task := range tasks {
+		if task.ID != excludeID && ColumnIndex(def, task) == index {
+			count++
+		}
+	}
+	return count
+}
+
+// FindColumn returns the index of the column with the given title, or -1 if there is none
+func FindColumn(def models.BoardDefinition, title string) int {
+	for i, col := range def.Columns {
+		if col.Title == title {
+			return i
+		}
+	}
+	return -1
+}
+
+// Move computes the status and categories a task needs to land in a column:
+// the board's marker categories are replaced by the column's, and the status is
+// the column's or "notStarted" when the column doesn't define one.
+func Move(def models.BoardDefinition, task models.Task, col models.ColumnDefinition) (status string, categories []string) {
+	categories = []string{}
+	for _, cat := range task.Categories {
+		if !isMarker(def, cat) {
+			categories = append(categories, cat)
+		}
+	}
+	if col.Category != "" {
+		categories = append(categories, col.Category)
+	}
+
+	status = col.Status
+	if status == "" {
+		status = "notStarted"
+	}
+
+	return status, categories
+}
+
+// isMarker reports whether a category is used by any column of the board
+func isMarker(def models.BoardDefinition, category string) bool {
+	for _, col := range def.Columns {
+		if col.Category != "" && strings.EqualFold(col.Category, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCategory reports whether categories contains category, ignoring case
+func hasCategory(categories []string, category string) bool {
+	for _, cat := range categories {
+		if strings.EqualFold(cat, category) {
+			return true
+		}
+	}
+	return false
+}
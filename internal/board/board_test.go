@@ -0,0 +1,206 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package board_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/board"
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// reviewBoard has a catch-all column in the middle and columns matching on status, category or both
+var reviewBoard = models.BoardDefinition{Columns: []models.ColumnDefinition{
+	{Title: "Doing", Category: "Doing"},
+	{Title: "Backlog"},
+	{Title: "Review", Category: "Review"},
+	{Title: "Reviewed", Status: "completed", Category: "Review"},
+	{Title: "Done", Status: "completed"},
+}}
+
+func TestColumnIndex(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		def  models.BoardDefinition
+		task models.Task
+		want string
+	}{
+		{"default board, nothing set", board.DefaultDefinition(), models.Task{Status: "notStarted"}, "Not Started"},
+		{"default board, category", board.DefaultDefinition(), models.Task{Status: "notStarted", Categories: []string{"Doing"}}, "Doing"},
+		{"default board, status", board.DefaultDefinition(), models.Task{Status: "completed"}, "Done"},
+		{"status outranks category", board.DefaultDefinition(), models.Task{Status: "completed", Categories: []string{"Doing"}}, "Done"},
+		{"category matches ignoring case", board.DefaultDefinition(), models.Task{Categories: []string{"doing"}}, "Doing"},
+		{"status and category outrank status", reviewBoard, models.Task{Status: "completed", Categories: []string{"Review"}}, "Reviewed"},
+		{"status outranks an earlier category", reviewBoard, models.Task{Status: "completed", Categories: []string{"Doing"}}, "Done"},
+		{"ties go to the earlier column", reviewBoard, models.Task{Categories: []string{"Review", "Doing"}}, "Doing"},
+		{"unmatched goes to the catch-all", reviewBoard, models.Task{Status: "inProgress", Categories: []string{"Personal"}}, "Backlog"},
+		{"no catch-all uses the first column", models.BoardDefinition{Columns: []models.ColumnDefinition{
+			{Title: "Doing", Category: "Doing"},
+			{Title: "Done", Status: "completed"},
+		}}, models.Task{Status: "notStarted"}, "Doing"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.def.Columns[board.ColumnIndex(tc.def, tc.task)].Title; got != tc.want {
+				t.Errorf("ColumnIndex put the task in %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFindColumn(t *testing.T) {
+	for title, want := range map[string]int{
+		"Doing":   0,
+		"Backlog": 1,
+		"Done":    4,
+		"done":    -1, // titles match exactly
+		"":        -1,
+		"Someday": -1,
+	} {
+		if got := board.FindColumn(reviewBoard, title); got != want {
+			t.Errorf("FindColumn(%q) = %d, want %d", title, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		columns []models.ColumnDefinition
+		wantErr string
+	}{
+		{"default board", board.DefaultDefinition().Columns, ""},
+		{"no columns", nil, "no columns"},
+		{"untitled column", []models.ColumnDefinition{{Title: "Doing"}, {Category: "Review"}}, "title is required"},
+		{"duplicate title", []models.ColumnDefinition{{Title: "Doing"}, {Title: "Doing", Category: "Doing"}}, `duplicate column "Doing"`},
+		{"negative WIP limit", []models.ColumnDefinition{{Title: "Doing", WIPLimit: -1}}, "negative WIP limit"},
+		{"status column", []models.ColumnDefinition{{Title: "Waiting", Status: "waitingOnOthers"}}, ""},
+		{"unknown status", []models.ColumnDefinition{{Title: "Blocked", Status: "blocked"}}, `unknown status "blocked"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := board.Validate(models.BoardDefinition{Columns: tc.columns})
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Validate = %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	waiting := models.BoardDefinition{Columns: []models.ColumnDefinition{{Title: "Waiting", Status: "waitingOnOthers"}, {Title: "Later", Status: "deferred"}}}
+	for _, tc := range []struct {
+		name string
+		defs []models.BoardDefinition
+		want string
+	}{
+		{"default board", []models.BoardDefinition{board.DefaultDefinition()}, "notStarted,completed"},
+		{"status columns", []models.BoardDefinition{waiting}, "notStarted,waitingOnOthers,deferred,completed"},
+		{"several boards", []models.BoardDefinition{reviewBoard, waiting, board.DefaultDefinition()}, "notStarted,waitingOnOthers,deferred,completed"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, status := range board.Statuses(tc.defs...) {
+				got = append(got, status.Value)
+			}
+			if strings.Join(got, ",") != tc.want {
+				t.Errorf("Statuses = %v, want [%s]", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMove(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		task           models.Task
+		column         string
+		wantStatus     string
+		wantCategories string
+	}{
+		{"into a category column", models.Task{Categories: []string{"Personal"}}, "Doing", "notStarted", "Personal,Doing"},
+		{"between category columns", models.Task{Categories: []string{"Doing", "Personal"}}, "Review", "notStarted", "Personal,Review"},
+		{"markers removed ignoring case", models.Task{Categories: []string{"review"}}, "Backlog", "notStarted", ""},
+		{"into a status column", models.Task{Categories: []string{"Doing"}}, "Done", "completed", ""},
+		{"into a status and category column", models.Task{Status: "notStarted"}, "Reviewed", "completed", "Review"},
+		{"out of a status column", models.Task{Status: "completed"}, "Backlog", "notStarted", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			col := reviewBoard.Columns[board.FindColumn(reviewBoard, tc.column)]
+			status, categories := board.Move(reviewBoard, tc.task, col)
+			if status != tc.wantStatus || strings.Join(categories, ",") != tc.wantCategories {
+				t.Errorf("Move = %s %v, want %s [%s]", status, categories, tc.wantStatus, tc.wantCategories)
+			}
+
+			// The task lands where it was moved
+			moved := models.Task{Status: status, Categories: categories}
+			if got := reviewBoard.Columns[board.ColumnIndex(reviewBoard, moved)].Title; got != tc.column {
+				t.Errorf("moved task is in %q, want %q", got, tc.column)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+		return path
+	}
+
+	// A config without a default board gets the built-in one
+	config, err := board.LoadConfig(write("lists.json", `{"lists": {"work": {"columns": [{"title": "Backlog"}, {"title": "Done", "status": "completed"}]}}}`))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(config.Default.Columns) != 3 || len(config.ForList("work").Columns) != 2 || len(config.ForList("home").Columns) != 3 {
+		t.Errorf("unexpected boards: default %+v, work %+v", config.Default, config.ForList("work"))
+	}
+
+	// Invalid boards are refused
+	if _, err := board.LoadConfig(write("invalid.json", `{"lists": {"work": {"columns": [{"title": "Doing"}, {"title": "Doing"}]}}}`)); err == nil {
+		t.Errorf("a board with duplicate columns was loaded")
+	}
+}
+
+func TestCountInColumn(t *testing.T) {
+	tasks := []models.Task{
+		{ID: "1", Categories: []string{"Doing"}},
+		{ID: "2", Categories: []string{"doing", "Personal"}},
+		{ID: "3", Status: "completed", Categories: []string{"Doing"}}, // in Done, as status outranks category
+		{ID: "4"},
+		{ID: "5", Categories: []string{"Review"}},
+	}
+
+	for _, tc := range []struct {
+		name      string
+		column    string
+		excludeID string
+		want      int
+	}{
+		{"category column", "Doing", "", 2},
+		{"the task being moved isn't counted", "Doing", "1", 1},
+		{"excluding a task in another column", "Doing", "4", 2},
+		{"catch-all column", "Backlog", "", 1},
+		{"status column", "Done", "", 1},
+		{"empty column", "Reviewed", "", 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := board.CountInColumn(reviewBoard, tasks, board.FindColumn(reviewBoard, tc.column), tc.excludeID); got != tc.want {
+				t.Errorf("CountInColumn(%s, exclude %q) = %d, want %d", tc.column, tc.excludeID, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+// Package events broadcasts board changes to the browsers that have a list open
+package events
+
+import (
+	"log"
+	"sync"
+)
+
+// Event types sent to browsers
+const (
+	TaskCreated   = "taskCreated"
+	TaskUpdated   = "taskUpdated"
+	TaskDeleted   = "taskDeleted"
+	TaskReordered = "taskReordered"
+	ListDeleted   = "listDeleted" // the list itself was deleted; TaskID is empty
+	ListResync    = "listResync"  // the list changed in ways that weren't reported task by task; TaskID is empty
+)
+
+// bufferSize is how many events a subscriber can fall behind before events are dropped
+const bufferSize = 16
+
+// Event describes a change to a task on a list's board, or to the list
+type Event struct {
+	Type   string   `json:"type"`
+	ListID string   `json:"listId"`
+	TaskID string   `json:"taskId"`
+	Source string   `json:"source,omitempty"` // ID of the browser that made the change
+	Order  []string `json:"order,omitempty"`  // column order after a TaskReordered event
+}
+
+// Hub fans out events to the subscribers of each list
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan Event]struct{}
+}
+
+// NewHub creates a new Hub
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[string]map[chan Event]struct{})}
+}
+
+// Subscribe registers for events on one or more lists, all sent to the same channel.
+// The returned function unsubscribes and must be called when the subscriber goes away.
+func (h *Hub) Subscribe(listIDs ...string) (<-chan Event, func()) {
+	ch := make(chan Event, bufferSize)
+
+	h.mu.Lock()
+	for _, listID := range listIDs {
+		if h.subscribers[listID] == nil {
+			h.subscribers[listID] = make(map[chan Event]struct{})
+		}
+		h.subscribers[listID][ch] = struct{}{}
+	}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			for _, listID := range listIDs {
+				delete(h.subscribers[listID], ch)
+				if len(h.subscribers[listID]) == 0 {
+					delete(h.subscribers, listID)
+				}
+			}
+		})
+	}
+}
+
+// Publish sends an event to every subscriber of its list without blocking.
+// Subscribers whose buffer is full miss the event.
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[event.ListID] {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("Dropping %s event for list %s: subscriber is not keeping up", event.Type, event.ListID)
+		}
+	}
+}
+
+// Subscribers returns the number of subscribers to a list
+func (h *Hub) Subscribers(listID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscribers[listID])
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package events
+
+import "testing"
+
+func TestPublishReachesListSubscribers(t *testing.T) {
+	hub := NewHub()
+
+	work, unsubscribeWork := hub.Subscribe("work")
+	defer unsubscribeWork()
+	home, unsubscribeHome := hub.Subscribe("home")
+	defer unsubscribeHome()
+
+	hub.Publish(Event{Type: TaskUpdated, ListID: "work", TaskID: "task-1"})
+
+	select {
+	case event := <-work:
+		if event.Type != TaskUpdated || event.TaskID != "task-1" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	default:
+		t.Fatalf("subscriber to the list did not receive the event")
+	}
+
+	select {
+	case event := <-home:
+		t.Errorf("subscriber to another list received %+v", event)
+	default:
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	hub := NewHub()
+
+	_, unsubscribe := hub.Subscribe("work")
+	if n := hub.Subscribers("work"); n != 1 {
+		t.Fatalf("Subscribers = %d, want 1", n)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if n := hub.Subscribers("work"); n != 0 {
+		t.Errorf("Subscribers after unsubscribe = %d, want 0", n)
+	}
+}
+
+func TestSubscribeToSeveralLists(t *testing.T) {
+	hub := NewHub()
+
+	ch, unsubscribe := hub.Subscribe("work", "home")
+
+	hub.Publish(Event{Type: TaskCreated, ListID: "work", TaskID: "task-1"})
+	hub.Publish(Event{Type: TaskDeleted, ListID: "home", TaskID: "task-2"})
+	hub.Publish(Event{Type: TaskUpdated, ListID: "errands", TaskID: "task-3"})
+
+	if len(ch) != 2 {
+		t.Fatalf("received %d events, want 2", len(ch))
+	}
+	if first, second := <-ch, <-ch; first.ListID != "work" || second.ListID != "home" {
+		t.Errorf("unexpected events: %+v, %+v", first, second)
+	}
+
+	unsubscribe()
+	if n := hub.Subscribers("work") + hub.Subscribers("home"); n != 0 {
+		t.Errorf("Subscribers after unsubscribe = %d, want 0", n)
+	}
+}
+
+func TestPublishDoesNotBlockOnSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+
+	ch, unsubscribe := hub.Subscribe("work")
+	defer unsubscribe()
+
+	for i := 0; i < bufferSize*2; i++ {
+		hub.Publish(Event{Type: TaskUpdated, ListID: "work"})
+	}
+
+	if len(ch) != bufferSize {
+		t.Errorf("buffered %d events, want %d", len(ch), bufferSize)
+	}
+}
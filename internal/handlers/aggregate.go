@@ -0,0 +1,210 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/coseguera/kanban-to-do/internal/auth"
+	"github.com/coseguera/kanban-to-do/internal/board"
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/ordering"
+	"github.com/coseguera/kanban-to-do/internal/templates"
+)
+
+// aggregateFetchLimit is how many lists an aggregate board reads at the same time,
+// so a user with many lists doesn't get throttled by Microsoft Graph
+const aggregateFetchLimit = 4
+
+// AggregateBoardHandler handles the board showing the tasks of all the user's lists,
+// or only of the lists named by listId query parameters
+func (h *Handler) AggregateBoardHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	// Get the to-do lists
+	todoLists, err := h.Client.GetTodoLists(r.Context(), session.AccessToken)
+	if err != nil {
+		writeClientError(w, "Error getting to-do lists", err)
+		return
+	}
+
+	// Keep only the selected lists, if any were picked
+	lists := todoLists.Value
+	if selected := r.URL.Query()["listId"]; len(selected) > 0 {
+		if lists, ok = selectLists(lists, selected); !ok {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	// Get every list's tasks
+	listTasks, err := h.aggregateTasks(r.Context(), sessionID, session.AccessToken, lists)
+	if err != nil {
+		writeClientError(w, "Error getting tasks", err)
+		return
+	}
+
+	// Lists can have their own boards, so the aggregate board uses the default columns.
+	// WIP limits apply to each list, so they aren't shown here.
+	boardDef := h.Boards.Default
+	columns := make([]models.KanbanColumn, len(boardDef.Columns))
+	for i, col := range boardDef.Columns {
+		columns[i] = models.KanbanColumn{Title: col.Title, Tasks: []models.TaskDisplay{}}
+	}
+
+	// Add each list's cards in turn, so cards from the same list stay together in its manual order
+	shown := []models.TodoList{}
+	for i, list := range lists {
+		if listTasks[i] == nil {
+			continue
+		}
+		shown = append(shown, list)
+
+		// Hear about changes made to the list in other apps
+		h.watchList(sessionID, list.ID)
+
+		listColumns := make([][]models.TaskDisplay, len(columns))
+		for _, task := range listTasks[i] {
+			taskDisplay := newTaskDisplay(task)
+			taskDisplay.ListID = list.ID
+			taskDisplay.ListName = list.DisplayName
+
+			c := aggregateColumnIndex(boardDef, h.Boards.ForList(list.ID), task)
+			listColumns[c] = append(listColumns[c], taskDisplay)
+		}
+
+		ranks, err := h.Order.Ranks(list.ID)
+		if err != nil {
+			log.Printf("Error reading card order for list %s: %v", list.ID, err)
+		}
+		for c := range listColumns {
+			ordering.Sort(listColumns[c], func(t models.TaskDisplay) string { return t.ID }, ranks)
+			columns[c].Tasks = append(columns[c].Tasks, listColumns[c]...)
+			columns[c].Count += len(listColumns[c])
+		}
+	}
+
+	// Offer the statuses of every shown list's board, so editing a task keeps it in its own list's column
+	boardDefs := []models.BoardDefinition{boardDef}
+	for _, list := range shown {
+		boardDefs = append(boardDefs, h.Boards.ForList(list.ID))
+	}
+
+	// Create TaskViewModel with Kanban columns
+	taskViewModel := models.TaskViewModel{
+		ListName: "All Lists",
+		Columns:  columns,
+		Statuses: board.Statuses(boardDefs...),
+		Lists:    shown,
+	}
+
+	// Render the template
+	tmpl := templates.Templates["tasks"]
+	if tmpl == nil {
+		http.Error(w, "Error loading template", http.StatusInternalServerError)
+		return
+	}
+	tmpl.Execute(w, taskViewModel)
+}
+
+// selectLists returns the lists whose IDs are in ids, in their original order,
+// and whether every ID was found
+func selectLists(lists []models.TodoList, ids []string) ([]models.TodoList, bool) {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	selected := []models.TodoList{}
+	for _, list := range lists {
+		if wanted[list.ID] {
+			selected = append(selected, list)
+		}
+	}
+	return selected, len(selected) == len(wanted)
+}
+
+// aggregateTasks reads the tasks of several lists concurrently, returning them in the order of lists.
+// A list deleted since the lists were read is left nil; any other error fails the whole read.
+func (h *Handler) aggregateTasks(ctx context.Context, sessionID string, accessToken string, lists []models.TodoList) ([][]models.Task, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]models.Task, len(lists))
+	limit := make(chan struct{}, aggregateFetchLimit)
+
+	// Keep the error that stopped the read, not the cancellations it caused
+	var firstErr error
+	var once sync.Once
+
+	var wg sync.WaitGroup
+	for i, list := range lists {
+		wg.Add(1)
+		go func(i int, listID string) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			tasks, err := h.Cache.Tasks(ctx, sessionID, accessToken, listID)
+			if err != nil {
+				if !isNotFound(err) {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+				return
+			}
+			if tasks == nil {
+				tasks = []models.Task{}
+			}
+			results[i] = tasks
+		}(i, list.ID)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// aggregateColumnIndex finds the aggregate board column for a task: the column with the same title
+// as the one the task is in on its own list's board, or else where the aggregate board would put it.
+// A task the aggregate board puts in a column its own list's board lacks stays in it, so a card dropped
+// into such a column doesn't jump back to another one when the board reloads.
+func aggregateColumnIndex(boardDef models.BoardDefinition, listBoard models.BoardDefinition, task models.Task) int {
+	own := board.ColumnIndex(boardDef, task)
+	if board.FindColumn(listBoard, boardDef.Columns[own].Title) < 0 {
+		return own
+	}
+
+	title := listBoard.Columns[board.ColumnIndex(listBoard, task)].Title
+	if i := board.FindColumn(boardDef, title); i >= 0 {
+		return i
+	}
+	return own
+}
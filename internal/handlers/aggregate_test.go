@@ -0,0 +1,171 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/board"
+	"github.com/coseguera/kanban-to-do/internal/events"
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// columnOf returns the title of the board column a card is rendered in, or "" if it isn't on the page
+func columnOf(page string, title string) string {
+	card := strings.Index(page, `<div class="task-title">`+title+`</div>`)
+	if card < 0 {
+		return ""
+	}
+	column := strings.LastIndex(page[:card], `data-column="`)
+	if column < 0 {
+		return ""
+	}
+	rest := page[column+len(`data-column="`):]
+	return rest[:strings.Index(rest, `"`)]
+}
+
+func TestAggregateBoard(t *testing.T) {
+	boards := board.DefaultConfig()
+	app := newTestApp(t, boards)
+	work := app.graph.AddList("Work")
+	home := app.graph.AddList("Home")
+	errands := app.graph.AddList("Errands")
+	app.graph.AddTask(work.ID, models.Task{Title: "Write report", Categories: []string{"Doing"}})
+	app.graph.AddTask(work.ID, models.Task{Title: "File expenses", Categories: []string{"Waiting"}})
+	fence := app.graph.AddTask(home.ID, models.Task{Title: "Paint fence", Status: "notStarted"})
+	app.graph.AddTask(home.ID, models.Task{Title: "Mow lawn", Status: "completed"})
+	app.graph.AddTask(errands.ID, models.Task{Title: "Buy milk"})
+
+	// Work has its own board; its Waiting column isn't on the default board
+	boards.Lists = map[string]models.BoardDefinition{work.ID: {Columns: []models.ColumnDefinition{
+		{Title: "Not Started"},
+		{Title: "Waiting", Category: "Waiting", WIPLimit: 1},
+		{Title: "Doing", Category: "Doing"},
+		{Title: "Done", Status: "completed"},
+	}}}
+	app.login(t)
+
+	// Every list's tasks are bucketed into the default columns and tagged with their list
+	resp, page := app.get(t, "/board")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, page)
+	}
+	for title, want := range map[string]string{
+		"Write report":  "Doing",
+		"File expenses": "Not Started",
+		"Paint fence":   "Not Started",
+		"Mow lawn":      "Done",
+		"Buy milk":      "Not Started",
+	} {
+		if got := columnOf(page, title); got != want {
+			t.Errorf("%q is in column %q, want %q", title, got, want)
+		}
+	}
+	for _, want := range []string{
+		`data-list-id="` + work.ID + `"`,
+		`<span class="task-list-tag" title="List">Home</span>`,
+		`class="board-list" value="` + errands.ID + `"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("board is missing %q", want)
+		}
+	}
+	if strings.Contains(page, `id="newTaskTitle"`) || strings.Contains(page, `data-column="Waiting"`) {
+		t.Errorf("aggregate board should have no add-task box or list-specific columns")
+	}
+
+	// Only the selected lists are shown
+	_, page = app.get(t, "/board?"+url.Values{"listId": {work.ID, home.ID}}.Encode())
+	if columnOf(page, "Buy milk") != "" || columnOf(page, "Paint fence") == "" {
+		t.Errorf("selected board shows the wrong lists")
+	}
+	if resp, _ := app.get(t, "/board?listId=missing"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown list: status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	// Dragging a card updates the task in its own list, and the board hears about it
+	nextEvent := app.openEvents(t, work.ID, home.ID)
+	resp, body := app.post(t, "/api/updateTask", url.Values{"listId": {home.ID}, "taskId": {fence.ID}, "column": {"Doing"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if updated, _ := app.graph.Task(home.ID, fence.ID); len(updated.Categories) != 1 || updated.Categories[0] != "Doing" {
+		t.Errorf("categories = %v, want [Doing]", updated.Categories)
+	}
+	name, data := nextEvent()
+	var event events.Event
+	if err := json.Unmarshal([]byte(data), &event); err != nil || name != "taskUpdated" || event.ListID != home.ID || event.TaskID != fence.ID {
+		t.Errorf("unexpected event %s: %s", name, data)
+	}
+}
+
+func TestAggregateBoardDropOnColumnListLacks(t *testing.T) {
+	boards := board.DefaultConfig()
+	app := newTestApp(t, boards)
+	work := app.graph.AddList("Work")
+	task := app.graph.AddTask(work.ID, models.Task{Title: "Check report", Categories: []string{"Review", "Urgent"}})
+
+	// Work's board has no Doing column
+	boards.Lists = map[string]models.BoardDefinition{work.ID: {Columns: []models.ColumnDefinition{
+		{Title: "Backlog"},
+		{Title: "Review", Category: "Review", WIPLimit: 1},
+		{Title: "Done", Status: "completed"},
+	}}}
+	app.login(t)
+
+	// Dropping the card into the aggregate board's Doing column gives it the Doing category instead of Review
+	resp, body := app.post(t, "/api/updateTask", url.Values{"listId": {work.ID}, "taskId": {task.ID}, "column": {"Doing"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	updated, _ := app.graph.Task(work.ID, task.ID)
+	if strings.Join(updated.Categories, ",") != "Urgent,Doing" || updated.Status != "notStarted" {
+		t.Errorf("task = %s %v, want notStarted [Urgent Doing]", updated.Status, updated.Categories)
+	}
+
+	// The card stays where it was dropped
+	_, page := app.get(t, "/board")
+	if got := columnOf(page, "Check report"); got != "Doing" {
+		t.Errorf("card is in column %q, want Doing", got)
+	}
+
+	// Columns on neither board are still refused
+	if resp, _ := app.post(t, "/api/updateTask", url.Values{"listId": {work.ID}, "taskId": {task.ID}, "column": {"Someday"}}); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown column: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestAggregateEventsCheckListsOnce(t *testing.T) {
+	app := newTestApp(t, nil)
+	var listIDs []string
+	for _, name := range []string{"Work", "Home", "Errands"} {
+		listIDs = append(listIDs, app.graph.AddList(name).ID)
+	}
+	app.login(t)
+
+	// Every list is checked with a single read of the user's lists, not one read per list
+	before := len(app.graph.Requests())
+	app.openEvents(t, listIDs...)
+	reads := 0
+	for _, req := range app.graph.Requests()[before:] {
+		_, rest, found := strings.Cut(req.Path, "/lists")
+		if found && req.Method == http.MethodGet && strings.Count(rest, "/") <= 1 {
+			reads++
+		}
+	}
+	if reads != 1 {
+		t.Errorf("the lists were read %d times, want once", reads)
+	}
+
+	// A list the user can't see is refused
+	resp, _ := app.get(t, "/api/events?"+url.Values{"listId": {listIDs[0], "missing"}}.Encode())
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown list: status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
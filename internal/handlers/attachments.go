@@ -0,0 +1,464 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/coseguera/kanban-to-do/internal/auth"
+	"github.com/coseguera/kanban-to-do/internal/events"
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+const (
+	// MaxAttachmentSize is the largest file that can be attached to a task, the Microsoft To Do limit
+	MaxAttachmentSize = 25 << 20
+	// linkApplicationName is the app shown as the source of links added from the board
+	linkApplicationName = "Kanban To-Do"
+)
+
+// LinkedResourcesHandler handles listing a task's links to items in other apps
+func (h *Handler) LinkedResourcesHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the query parameters
+	listID := r.URL.Query().Get("listId")
+	taskID := r.URL.Query().Get("taskId")
+
+	if listID == "" || taskID == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Get the links
+	links, err := h.Client.GetLinkedResources(r.Context(), session.AccessToken, listID, taskID)
+	if err != nil {
+		writeClientError(w, "Error fetching links", err)
+		return
+	}
+	if links == nil {
+		links = []models.LinkedResource{}
+	}
+
+	// Convert to JSON and send response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(links); err != nil {
+		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateLinkedResourceHandler handles linking a task to a web page, such as a pull request or a document
+func (h *Handler) CreateLinkedResourceHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	listID := r.FormValue("listId")
+	taskID := r.FormValue("taskId")
+	webURL := strings.TrimSpace(r.FormValue("webUrl"))
+	displayName := strings.TrimSpace(r.FormValue("displayName"))
+
+	if listID == "" || taskID == "" || webURL == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Only web links are shown as clickable links on the board
+	parsed, err := url.Parse(webURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		http.Error(w, "Invalid webUrl: must be an http or https URL", http.StatusBadRequest)
+		return
+	}
+	if displayName == "" {
+		displayName = webURL
+	}
+
+	// Add the link
+	link, err := h.Client.CreateLinkedResource(r.Context(), session.AccessToken, listID, taskID, models.LinkedResource{
+		WebURL:          webURL,
+		ApplicationName: linkApplicationName,
+		DisplayName:     displayName,
+	})
+	if err != nil {
+		writeClientError(w, "Error creating link", err)
+		return
+	}
+
+	// Let other open boards know the task changed
+	h.listChanged(r, events.Event{Type: events.TaskUpdated, ListID: listID, TaskID: taskID})
+
+	// Convert to JSON and send response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(link); err != nil {
+		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteLinkedResourceHandler handles removing a link from a task
+func (h *Handler) DeleteLinkedResourceHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	listID := r.FormValue("listId")
+	taskID := r.FormValue("taskId")
+	resourceID := r.FormValue("resourceId")
+
+	if listID == "" || taskID == "" || resourceID == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Delete the link
+	if err := h.Client.DeleteLinkedResource(r.Context(), session.AccessToken, listID, taskID, resourceID); err != nil {
+		writeClientError(w, "Error deleting link", err)
+		return
+	}
+
+	// Let other open boards know the task changed
+	h.listChanged(r, events.Event{Type: events.TaskUpdated, ListID: listID, TaskID: taskID})
+
+	// Send a success response
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Link deleted successfully"))
+}
+
+// AttachmentsHandler handles listing the files attached to a task
+func (h *Handler) AttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the query parameters
+	listID := r.URL.Query().Get("listId")
+	taskID := r.URL.Query().Get("taskId")
+
+	if listID == "" || taskID == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Get the attachments
+	attachments, err := h.Client.GetAttachments(r.Context(), session.AccessToken, listID, taskID)
+	if err != nil {
+		writeClientError(w, "Error fetching attachments", err)
+		return
+	}
+	if attachments == nil {
+		attachments = []models.Attachment{}
+	}
+
+	// Convert to JSON and send response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(attachments); err != nil {
+		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// UploadAttachmentHandler handles attaching a file, sent as multipart form data, to a task
+func (h *Handler) UploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the form, leaving room for the other fields around the file
+	r.Body = http.MaxBytesReader(w, r.Body, MaxAttachmentSize+1<<20)
+	if err := r.ParseMultipartForm(MaxAttachmentSize); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "File is too large: attachments can be at most 25 MB", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	listID := r.FormValue("listId")
+	taskID := r.FormValue("taskId")
+	file, header, err := r.FormFile("file")
+
+	if listID == "" || taskID == "" || err != nil {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > MaxAttachmentSize {
+		http.Error(w, "File is too large: attachments can be at most 25 MB", http.StatusRequestEntityTooLarge)
+		return
+	}
+	content, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "Error reading file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Attach the file
+	attachment, err := h.Client.AddAttachment(r.Context(), session.AccessToken, listID, taskID, filepath.Base(header.Filename), header.Header.Get("Content-Type"), content)
+	if err != nil {
+		writeClientError(w, "Error attaching file", err)
+		return
+	}
+
+	// Let other open boards know the card's attachment marker may have changed
+	h.listChanged(r, events.Event{Type: events.TaskUpdated, ListID: listID, TaskID: taskID})
+
+	// Convert to JSON and send response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(attachment); err != nil {
+		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DownloadAttachmentHandler handles downloading a file attached to a task
+func (h *Handler) DownloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the query parameters
+	listID := r.URL.Query().Get("listId")
+	taskID := r.URL.Query().Get("taskId")
+	attachmentID := r.URL.Query().Get("attachmentId")
+
+	if listID == "" || taskID == "" || attachmentID == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Get the attachment with its content
+	attachment, err := h.Client.GetAttachment(r.Context(), session.AccessToken, listID, taskID, attachmentID)
+	if err != nil {
+		writeClientError(w, "Error fetching attachment", err)
+		return
+	}
+
+	// Always download rather than display, so attached HTML can't run on the board's origin
+	contentType := attachment.ContentType
+	if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(attachment.ContentBytes)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(attachment.ContentBytes)
+}
+
+// DeleteAttachmentHandler handles removing a file from a task
+func (h *Handler) DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	listID := r.FormValue("listId")
+	taskID := r.FormValue("taskId")
+	attachmentID := r.FormValue("attachmentId")
+
+	if listID == "" || taskID == "" || attachmentID == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Delete the attachment
+	if err := h.Client.DeleteAttachment(r.Context(), session.AccessToken, listID, taskID, attachmentID); err != nil {
+		writeClientError(w, "Error deleting attachment", err)
+		return
+	}
+
+	// Let other open boards know the card's attachment marker may have changed
+	h.listChanged(r, events.Event{Type: events.TaskUpdated, ListID: listID, TaskID: taskID})
+
+	// Send a success response
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Attachment deleted successfully"))
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// upload attaches a file to a task through the board server
+func (a *testApp) upload(t *testing.T, listID string, taskID string, name string, content []byte) (*http.Response, string) {
+	t.Helper()
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	writer.WriteField("listId", listID)
+	writer.WriteField("taskId", taskID)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	resp, err := a.client.Post(a.URL+"/api/uploadAttachment", writer.FormDataContentType(), &form)
+	if err != nil {
+		t.Fatalf("POST /api/uploadAttachment failed: %v", err)
+	}
+	return resp, readBody(t, resp)
+}
+
+func TestLinkedResources(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Review"})
+	app.login(t)
+
+	// Add a link; the title defaults to the URL
+	resp, body := app.post(t, "/api/createLinkedResource", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "webUrl": {"https://example.com/pr/1"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	var link models.LinkedResource
+	if err := json.Unmarshal([]byte(body), &link); err != nil || link.ID == "" || link.DisplayName != "https://example.com/pr/1" || link.ApplicationName == "" {
+		t.Fatalf("unexpected link %q: %v", body, err)
+	}
+
+	// Only web links are accepted
+	for _, webURL := range []string{"javascript:alert(1)", "example.com/pr/1", ""} {
+		resp, _ = app.post(t, "/api/createLinkedResource", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "webUrl": {webURL}})
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("webUrl %q: status = %d, want %d", webURL, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+
+	resp, body = app.get(t, "/api/linkedResources?listId="+list.ID+"&taskId="+task.ID)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	var links []models.LinkedResource
+	if err := json.Unmarshal([]byte(body), &links); err != nil || len(links) != 1 || links[0].WebURL != "https://example.com/pr/1" {
+		t.Fatalf("unexpected links %q: %v", body, err)
+	}
+
+	// Remove it
+	resp, body = app.post(t, "/api/deleteLinkedResource", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "resourceId": {link.ID}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	_, body = app.get(t, "/api/linkedResources?listId="+list.ID+"&taskId="+task.ID)
+	if strings.TrimSpace(body) != "[]" {
+		t.Errorf("links after delete = %s, want []", body)
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Spec"})
+	app.login(t)
+
+	// Attach a file
+	resp, body := app.upload(t, list.ID, task.ID, "notes.txt", []byte("first draft"))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	var attachment models.Attachment
+	if err := json.Unmarshal([]byte(body), &attachment); err != nil || attachment.ID == "" || attachment.Name != "notes.txt" || attachment.Size != 11 {
+		t.Fatalf("unexpected attachment %q: %v", body, err)
+	}
+
+	resp, body = app.get(t, "/api/attachments?listId="+list.ID+"&taskId="+task.ID)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	var attachments []models.Attachment
+	if err := json.Unmarshal([]byte(body), &attachments); err != nil || len(attachments) != 1 || attachments[0].ContentBytes != nil {
+		t.Fatalf("unexpected attachments %q: %v", body, err)
+	}
+
+	// The card is marked
+	_, page := app.get(t, "/list/"+list.ID+"/tasks")
+	if !strings.Contains(page, `title="Has attachments"`) {
+		t.Errorf("tasks page lacks the attachment marker")
+	}
+
+	// Download it
+	resp, body = app.get(t, "/api/downloadAttachment?listId="+list.ID+"&taskId="+task.ID+"&attachmentId="+attachment.ID)
+	if resp.StatusCode != http.StatusOK || body != "first draft" {
+		t.Fatalf("status = %d, body = %q", resp.StatusCode, body)
+	}
+	if disposition := resp.Header.Get("Content-Disposition"); disposition != `attachment; filename=notes.txt` {
+		t.Errorf("Content-Disposition = %q", disposition)
+	}
+
+	// Remove it
+	resp, body = app.post(t, "/api/deleteAttachment", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "attachmentId": {attachment.ID}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	_, body = app.get(t, "/api/getTaskDetails?listId="+list.ID+"&taskId="+task.ID)
+	var details map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &details); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", body, err)
+	}
+	if details["hasAttachments"] != false {
+		t.Errorf("hasAttachments = %v after deleting the only attachment", details["hasAttachments"])
+	}
+
+	// A missing file is rejected
+	resp, _ = app.post(t, "/api/uploadAttachment", url.Values{"listId": {list.ID}, "taskId": {task.ID}})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("upload without a file: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
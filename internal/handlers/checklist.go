@@ -0,0 +1,304 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/coseguera/kanban-to-do/internal/auth"
+	"github.com/coseguera/kanban-to-do/internal/events"
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// ChecklistItemsHandler handles listing a task's checklist items
+func (h *Handler) ChecklistItemsHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the query parameters
+	listID := r.URL.Query().Get("listId")
+	taskID := r.URL.Query().Get("taskId")
+
+	if listID == "" || taskID == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Get the checklist
+	items, err := h.Client.GetChecklistItems(r.Context(), session.AccessToken, listID, taskID)
+	if err != nil {
+		writeClientError(w, "Error fetching checklist", err)
+		return
+	}
+	if items == nil {
+		items = []models.ChecklistItem{}
+	}
+
+	// Convert to JSON and send response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(items); err != nil {
+		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateChecklistItemHandler handles adding an item to a task's checklist
+func (h *Handler) CreateChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	listID := r.FormValue("listId")
+	taskID := r.FormValue("taskId")
+	displayName := strings.TrimSpace(r.FormValue("displayName"))
+
+	if listID == "" || taskID == "" || displayName == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Add the item
+	item, err := h.Client.CreateChecklistItem(r.Context(), session.AccessToken, listID, taskID, displayName)
+	if err != nil {
+		writeClientError(w, "Error creating checklist item", err)
+		return
+	}
+
+	// Let other open boards know the card's progress changed
+	h.listChanged(r, events.Event{Type: events.TaskUpdated, ListID: listID, TaskID: taskID})
+
+	// Send the new item
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(item); err != nil {
+		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// UpdateChecklistItemHandler handles renaming a checklist item
+func (h *Handler) UpdateChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	listID := r.FormValue("listId")
+	taskID := r.FormValue("taskId")
+	itemID := r.FormValue("itemId")
+	displayName := strings.TrimSpace(r.FormValue("displayName"))
+
+	if listID == "" || taskID == "" || itemID == "" || displayName == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Rename the item
+	if err := h.Client.UpdateChecklistItem(r.Context(), session.AccessToken, listID, taskID, itemID, displayName); err != nil {
+		writeClientError(w, "Error updating checklist item", err)
+		return
+	}
+
+	// Let other open boards know the card changed
+	h.listChanged(r, events.Event{Type: events.TaskUpdated, ListID: listID, TaskID: taskID})
+
+	// Send a success response
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Checklist item updated successfully"))
+}
+
+// ToggleChecklistItemHandler handles checking or unchecking a checklist item
+func (h *Handler) ToggleChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	listID := r.FormValue("listId")
+	taskID := r.FormValue("taskId")
+	itemID := r.FormValue("itemId")
+	isChecked := r.FormValue("isChecked")
+
+	if listID == "" || taskID == "" || itemID == "" || isChecked == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Check or uncheck the item
+	if err := h.Client.SetChecklistItemChecked(r.Context(), session.AccessToken, listID, taskID, itemID, isChecked == "true"); err != nil {
+		writeClientError(w, "Error updating checklist item", err)
+		return
+	}
+
+	// Let other open boards know the card's progress changed
+	h.listChanged(r, events.Event{Type: events.TaskUpdated, ListID: listID, TaskID: taskID})
+
+	// Send a success response
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Checklist item updated successfully"))
+}
+
+// DeleteChecklistItemHandler handles deleting a checklist item
+func (h *Handler) DeleteChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	listID := r.FormValue("listId")
+	taskID := r.FormValue("taskId")
+	itemID := r.FormValue("itemId")
+
+	if listID == "" || taskID == "" || itemID == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Delete the item
+	if err := h.Client.DeleteChecklistItem(r.Context(), session.AccessToken, listID, taskID, itemID); err != nil {
+		writeClientError(w, "Error deleting checklist item", err)
+		return
+	}
+
+	// Let other open boards know the card's progress changed
+	h.listChanged(r, events.Event{Type: events.TaskUpdated, ListID: listID, TaskID: taskID})
+
+	// Send a success response
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Checklist item deleted successfully"))
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+func TestChecklist(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Release"})
+	app.login(t)
+
+	// Add two items
+	var created models.ChecklistItem
+	for _, name := range []string{"Tag", "Publish"} {
+		resp, body := app.post(t, "/api/createChecklistItem", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "displayName": {name}})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d: %s", resp.StatusCode, body)
+		}
+		if err := json.Unmarshal([]byte(body), &created); err != nil || created.ID == "" || created.DisplayName != name {
+			t.Fatalf("unexpected item %q: %v", body, err)
+		}
+	}
+
+	// Rename the last one and check it
+	resp, body := app.post(t, "/api/updateChecklistItem", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "itemId": {created.ID}, "displayName": {"Publish to the store"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	resp, body = app.post(t, "/api/toggleChecklistItem", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "itemId": {created.ID}, "isChecked": {"true"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+
+	resp, body = app.get(t, "/api/checklistItems?listId="+list.ID+"&taskId="+task.ID)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	var items []models.ChecklistItem
+	if err := json.Unmarshal([]byte(body), &items); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", body, err)
+	}
+	if len(items) != 2 || items[0].IsChecked || !items[1].IsChecked || items[1].DisplayName != "Publish to the store" {
+		t.Errorf("unexpected checklist: %+v", items)
+	}
+
+	// The card shows the progress
+	_, page := app.get(t, "/list/"+list.ID+"/tasks")
+	if !strings.Contains(page, "1/2") {
+		t.Errorf("tasks page lacks checklist progress 1/2")
+	}
+
+	// Delete an item; the details include what's left
+	resp, body = app.post(t, "/api/deleteChecklistItem", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "itemId": {items[0].ID}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	_, body = app.get(t, "/api/getTaskDetails?listId="+list.ID+"&taskId="+task.ID)
+	var details struct {
+		ChecklistItems []models.ChecklistItem `json:"checklistItems"`
+	}
+	if err := json.Unmarshal([]byte(body), &details); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", body, err)
+	}
+	if len(details.ChecklistItems) != 1 || details.ChecklistItems[0].ID != created.ID {
+		t.Errorf("unexpected checklist in details: %+v", details.ChecklistItems)
+	}
+
+	// Items must have a name
+	resp, _ = app.post(t, "/api/createChecklistItem", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "displayName": {"  "}})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d for a blank item, want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// dateLayout is the format of the dates in filter query parameters
+const dateLayout = "2006-01-02"
+
+// parseTaskFilter reads a board's filter from the query parameters q, category, importance,
+// dueBefore, dueAfter and overdue. Overdue is measured against today's date where the server runs,
+// which can differ from the user's date near midnight.
+func parseTaskFilter(r *http.Request) (models.TaskFilter, error) {
+	query := r.URL.Query()
+	filter := models.TaskFilter{
+		Search:     strings.TrimSpace(query.Get("q")),
+		Category:   strings.TrimSpace(query.Get("category")),
+		Importance: query.Get("importance"),
+		DueBefore:  query.Get("dueBefore"),
+		DueAfter:   query.Get("dueAfter"),
+		Overdue:    query.Get("overdue") == "true",
+		Today:      time.Now().Format(dateLayout),
+	}
+
+	switch filter.Importance {
+	case "", "low", "normal", "high":
+	default:
+		return filter, fmt.Errorf("invalid importance %q", filter.Importance)
+	}
+
+	for name, value := range map[string]string{"dueBefore": filter.DueBefore, "dueAfter": filter.DueAfter} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, value); err != nil {
+			return filter, fmt.Errorf("invalid %s date %q", name, value)
+		}
+	}
+
+	return filter, nil
+}
+
+// filterTasks returns the tasks in a list that pass the filter, and all of the list's tasks, which
+// WIP limits count. Both come from the cached tasks, so the hidden counts always agree with what is shown.
+func (h *Handler) filterTasks(ctx context.Context, sessionID string, accessToken string, listID string, filter models.TaskFilter) (matching []models.Task, all []models.Task, err error) {
+	all, err = h.Cache.Tasks(ctx, sessionID, accessToken, listID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if filter.IsEmpty() {
+		return all, all, nil
+	}
+
+	matching = []models.Task{}
+	for _, task := range all {
+		if filter.Matches(task) {
+			matching = append(matching, task)
+		}
+	}
+	return matching, all, nil
+}
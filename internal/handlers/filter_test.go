@@ -0,0 +1,120 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/board"
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+func TestFilteredBoard(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Home")
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	app.graph.AddTask(list.ID, models.Task{Title: "Fix the fence", Importance: "high", Body: &models.ItemBody{Content: "Buy nails", ContentType: "text"}, Categories: []string{"Garden"}})
+	app.graph.AddTask(list.ID, models.Task{Title: "Paint the shed", Importance: "high", Categories: []string{"Garden"}, DueDateTime: models.NewDate(yesterday)})
+	app.graph.AddTask(list.ID, models.Task{Title: "Water plants", Importance: "normal", Categories: []string{"Garden"}})
+	app.graph.AddTask(list.ID, models.Task{Title: "Call the plumber", Importance: "high", Status: "completed", DueDateTime: models.NewDate(yesterday)})
+	app.login(t)
+
+	board := "/list/" + list.ID + "/tasks"
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{"no filter", nil, []string{"Fix the fence", "Paint the shed", "Water plants", "Call the plumber"}},
+		{"search in notes", url.Values{"q": {"NAILS"}}, []string{"Fix the fence"}},
+		{"category and importance", url.Values{"category": {"Garden"}, "importance": {"high"}}, []string{"Fix the fence", "Paint the shed"}},
+		{"overdue", url.Values{"overdue": {"true"}}, []string{"Paint the shed"}},
+		{"search and filter", url.Values{"q": {"the"}, "importance": {"high"}, "dueBefore": {time.Now().Format("2006-01-02")}}, []string{"Paint the shed", "Call the plumber"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := board
+			if test.query != nil {
+				path += "?" + test.query.Encode()
+			}
+			resp, page := app.get(t, path)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d: %s", resp.StatusCode, page)
+			}
+
+			shown := 0
+			for _, title := range []string{"Fix the fence", "Paint the shed", "Water plants", "Call the plumber"} {
+				if strings.Contains(page, `<div class="task-title">`+title+`</div>`) {
+					shown++
+				}
+			}
+			for _, title := range test.want {
+				if !strings.Contains(page, `<div class="task-title">`+title+`</div>`) {
+					t.Errorf("board is missing %q", title)
+				}
+			}
+			if shown != len(test.want) {
+				t.Errorf("board shows %d tasks, want %d", shown, len(test.want))
+			}
+
+			// The filter bar shows the filter, so it stays in the URL when changed
+			if search := test.query.Get("q"); search != "" && !strings.Contains(page, `name="q" value="`+search+`"`) {
+				t.Errorf("filter bar doesn't show the search %q", search)
+			}
+		})
+	}
+
+	// Every filter runs on the cached tasks, so filtered boards don't read the list again
+	for _, req := range app.graph.Requests() {
+		if req.Query.Get("$filter") != "" {
+			t.Errorf("a filter was sent to Graph: %s", req.Query.Get("$filter"))
+		}
+	}
+
+	// Bad filters are rejected
+	for _, query := range []string{"dueBefore=June", "importance=urgent"} {
+		if resp, _ := app.get(t, board+"?"+query); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestFilteredBoardCountsHiddenTasks(t *testing.T) {
+	boards := board.DefaultConfig()
+	boards.Default.Columns[1].WIPLimit = 2
+	app := newTestApp(t, boards)
+	list := app.graph.AddList("Home")
+	for _, title := range []string{"Fix the fence", "Paint the shed", "Water plants"} {
+		app.graph.AddTask(list.ID, models.Task{Title: title, Categories: []string{"Doing"}})
+	}
+	app.login(t)
+
+	// A search hides two of the three tasks in Doing, but they still count against its limit
+	before := len(app.graph.Requests())
+	resp, page := app.get(t, "/list/"+list.ID+"/tasks?q=fence&category=Doing")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, page)
+	}
+	for _, want := range []string{
+		`<span class="column-count">3/2</span>`,
+		`over-limit" data-column="Doing" data-wip-limit="2" data-hidden="2"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("board is missing %q", want)
+		}
+	}
+
+	// The shown and counted tasks come from the same read of the list
+	for _, req := range app.graph.Requests()[before:] {
+		if req.Query.Get("$filter") != "" {
+			t.Errorf("the filter was sent to Graph as a $filter: %s", req.Query.Get("$filter"))
+		}
+	}
+}
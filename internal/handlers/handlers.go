@@ -6,33 +6,131 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/coseguera/kanban-to-do/internal/auth"
+	"github.com/coseguera/kanban-to-do/internal/board"
+	"github.com/coseguera/kanban-to-do/internal/events"
 	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/notify"
+	"github.com/coseguera/kanban-to-do/internal/ordering"
+	"github.com/coseguera/kanban-to-do/internal/service"
+	"github.com/coseguera/kanban-to-do/internal/tasksync"
 	"github.com/coseguera/kanban-to-do/internal/templates"
-	"github.com/coseguera/kanban-to-do/pkg/microsoft"
+)
+
+const (
+	// eventsKeepAliveInterval is how often EventsHandler writes to an otherwise idle stream
+	eventsKeepAliveInterval = 30 * time.Second
+	// watchTimeout bounds the background call that starts watching a list for outside changes
+	watchTimeout = 30 * time.Second
+	// maxReorderTasks bounds the column order a reorder request can send
+	maxReorderTasks = 1000
 )
 
 // Handler contains the dependencies for the HTTP handlers
 type Handler struct {
-	Client         *microsoft.Client
+	Client         service.TodoService
 	SessionManager *auth.SessionManager
+	Boards         *board.Config
+	Order          ordering.Store
+	Events         *events.Hub
+	Cache          *tasksync.Cache
+	Notifier       notify.Notifier // optional; reports changes made outside this server
 }
 
-// NewHandler creates a new Handler
-func NewHandler(client *microsoft.Client, sessionManager *auth.SessionManager) *Handler {
+// NewHandler creates a new Handler.
+// If boards is nil, every list uses the built-in Not Started / Doing / Done board,
+// and if order is nil, card order is kept in memory.
+// Board changes are broadcast through a new events.Hub, and tasks are read through a new tasksync.Cache.
+func NewHandler(client service.TodoService, sessionManager *auth.SessionManager, boards *board.Config, order ordering.Store) *Handler {
+	if boards == nil {
+		boards = board.DefaultConfig()
+	}
+	if order == nil {
+		order = ordering.NewMemoryStore()
+	}
+
 	return &Handler{
 		Client:         client,
 		SessionManager: sessionManager,
+		Boards:         boards,
+		Order:          order,
+		Events:         events.NewHub(),
+		Cache:          tasksync.NewCache(client, sessionManager.AccessToken),
 	}
 }
 
+// listChanged records a change made through this server: cached copies of the list are
+// marked stale and open boards are told, except the browser tab that made the change
+func (h *Handler) listChanged(r *http.Request, event events.Event) {
+	h.Cache.Invalidate(event.ListID)
+
+	event.Source = r.FormValue("clientId")
+	h.Events.Publish(event)
+}
+
+// ListChangedElsewhere applies a change reported by the Notifier: cached copies of the list
+// are marked stale and open boards are told about the task, or told to reload when the
+// change isn't about one task, such as after notifications were missed
+func (h *Handler) ListChangedElsewhere(change notify.Change) {
+	h.Cache.Invalidate(change.ListID)
+	if cache, ok := h.Client.(service.CachingService); ok {
+		cache.Invalidate(change.ListID, change.TaskID)
+	}
+	if change.TaskID == "" {
+		h.Events.Publish(events.Event{Type: events.ListResync, ListID: change.ListID})
+		return
+	}
+
+	eventType := events.TaskUpdated
+	switch change.Type {
+	case notify.Created:
+		eventType = events.TaskCreated
+	case notify.Deleted:
+		eventType = events.TaskDeleted
+	}
+	h.Events.Publish(events.Event{Type: eventType, ListID: change.ListID, TaskID: change.TaskID})
+}
+
+// watchList asks the Notifier, if any, to report outside changes to a list.
+// It runs in the background so subscribing doesn't delay the page.
+func (h *Handler) watchList(sessionID string, listID string) {
+	if h.Notifier == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), watchTimeout)
+		defer cancel()
+
+		if err := h.Notifier.Watch(ctx, sessionID, listID); err != nil {
+			log.Printf("Error watching list %s for changes: %v", listID, err)
+		}
+	}()
+}
+
+// WithSession wraps the app's routes so backend calls made while handling a request know its session,
+// letting a caching backend keep the session's cache when its access token is refreshed.
+// Handlers still check the session before using it.
+func WithSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sessionID, err := auth.GetSessionFromRequest(r); err == nil {
+			r = r.WithContext(service.WithSession(r.Context(), sessionID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // HomeHandler handles the home page
 func (h *Handler) HomeHandler(w http.ResponseWriter, r *http.Request) {
 	tmpl := templates.Templates["home"]
@@ -45,17 +143,48 @@ func (h *Handler) HomeHandler(w http.ResponseWriter, r *http.Request) {
 
 // LoginHandler handles the login request
 func (h *Handler) LoginHandler(w http.ResponseWriter, r *http.Request) {
-	// Generate a state parameter to prevent CSRF
-	state := fmt.Sprintf("%d", time.Now().UnixNano())
+	// Generate a state parameter to prevent CSRF and bind it to this browser
+	state, err := auth.GenerateRandomID()
+	if err != nil {
+		http.Error(w, "Error starting login: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Generate a PKCE code verifier; only its S256 challenge leaves the server
+	codeVerifier, err := auth.GenerateCodeVerifier()
+	if err != nil {
+		http.Error(w, "Error starting login: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	auth.SetPreLoginCookies(w, state, codeVerifier)
 
 	// Create the authorization URL
-	authRequestURL := h.Client.GetAuthURL(state)
+	authRequestURL := h.Client.GetAuthURL(state, auth.CodeChallengeS256(codeVerifier))
 
 	http.Redirect(w, r, authRequestURL, http.StatusFound)
 }
 
 // CallbackHandler handles the OAuth callback
 func (h *Handler) CallbackHandler(w http.ResponseWriter, r *http.Request) {
+	// Verify the state matches the one issued to this browser by LoginHandler
+	validState := auth.ValidateState(r, r.URL.Query().Get("state"))
+	codeVerifier, verifierErr := auth.GetCodeVerifierFromRequest(r)
+	auth.ClearPreLoginCookies(w)
+	if !validState || verifierErr != nil {
+		h.renderLoginError(w, http.StatusForbidden, "Your sign-in request could not be verified. It may have expired or been started from another browser window.")
+		return
+	}
+
+	// Surface errors reported by the identity provider
+	if errCode := r.URL.Query().Get("error"); errCode != "" {
+		errMsg := r.URL.Query().Get("error_description")
+		if errMsg == "" {
+			errMsg = errCode
+		}
+		h.renderLoginError(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
 	code := r.URL.Query().Get("code")
 	if code == "" {
 		http.Error(w, "Code not found in callback", http.StatusBadRequest)
@@ -63,9 +192,9 @@ func (h *Handler) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Exchange code for access token
-	tokenResp, err := h.Client.ExchangeCodeForToken(code)
+	tokenResp, err := h.Client.ExchangeCodeForToken(r.Context(), code, codeVerifier)
 	if err != nil {
-		http.Error(w, "Error exchanging code for token: "+err.Error(), http.StatusInternalServerError)
+		writeClientError(w, "Error exchanging code for token", err)
 		return
 	}
 
@@ -83,6 +212,61 @@ func (h *Handler) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/todoLists", http.StatusFound)
 }
 
+// writeClientError writes an error returned by the to-do backend, passing through
+// 401, 403, 404 and 429 and reporting anything else as a 500
+func writeClientError(w http.ResponseWriter, message string, err error) {
+	status := http.StatusInternalServerError
+
+	var statusErr service.StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.HTTPStatus() {
+		case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusTooManyRequests:
+			status = statusErr.HTTPStatus()
+		}
+	}
+
+	var retryErr service.RetryAfterError
+	if errors.As(err, &retryErr) && retryErr.RetryAfterDelay() > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryErr.RetryAfterDelay().Seconds()))))
+	}
+
+	http.Error(w, message+": "+err.Error(), status)
+}
+
+// isPreconditionFailed reports whether a backend error says the task changed since its etag was read
+func isPreconditionFailed(err error) bool {
+	var statusErr service.StatusError
+	return errors.As(err, &statusErr) && statusErr.HTTPStatus() == http.StatusPreconditionFailed
+}
+
+// writeConflict answers an update refused because the task changed since the browser read it,
+// sending the task as it is now so the browser can show both versions
+func (h *Handler) writeConflict(w http.ResponseWriter, r *http.Request, accessToken string, listID string, taskID string) {
+	task, err := h.Client.GetTaskDetails(r.Context(), accessToken, listID, taskID)
+	if err != nil {
+		writeClientError(w, "Error fetching the current task", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   "The task was changed by someone else",
+		"current": taskDetails(h.Boards.ForList(listID), task),
+	})
+}
+
+// renderLoginError renders the login rejection page with the given status
+func (h *Handler) renderLoginError(w http.ResponseWriter, status int, message string) {
+	tmpl := templates.Templates["loginError"]
+	if tmpl == nil {
+		http.Error(w, message, status)
+		return
+	}
+	w.WriteHeader(status)
+	tmpl.Execute(w, map[string]string{"Message": message})
+}
+
 // TodoListsHandler handles the to-do lists page
 func (h *Handler) TodoListsHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the session ID from the cookie
@@ -100,15 +284,15 @@ func (h *Handler) TodoListsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Refresh the session if needed
-	if err := h.SessionManager.RefreshSessionIfNeeded(sessionID); err != nil {
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
 		http.Redirect(w, r, "/", http.StatusFound)
 		return
 	}
 
 	// Get the to-do lists
-	todoLists, err := h.Client.GetTodoLists(session.AccessToken)
+	todoLists, err := h.Client.GetTodoLists(r.Context(), session.AccessToken)
 	if err != nil {
-		http.Error(w, "Error getting to-do lists: "+err.Error(), http.StatusInternalServerError)
+		writeClientError(w, "Error getting to-do lists", err)
 		return
 	}
 
@@ -147,82 +331,77 @@ func (h *Handler) TasksHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Refresh the session if needed
-	if err := h.SessionManager.RefreshSessionIfNeeded(sessionID); err != nil {
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
 		http.Redirect(w, r, "/", http.StatusFound)
 		return
 	}
 
+	// Read the filter from the query parameters
+	filter, err := parseTaskFilter(r)
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Get the list details
-	list, err := h.Client.GetListDetails(session.AccessToken, listID)
+	list, err := h.Client.GetListDetails(r.Context(), session.AccessToken, listID)
 	if err != nil {
-		http.Error(w, "Error getting list details: "+err.Error(), http.StatusInternalServerError)
+		writeClientError(w, "Error getting list details", err)
 		return
 	}
 
-	// Get the tasks
-	taskResp, err := h.Client.GetListTasks(session.AccessToken, listID)
+	// Get the tasks that pass the filter, and all of them for the WIP counts
+	tasks, allTasks, err := h.filterTasks(r.Context(), sessionID, session.AccessToken, listID, filter)
 	if err != nil {
-		http.Error(w, "Error getting tasks: "+err.Error(), http.StatusInternalServerError)
+		writeClientError(w, "Error getting tasks", err)
 		return
 	}
 
-	// Initialize columns for Kanban view
-	notStartedTasks := []models.TaskDisplay{}
-	doingTasks := []models.TaskDisplay{}
-	doneTasks := []models.TaskDisplay{}
+	// Hear about changes made to the list in other apps
+	h.watchList(sessionID, listID)
 
-	// Convert tasks to display format and organize into columns
-	for _, task := range taskResp.Value {
-		taskDisplay := models.TaskDisplay{
-			ID:         task.ID,
-			Title:      task.Title,
-			Status:     task.Status == "completed",
-			Importance: task.Importance == "high",
-			Categories: task.Categories,
-		}
+	// Initialize columns for Kanban view from the list's board definition
+	boardDef := h.Boards.ForList(listID)
+	columns := make([]models.KanbanColumn, len(boardDef.Columns))
+	for i, col := range boardDef.Columns {
+		columns[i] = models.KanbanColumn{Title: col.Title, Tasks: []models.TaskDisplay{}, WIPLimit: col.WIPLimit}
+	}
 
-		// Format the due date if present
-		if task.DueDateTime != nil {
-			// Parse the due date
-			t, err := time.Parse(time.RFC3339, task.DueDateTime.DateTime)
-			if err == nil {
-				// Format as a more readable date
-				taskDisplay.DueDateTime = t.Format("Jan 2, 2006")
-			} else {
-				taskDisplay.DueDateTime = task.DueDateTime.DateTime
-			}
-		}
+	// Convert tasks to display format and organize into columns
+	for _, task := range tasks {
+		taskDisplay := newTaskDisplay(task)
+		taskDisplay.ListID = listID
 
 		// Determine which column this task belongs in
-		if task.Status == "completed" {
-			doneTasks = append(doneTasks, taskDisplay)
-		} else {
-			// Check if task has "Doing" category
-			hasDoing := false
-			for _, category := range task.Categories {
-				if strings.EqualFold(category, "Doing") {
-					hasDoing = true
-					break
-				}
-			}
+		i := board.ColumnIndex(boardDef, task)
+		columns[i].Tasks = append(columns[i].Tasks, taskDisplay)
+	}
 
-			if hasDoing {
-				doingTasks = append(doingTasks, taskDisplay)
-			} else {
-				notStartedTasks = append(notStartedTasks, taskDisplay)
-			}
-		}
+	// Sort each column by the team's manual order
+	ranks, err := h.Order.Ranks(listID)
+	if err != nil {
+		log.Printf("Error reading card order for list %s: %v", listID, err)
+	}
+	for i := range columns {
+		ordering.Sort(columns[i].Tasks, func(t models.TaskDisplay) string { return t.ID }, ranks)
+	}
+
+	// Count every task against the WIP limits, including those the filter hides,
+	// and flag columns holding more tasks than their limit
+	for _, task := range allTasks {
+		columns[board.ColumnIndex(boardDef, task)].Count++
+	}
+	for i := range columns {
+		columns[i].OverLimit = columns[i].WIPLimit > 0 && columns[i].Count > columns[i].WIPLimit
 	}
 
 	// Create TaskViewModel with Kanban columns
 	taskViewModel := models.TaskViewModel{
 		ListID:   listID,
 		ListName: list.DisplayName,
-		Columns: []models.KanbanColumn{
-			{Title: "Not Started", Tasks: notStartedTasks},
-			{Title: "Doing", Tasks: doingTasks},
-			{Title: "Done", Tasks: doneTasks},
-		},
+		Columns:  columns,
+		Statuses: board.Statuses(boardDef),
+		Filter:   filter,
 	}
 
 	// Render the template
@@ -234,6 +413,46 @@ func (h *Handler) TasksHandler(w http.ResponseWriter, r *http.Request) {
 	tmpl.Execute(w, taskViewModel)
 }
 
+// newTaskDisplay converts a task to the form shown on a board card
+func newTaskDisplay(task models.Task) models.TaskDisplay {
+	taskDisplay := models.TaskDisplay{
+		ID:         task.ID,
+		Title:      task.Title,
+		Status:     task.Status == "completed",
+		Importance: task.Importance == "high",
+		Categories: task.Categories,
+	}
+
+	// Format the dates if present
+	if task.DueDateTime != nil {
+		taskDisplay.DueDateTime = formatDate(task.DueDateTime)
+	}
+	if task.StartDateTime != nil {
+		taskDisplay.StartDateTime = formatDate(task.StartDateTime)
+	}
+	if task.CompletedDateTime != nil && task.Status == "completed" {
+		taskDisplay.CompletedDateTime = formatDate(task.CompletedDateTime)
+	}
+
+	// Flag notes, reminders and attachments
+	taskDisplay.HasNotes = task.Body != nil && strings.TrimSpace(task.Body.Content) != ""
+	taskDisplay.ReminderOn = task.IsReminderOn
+	taskDisplay.HasAttachments = task.HasAttachments
+	if task.Recurrence != nil {
+		taskDisplay.Recurrence = task.Recurrence.Describe()
+	}
+
+	// Count checklist progress
+	taskDisplay.ChecklistTotal = len(task.ChecklistItems)
+	for _, item := range task.ChecklistItems {
+		if item.IsChecked {
+			taskDisplay.ChecklistChecked++
+		}
+	}
+
+	return taskDisplay
+}
+
 // UpdateTaskHandler handles updating task status and categories when dragged between columns
 func (h *Handler) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	// Only accept POST requests
@@ -257,7 +476,7 @@ func (h *Handler) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Refresh the session if needed
-	if err := h.SessionManager.RefreshSessionIfNeeded(sessionID); err != nil {
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -274,6 +493,7 @@ func (h *Handler) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	listID := r.FormValue("listId")
 	taskID := r.FormValue("taskId")
 	column := r.FormValue("column")
+	override := r.FormValue("override") == "true"
 
 	log.Printf("Parsed values - listID: '%s', taskID: '%s', column: '%s'", listID, taskID, column)
 
@@ -283,18 +503,18 @@ func (h *Handler) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the task to preserve any existing categories
-	taskResp, err := h.Client.GetListTasks(session.AccessToken, listID)
+	// Get the list's tasks to preserve any existing categories and count WIP
+	tasks, err := h.Cache.Tasks(r.Context(), sessionID, session.AccessToken, listID)
 	if err != nil {
-		http.Error(w, "Error fetching task: "+err.Error(), http.StatusInternalServerError)
+		writeClientError(w, "Error fetching task", err)
 		return
 	}
 
-	// Find the task in the response
+	// Find the task in the list
 	var targetTask *models.Task
-	for i := range taskResp.Value {
-		if taskResp.Value[i].ID == taskID {
-			targetTask = &taskResp.Value[i]
+	for i := range tasks {
+		if tasks[i].ID == taskID {
+			targetTask = &tasks[i]
 			break
 		}
 	}
@@ -304,38 +524,73 @@ func (h *Handler) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get current categories and filter out any "Doing" category
-	categories := []string{}
-	for _, cat := range targetTask.Categories {
-		if !strings.EqualFold(cat, "Doing") {
-			categories = append(categories, cat)
-		}
+	// Find the target column on this list's board
+	boardDef := h.Boards.ForList(listID)
+	var targetColumn models.ColumnDefinition
+	columnIndex := board.FindColumn(boardDef, column)
+	if columnIndex >= 0 {
+		targetColumn = boardDef.Columns[columnIndex]
+	} else if i := board.FindColumn(h.Boards.Default, column); i >= 0 {
+		// A card dragged on the board of all lists can land in a default column its own list's board lacks.
+		// It takes that column's status and category, replacing the markers of both boards; the list's
+		// board has no such column, so no WIP limit applies.
+		targetColumn = h.Boards.Default.Columns[i]
+		targetColumn.WIPLimit = 0
+		boardDef = models.BoardDefinition{Columns: append(append([]models.ColumnDefinition{}, boardDef.Columns...), h.Boards.Default.Columns...)}
+	} else {
+		http.Error(w, "Unknown column: "+column, http.StatusBadRequest)
+		return
 	}
 
-	// Determine new status and categories based on the target column
-	status := "notStarted"
-	if column == "Done" {
-		status = "completed"
-	} else if column == "Doing" {
-		status = "notStarted"
-		categories = append(categories, "Doing")
-	} else if column == "Not Started" {
-		status = "notStarted"
-		// No need to add categories, as we already filtered out "Doing"
-	} else {
-		// Unknown column
-		log.Printf("Warning: Unknown column name received: %s", column)
+	// Refuse moves that would exceed the target column's WIP limit unless overridden
+	if targetColumn.WIPLimit > 0 && !override {
+		count := board.CountInColumn(boardDef, tasks, columnIndex, taskID)
+		if count >= targetColumn.WIPLimit {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":    fmt.Sprintf("Column %q is at its WIP limit of %d", targetColumn.Title, targetColumn.WIPLimit),
+				"column":   targetColumn.Title,
+				"count":    count,
+				"wipLimit": targetColumn.WIPLimit,
+			})
+			return
+		}
 	}
 
-	// Update the task
-	if err := h.Client.UpdateTaskStatus(session.AccessToken, listID, taskID, status, categories); err != nil {
-		http.Error(w, "Error updating task: "+err.Error(), http.StatusInternalServerError)
+	// Determine new status and categories, preserving any non-marker categories
+	status, categories := board.Move(boardDef, *targetTask, targetColumn)
+
+	// Update the task, unless it has changed since the categories above were read
+	if err := h.Client.UpdateTaskStatus(r.Context(), session.AccessToken, listID, taskID, targetTask.ETag, status, categories); err != nil {
+		if isPreconditionFailed(err) {
+			h.Cache.Invalidate(listID)
+			h.writeConflict(w, r, session.AccessToken, listID, taskID)
+			return
+		}
+		writeClientError(w, "Error updating task", err)
 		return
 	}
 
-	// Send a success response
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Task updated successfully"))
+	// Let other open boards know the card moved
+	h.listChanged(r, events.Event{Type: events.TaskUpdated, ListID: listID, TaskID: taskID})
+
+	// Send the new status and categories so the board can update the card
+	response := map[string]interface{}{
+		"status":     status,
+		"categories": categories,
+	}
+
+	// Completing a recurring task creates its next occurrence, which the board adds as a new card
+	if completesRecurringTask(targetTask, status) {
+		response["nextOccurrences"] = h.publishNextOccurrences(r, sessionID, session.AccessToken, listID, *targetTask, tasks)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 }
 
 // LogoutHandler handles the logout request
@@ -343,8 +598,9 @@ func (h *Handler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the session ID from the cookie
 	sessionID, err := auth.GetSessionFromRequest(r)
 	if err == nil {
-		// Delete the session
+		// Delete the session and the tasks cached for it
 		h.SessionManager.DeleteSession(sessionID)
+		h.Cache.DeleteSession(sessionID)
 	}
 
 	// Clear the session cookie
@@ -377,7 +633,7 @@ func (h *Handler) ToggleTaskImportanceHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	// Refresh the session if needed
-	if err := h.SessionManager.RefreshSessionIfNeeded(sessionID); err != nil {
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -409,11 +665,37 @@ func (h *Handler) ToggleTaskImportanceHandler(w http.ResponseWriter, r *http.Req
 		importance = "high"
 	}
 
-	// Update the task importance
-	if err := h.Client.UpdateTaskImportance(session.AccessToken, listID, taskID, importance); err != nil {
-		http.Error(w, "Error updating task importance: "+err.Error(), http.StatusInternalServerError)
+	// Find the task's version, as UpdateTaskHandler does, so a change made since can't be overwritten
+	tasks, err := h.Cache.Tasks(r.Context(), sessionID, session.AccessToken, listID)
+	if err != nil {
+		writeClientError(w, "Error fetching task", err)
 		return
 	}
+	var targetTask *models.Task
+	for i := range tasks {
+		if tasks[i].ID == taskID {
+			targetTask = &tasks[i]
+			break
+		}
+	}
+	if targetTask == nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	// Update the task importance, unless it has changed since
+	if err := h.Client.UpdateTaskImportance(r.Context(), session.AccessToken, listID, taskID, targetTask.ETag, importance); err != nil {
+		if isPreconditionFailed(err) {
+			h.Cache.Invalidate(listID)
+			h.writeConflict(w, r, session.AccessToken, listID, taskID)
+			return
+		}
+		writeClientError(w, "Error updating task importance", err)
+		return
+	}
+
+	// Let other open boards know the card changed
+	h.listChanged(r, events.Event{Type: events.TaskUpdated, ListID: listID, TaskID: taskID})
 
 	// Send a success response
 	w.WriteHeader(http.StatusOK)
@@ -443,7 +725,7 @@ func (h *Handler) GetTaskDetailsHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Refresh the session if needed
-	if err := h.SessionManager.RefreshSessionIfNeeded(sessionID); err != nil {
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -458,42 +740,86 @@ func (h *Handler) GetTaskDetailsHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Get the task details from Microsoft API
-	task, err := h.Client.GetTaskDetails(session.AccessToken, listID, taskID)
+	task, err := h.Client.GetTaskDetails(r.Context(), session.AccessToken, listID, taskID)
 	if err != nil {
-		http.Error(w, "Error fetching task details: "+err.Error(), http.StatusInternalServerError)
+		writeClientError(w, "Error fetching task details", err)
 		return
 	}
 
-	// Create a response object
-	response := map[string]interface{}{
+	// Create a response object, including the board column the task belongs in
+	response := taskDetails(h.Boards.ForList(listID), task)
+
+	// Convert to JSON and send response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// taskDetails is the JSON form of a task sent to the details modal
+func taskDetails(boardDef models.BoardDefinition, task *models.Task) map[string]interface{} {
+	details := map[string]interface{}{
 		"id":         task.ID,
 		"title":      task.Title,
 		"status":     task.Status,
 		"importance": task.Importance,
 		"categories": task.Categories,
+		"column":     boardDef.Columns[board.ColumnIndex(boardDef, *task)].Title,
+		"etag":       task.ETag,
+	}
+
+	// Add the checklist, always as an array
+	if task.ChecklistItems != nil {
+		details["checklistItems"] = task.ChecklistItems
+	} else {
+		details["checklistItems"] = []models.ChecklistItem{}
 	}
 
-	// Add due date if it exists
+	// Add the notes if there are any
+	if task.Body != nil {
+		details["body"] = task.Body.Content
+		details["bodyType"] = task.Body.ContentType
+	}
+
+	// Add dates if they exist, with the raw datetime for form handling
 	if task.DueDateTime != nil {
-		// Store the raw datetime for form handling
-		response["dueDateTimeRaw"] = task.DueDateTime.DateTime
-
-		// Parse the due date for display
-		t, err := time.Parse(time.RFC3339, task.DueDateTime.DateTime)
-		if err == nil {
-			// Format as a more readable date
-			response["dueDateTime"] = t.Format("Jan 2, 2006")
-		} else {
-			response["dueDateTime"] = task.DueDateTime.DateTime
+		details["dueDateTimeRaw"] = task.DueDateTime.DateTime
+		details["dueDateTime"] = formatDate(task.DueDateTime)
+	}
+	if task.StartDateTime != nil {
+		details["startDateTimeRaw"] = task.StartDateTime.DateTime
+		details["startDateTime"] = formatDate(task.StartDateTime)
+	}
+	if task.CompletedDateTime != nil {
+		details["completedDateTime"] = formatDate(task.CompletedDateTime)
+	}
+
+	// Add the reminder as an RFC 3339 time, which the browser shows in the user's time zone
+	details["isReminderOn"] = task.IsReminderOn
+	if task.ReminderDateTime != nil {
+		if t, err := task.ReminderDateTime.Time(); err == nil {
+			details["reminderDateTime"] = t.UTC().Format(time.RFC3339)
 		}
 	}
+	details["hasAttachments"] = task.HasAttachments
 
-	// Convert to JSON and send response
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
-		return
+	// Add the recurrence as Graph describes it, for the edit form, and as text
+	details["recurrence"] = task.Recurrence
+	if task.Recurrence != nil {
+		details["recurrenceText"] = task.Recurrence.Describe()
 	}
+
+	return details
+}
+
+// formatDate formats a Graph date as a more readable date, or returns it as-is if it can't be parsed
+func formatDate(dt *models.DateTime) string {
+	t, err := dt.Time()
+	if err != nil {
+		return dt.DateTime
+	}
+	return t.Format("Jan 2, 2006")
 }
 
 // UpdateTaskDetailsHandler handles updating a task's details
@@ -519,7 +845,7 @@ func (h *Handler) UpdateTaskDetailsHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Refresh the session if needed
-	if err := h.SessionManager.RefreshSessionIfNeeded(sessionID); err != nil {
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -537,7 +863,11 @@ func (h *Handler) UpdateTaskDetailsHandler(w http.ResponseWriter, r *http.Reques
 	status := r.FormValue("status")
 	importance := r.FormValue("importance")
 	dueDate := r.FormValue("dueDate")
+	startDate := r.FormValue("startDate")
 	categoriesJson := r.FormValue("categories")
+	reminderOn := r.FormValue("isReminderOn") == "true"
+	reminder := r.FormValue("reminderDateTime")
+	etag := r.FormValue("etag") // version the modal was opened at; empty overwrites unconditionally
 
 	if listID == "" || taskID == "" || title == "" {
 		http.Error(w, "Missing required parameters", http.StatusBadRequest)
@@ -553,24 +883,101 @@ func (h *Handler) UpdateTaskDetailsHandler(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	// Keep the status as chosen, so a task in a column defined by a status such as waitingOnOthers stays there
+	if status == "" {
+		status = "notStarted"
+	}
+	if !models.IsTaskStatus(status) {
+		http.Error(w, "Invalid status: "+status, http.StatusBadRequest)
+		return
+	}
+
+	// Collect the changes
+	update := models.TaskUpdate{
+		Title:      title,
+		Status:     status,
+		Importance: importance,
+		DueDate:    dueDate,
+		StartDate:  startDate,
+		Categories: categories,
+		ReminderOn: reminderOn,
+	}
+
+	// The notes are only sent when they were edited
+	if _, ok := r.Form["body"]; ok {
+		body := r.FormValue("body")
+		update.Body = &body
+	}
+
+	// Parse the reminder time, an RFC 3339 time from the browser
+	if reminderOn {
+		t, err := time.Parse(time.RFC3339, reminder)
+		if err != nil {
+			http.Error(w, "Invalid reminder time: "+reminder, http.StatusBadRequest)
+			return
+		}
+		update.Reminder = t
+	}
+
+	// The recurrence is only sent when it was edited
+	recurrence, err := recurrenceFromForm(r, dueDate)
+	if err != nil {
+		http.Error(w, "Invalid recurrence: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	update.Recurrence = recurrence
+
+	// Completing a recurring task creates its next occurrence, so note the list's tasks beforehand
+	var before []models.Task
+	var targetTask *models.Task
+	if status == "completed" {
+		before, err = h.Cache.Tasks(r.Context(), sessionID, session.AccessToken, listID)
+		if err != nil {
+			writeClientError(w, "Error fetching task", err)
+			return
+		}
+		for i := range before {
+			if before[i].ID == taskID {
+				targetTask = &before[i]
+			}
+		}
+	}
+
 	// Update the task
-	if err := h.Client.UpdateTaskDetails(
-		session.AccessToken,
-		listID,
-		taskID,
-		title,
-		status,
-		importance,
-		dueDate,
-		categories,
-	); err != nil {
-		http.Error(w, "Error updating task: "+err.Error(), http.StatusInternalServerError)
+	if err := h.Client.UpdateTaskDetails(r.Context(), session.AccessToken, listID, taskID, etag, update); err != nil {
+		if isPreconditionFailed(err) {
+			h.Cache.Invalidate(listID)
+			h.writeConflict(w, r, session.AccessToken, listID, taskID)
+			return
+		}
+		writeClientError(w, "Error updating task", err)
 		return
 	}
 
-	// Send success response
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Task updated successfully"))
+	// Let other open boards know the card changed
+	h.listChanged(r, events.Event{Type: events.TaskUpdated, ListID: listID, TaskID: taskID})
+
+	// Send the new occurrence of a completed recurring task, if there is one
+	response := map[string]interface{}{}
+	if completesRecurringTask(targetTask, status) {
+		// The occurrence is made from the task as edited
+		completed := *targetTask
+		completed.Title = update.Title
+		completed.DueDateTime = models.NewDate(update.DueDate)
+		if update.Recurrence != nil {
+			completed.Recurrence = nil
+			if update.Recurrence.Pattern.Type != "" {
+				completed.Recurrence = update.Recurrence
+			}
+		}
+		response["nextOccurrences"] = h.publishNextOccurrences(r, sessionID, session.AccessToken, listID, completed, before)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 }
 
 // CreateTaskHandler handles creating a new task
@@ -596,7 +1003,7 @@ func (h *Handler) CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Refresh the session if needed
-	if err := h.SessionManager.RefreshSessionIfNeeded(sessionID); err != nil {
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -617,12 +1024,15 @@ func (h *Handler) CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create the task
-	taskID, err := h.Client.CreateTask(session.AccessToken, listID, title)
+	taskID, err := h.Client.CreateTask(r.Context(), session.AccessToken, listID, title)
 	if err != nil {
-		http.Error(w, "Error creating task: "+err.Error(), http.StatusInternalServerError)
+		writeClientError(w, "Error creating task", err)
 		return
 	}
 
+	// Let other open boards know about the new card
+	h.listChanged(r, events.Event{Type: events.TaskCreated, ListID: listID, TaskID: taskID})
+
 	// Prepare the response
 	response := map[string]string{
 		"taskId": taskID,
@@ -659,7 +1069,7 @@ func (h *Handler) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Refresh the session if needed
-	if err := h.SessionManager.RefreshSessionIfNeeded(sessionID); err != nil {
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -680,12 +1090,217 @@ func (h *Handler) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delete the task
-	if err := h.Client.DeleteTask(session.AccessToken, listID, taskID); err != nil {
-		http.Error(w, "Error deleting task: "+err.Error(), http.StatusInternalServerError)
+	if err := h.Client.DeleteTask(r.Context(), session.AccessToken, listID, taskID, ""); err != nil {
+		writeClientError(w, "Error deleting task", err)
 		return
 	}
 
+	// Forget the task's position on the board
+	if err := h.Order.DeleteRank(listID, taskID); err != nil {
+		log.Printf("Error removing card order for task %s: %v", taskID, err)
+	}
+
+	// Let other open boards know the card is gone
+	h.listChanged(r, events.Event{Type: events.TaskDeleted, ListID: listID, TaskID: taskID})
+
 	// Send success response
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Task deleted successfully"))
 }
+
+// ReorderTaskHandler handles persisting a card's position within its column
+func (h *Handler) ReorderTaskHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Extract form values; order is the column's task IDs from top to bottom after the move
+	listID := r.FormValue("listId")
+	taskID := r.FormValue("taskId")
+	orderJson := r.FormValue("order")
+
+	if listID == "" || taskID == "" || orderJson == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	var order []string
+	if err := json.Unmarshal([]byte(orderJson), &order); err != nil {
+		http.Error(w, "Invalid order format: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(order) > maxReorderTasks {
+		http.Error(w, fmt.Sprintf("Order has more than %d tasks", maxReorderTasks), http.StatusBadRequest)
+		return
+	}
+
+	// Only order lists the user can see
+	if _, err := h.Client.GetListDetails(r.Context(), session.AccessToken, listID); err != nil {
+		writeClientError(w, "Error getting list details", err)
+		return
+	}
+
+	// Only rank tasks that are in the list, each once
+	tasks, err := h.Cache.Tasks(r.Context(), sessionID, session.AccessToken, listID)
+	if err != nil {
+		writeClientError(w, "Error fetching tasks", err)
+		return
+	}
+	inList := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		inList[task.ID] = true
+	}
+	seen := make(map[string]bool, len(order))
+	for _, id := range order {
+		if !inList[id] || seen[id] {
+			http.Error(w, "Order has a task that isn't in the list, or has it twice: "+id, http.StatusBadRequest)
+			return
+		}
+		seen[id] = true
+	}
+
+	// Compute the new rank(s) from the task's neighbours
+	ranks, err := h.Order.Ranks(listID)
+	if err != nil {
+		http.Error(w, "Error reading card order: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	changes := ordering.Place(order, taskID, ranks)
+	if changes == nil {
+		http.Error(w, "Task not found in order", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Order.SetRanks(listID, changes); err != nil {
+		http.Error(w, "Error saving card order: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Let other open boards know the column order changed
+	h.listChanged(r, events.Event{Type: events.TaskReordered, ListID: listID, TaskID: taskID, Order: order})
+
+	// Send success response
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Task reordered successfully"))
+}
+
+// EventsHandler streams changes to the lists on a board to the browser as Server-Sent Events
+func (h *Handler) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// An aggregate board streams several lists at once
+	listIDs := r.URL.Query()["listId"]
+	if len(listIDs) == 0 {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Only stream lists the user can see, checking them all with one read
+	todoLists, err := h.Client.GetTodoLists(r.Context(), session.AccessToken)
+	if err != nil {
+		writeClientError(w, "Error getting to-do lists", err)
+		return
+	}
+	if _, ok := selectLists(todoLists.Value, listIDs); !ok {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before responding so no change is missed once the browser is connected
+	changes, unsubscribe := h.Events.Subscribe(listIDs...)
+	defer unsubscribe()
+
+	// Keep hearing about changes made to the lists in other apps while the board is open
+	for _, listID := range listIDs {
+		h.watchList(sessionID, listID)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	// Comment lines keep proxies from closing an idle stream
+	keepAlive := time.NewTicker(eventsKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case event := <-changes:
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("Error encoding %s event: %v", event.Type, err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,886 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/auth"
+	"github.com/coseguera/kanban-to-do/internal/board"
+	"github.com/coseguera/kanban-to-do/internal/handlers"
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/notify"
+	"github.com/coseguera/kanban-to-do/internal/ordering"
+	"github.com/coseguera/kanban-to-do/internal/service"
+	"github.com/coseguera/kanban-to-do/internal/templates"
+	"github.com/coseguera/kanban-to-do/pkg/microsoft"
+	"github.com/coseguera/kanban-to-do/pkg/microsoft/graphtest"
+)
+
+func TestMain(m *testing.M) {
+	if err := templates.LoadTemplates("../../templates"); err != nil {
+		log.Fatalf("Failed to load templates: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+// testApp is the board server wired to a fake Graph server
+type testApp struct {
+	*httptest.Server
+	graph   *graphtest.Server
+	handler *handlers.Handler
+	order   ordering.Store
+	cache   *microsoft.CachingClient
+	client  *http.Client
+}
+
+// newTestApp starts the board server in front of a fake Graph server, using the given board config
+func newTestApp(t *testing.T, boards *board.Config) *testApp {
+	t.Helper()
+	return startTestApp(t, boards, func(client *microsoft.Client) service.TodoService { return client })
+}
+
+// newCachingTestApp starts the board server with a caching client in front of the fake Graph server,
+// as the server runs against Microsoft To Do
+func newCachingTestApp(t *testing.T, boards *board.Config) *testApp {
+	t.Helper()
+
+	var cache *microsoft.CachingClient
+	app := startTestApp(t, boards, func(client *microsoft.Client) service.TodoService {
+		cache = microsoft.NewCachingClient(client)
+		return cache
+	})
+	app.cache = cache
+	return app
+}
+
+// startTestApp starts the board server in front of a fake Graph server, using the backend wrap makes of its client
+func startTestApp(t *testing.T, boards *board.Config, wrap func(*microsoft.Client) service.TodoService) *testApp {
+	t.Helper()
+
+	graph := graphtest.NewServer()
+	t.Cleanup(graph.Close)
+
+	// The redirect URI is only known once the app server has started
+	app := &testApp{graph: graph, order: ordering.NewMemoryStore()}
+	mux := http.NewServeMux()
+	app.Server = httptest.NewTLSServer(handlers.WithSession(mux))
+	t.Cleanup(app.Close)
+
+	client := wrap(microsoft.NewClient(graph.Config(app.URL + "/auth/callback")))
+	sessionManager := auth.NewSessionManager(client, auth.NewMemoryStore())
+	h := handlers.NewHandler(client, sessionManager, boards, app.order)
+	app.handler = h
+
+	mux.HandleFunc("/", h.HomeHandler)
+	mux.HandleFunc("/login", h.LoginHandler)
+	mux.HandleFunc("/auth/callback", h.CallbackHandler)
+	mux.HandleFunc("/todoLists", h.TodoListsHandler)
+	mux.HandleFunc("/api/lists", h.ListsHandler)
+	mux.HandleFunc("/api/createList", h.CreateListHandler)
+	mux.HandleFunc("/api/renameList", h.RenameListHandler)
+	mux.HandleFunc("/api/deleteList", h.DeleteListHandler)
+	mux.HandleFunc("/list/", h.TasksHandler)
+	mux.HandleFunc("/board", h.AggregateBoardHandler)
+	mux.HandleFunc("/api/updateTask", h.UpdateTaskHandler)
+	mux.HandleFunc("/api/toggleImportance", h.ToggleTaskImportanceHandler)
+	mux.HandleFunc("/api/getTaskDetails", h.GetTaskDetailsHandler)
+	mux.HandleFunc("/api/updateTaskDetails", h.UpdateTaskDetailsHandler)
+	mux.HandleFunc("/api/createTask", h.CreateTaskHandler)
+	mux.HandleFunc("/api/deleteTask", h.DeleteTaskHandler)
+	mux.HandleFunc("/api/moveTask", h.MoveTaskHandler)
+	mux.HandleFunc("/api/reorderTask", h.ReorderTaskHandler)
+	mux.HandleFunc("/api/checklistItems", h.ChecklistItemsHandler)
+	mux.HandleFunc("/api/createChecklistItem", h.CreateChecklistItemHandler)
+	mux.HandleFunc("/api/updateChecklistItem", h.UpdateChecklistItemHandler)
+	mux.HandleFunc("/api/toggleChecklistItem", h.ToggleChecklistItemHandler)
+	mux.HandleFunc("/api/deleteChecklistItem", h.DeleteChecklistItemHandler)
+	mux.HandleFunc("/api/linkedResources", h.LinkedResourcesHandler)
+	mux.HandleFunc("/api/createLinkedResource", h.CreateLinkedResourceHandler)
+	mux.HandleFunc("/api/deleteLinkedResource", h.DeleteLinkedResourceHandler)
+	mux.HandleFunc("/api/attachments", h.AttachmentsHandler)
+	mux.HandleFunc("/api/uploadAttachment", h.UploadAttachmentHandler)
+	mux.HandleFunc("/api/downloadAttachment", h.DownloadAttachmentHandler)
+	mux.HandleFunc("/api/deleteAttachment", h.DeleteAttachmentHandler)
+	mux.HandleFunc("/api/events", h.EventsHandler)
+	mux.HandleFunc("/logout", h.LogoutHandler)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to create cookie jar: %v", err)
+	}
+	app.client = app.Server.Client()
+	app.client.Jar = jar
+
+	return app
+}
+
+// login signs in through the fake identity provider and returns the lists page
+func (a *testApp) login(t *testing.T) string {
+	t.Helper()
+
+	resp, body := a.get(t, "/login")
+	if resp.StatusCode != http.StatusOK || resp.Request.URL.Path != "/todoLists" {
+		t.Fatalf("login ended at %s with status %d: %s", resp.Request.URL, resp.StatusCode, body)
+	}
+	return body
+}
+
+// get fetches a path on the board server, following redirects
+func (a *testApp) get(t *testing.T, path string) (*http.Response, string) {
+	t.Helper()
+
+	resp, err := a.client.Get(a.URL + path)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", path, err)
+	}
+	return resp, readBody(t, resp)
+}
+
+// post sends a form to a path on the board server
+func (a *testApp) post(t *testing.T, path string, form url.Values) (*http.Response, string) {
+	t.Helper()
+
+	resp, err := a.client.PostForm(a.URL+path, form)
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", path, err)
+	}
+	return resp, readBody(t, resp)
+}
+
+// openEvents connects to the event stream of one or more lists and returns a function that waits for the next event
+func (a *testApp) openEvents(t *testing.T, listIDs ...string) func() (name string, data string) {
+	t.Helper()
+
+	resp, err := a.client.Get(a.URL + "/api/events?" + url.Values{"listId": listIDs}.Encode())
+	if err != nil {
+		t.Fatalf("GET /api/events failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d, content type = %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// Read event names and data from the stream in the background
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	// Wait for the stream to be established before the test makes changes
+	if line := <-lines; line != ": connected" {
+		t.Fatalf("first line = %q, want the connected comment", line)
+	}
+
+	return func() (string, string) {
+		t.Helper()
+
+		var name string
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatalf("event stream closed")
+				}
+				if strings.HasPrefix(line, "event: ") {
+					name = strings.TrimPrefix(line, "event: ")
+				} else if strings.HasPrefix(line, "data: ") {
+					return name, strings.TrimPrefix(line, "data: ")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for an event")
+			}
+		}
+	}
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %v", err)
+	}
+	return string(body)
+}
+
+func TestLoginFlow(t *testing.T) {
+	app := newTestApp(t, nil)
+	app.graph.AddList("Groceries")
+
+	body := app.login(t)
+	if !strings.Contains(body, "Groceries") {
+		t.Errorf("lists page does not show the list: %s", body)
+	}
+
+	// The token request must carry the PKCE verifier matching the challenge
+	for _, req := range app.graph.Requests() {
+		if req.Path == "/token" {
+			form, _ := url.ParseQuery(req.Body)
+			if form.Get("code_verifier") == "" {
+				t.Errorf("token request has no code_verifier")
+			}
+		}
+	}
+}
+
+func TestCallbackRejectsForgedState(t *testing.T) {
+	app := newTestApp(t, nil)
+
+	resp, body := app.get(t, "/auth/callback?code="+graphtest.AuthCode+"&state=forged")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want %d: %s", resp.StatusCode, http.StatusForbidden, body)
+	}
+}
+
+func TestPagesRequireSession(t *testing.T) {
+	app := newTestApp(t, nil)
+
+	resp, _ := app.get(t, "/todoLists")
+	if resp.Request.URL.Path != "/" {
+		t.Errorf("unauthenticated lists page ended at %s, want /", resp.Request.URL.Path)
+	}
+
+	resp, _ = app.post(t, "/api/updateTask", url.Values{"listId": {"a"}, "taskId": {"b"}, "column": {"Done"}})
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestTasksPage(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	todo := app.graph.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	doing := app.graph.AddTask(list.ID, models.Task{Title: "Write code", Categories: []string{"Doing"}})
+	done := app.graph.AddTask(list.ID, models.Task{Title: "Ship it", Status: "completed"})
+	app.login(t)
+
+	resp, body := app.get(t, "/list/"+list.ID+"/tasks")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+
+	// Cards appear in column order
+	iTodo := strings.Index(body, todo.Title)
+	iDoing := strings.Index(body, doing.Title)
+	iDone := strings.Index(body, done.Title)
+	if iTodo < 0 || iDoing < 0 || iDone < 0 || !(iTodo < iDoing && iDoing < iDone) {
+		t.Errorf("cards not bucketed into Not Started / Doing / Done (offsets %d, %d, %d)", iTodo, iDoing, iDone)
+	}
+}
+
+func TestTasksPageMapsGraphErrors(t *testing.T) {
+	app := newTestApp(t, nil)
+	app.login(t)
+
+	resp, _ := app.get(t, "/list/missing/tasks")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	list := app.graph.AddList("Work")
+	app.graph.FailNext(10, http.StatusTooManyRequests, "1")
+	resp, _ = app.get(t, "/list/"+list.ID+"/tasks")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	if resp.Header.Get("Retry-After") != "1" {
+		t.Errorf("Retry-After = %q, want %q", resp.Header.Get("Retry-After"), "1")
+	}
+}
+
+func TestUpdateTaskMovesCard(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Plan sprint", Categories: []string{"Personal"}})
+	app.login(t)
+
+	resp, body := app.post(t, "/api/updateTask", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "column": {"Doing"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+
+	stored, _ := app.graph.Task(list.ID, task.ID)
+	if stored.Status != "notStarted" || !hasCategory(stored.Categories, "Doing") || !hasCategory(stored.Categories, "Personal") {
+		t.Errorf("unexpected task after move to Doing: %+v", stored)
+	}
+
+	resp, body = app.post(t, "/api/updateTask", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "column": {"Done"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+
+	var result struct {
+		Status     string   `json:"status"`
+		Categories []string `json:"categories"`
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", body, err)
+	}
+	if result.Status != "completed" || hasCategory(result.Categories, "Doing") {
+		t.Errorf("unexpected response after move to Done: %+v", result)
+	}
+}
+
+func TestUpdateTaskEnforcesWIPLimit(t *testing.T) {
+	boards := board.DefaultConfig()
+	boards.Default.Columns[1].WIPLimit = 1
+
+	app := newTestApp(t, boards)
+	list := app.graph.AddList("Work")
+	app.graph.AddTask(list.ID, models.Task{Title: "In progress", Categories: []string{"Doing"}})
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Next up"})
+	app.login(t)
+
+	form := url.Values{"listId": {list.ID}, "taskId": {task.ID}, "column": {"Doing"}}
+	resp, body := app.post(t, "/api/updateTask", form)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("status = %d, want %d: %s", resp.StatusCode, http.StatusConflict, body)
+	}
+
+	var conflict struct {
+		Column   string `json:"column"`
+		Count    int    `json:"count"`
+		WIPLimit int    `json:"wipLimit"`
+	}
+	if err := json.Unmarshal([]byte(body), &conflict); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", body, err)
+	}
+	if conflict.Column != "Doing" || conflict.Count != 1 || conflict.WIPLimit != 1 {
+		t.Errorf("unexpected conflict: %+v", conflict)
+	}
+
+	// The override flag lets the move through
+	form.Set("override", "true")
+	if resp, body := app.post(t, "/api/updateTask", form); resp.StatusCode != http.StatusOK {
+		t.Errorf("status with override = %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestTaskDetailsAndEdit(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	app.login(t)
+
+	resp, body := app.post(t, "/api/updateTaskDetails", url.Values{
+		"listId":           {list.ID},
+		"taskId":           {task.ID},
+		"title":            {"Plan next sprint"},
+		"status":           {"notStarted"},
+		"importance":       {"high"},
+		"dueDate":          {"2025-06-01"},
+		"startDate":        {"2025-05-26"},
+		"categories":       {`["Doing"]`},
+		"body":             {"Book the big room"},
+		"isReminderOn":     {"true"},
+		"reminderDateTime": {"2025-05-30T08:00:00Z"},
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+
+	resp, body = app.get(t, "/api/getTaskDetails?listId="+list.ID+"&taskId="+task.ID)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+
+	var details map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &details); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", body, err)
+	}
+	if details["title"] != "Plan next sprint" || details["importance"] != "high" || details["column"] != "Doing" {
+		t.Errorf("unexpected details: %v", details)
+	}
+	if details["dueDateTime"] != "Jun 1, 2025" {
+		t.Errorf("dueDateTime = %v, want Jun 1, 2025", details["dueDateTime"])
+	}
+	if details["startDateTime"] != "May 26, 2025" || details["body"] != "Book the big room" {
+		t.Errorf("unexpected start date or notes: %v", details)
+	}
+	if details["isReminderOn"] != true || details["reminderDateTime"] != "2025-05-30T08:00:00Z" {
+		t.Errorf("unexpected reminder: %v", details)
+	}
+
+	// The card shows the start date and flags the notes and reminder
+	_, page := app.get(t, "/list/"+list.ID+"/tasks")
+	for _, want := range []string{"Starts: May 26, 2025", `title="Has notes"`, `title="Reminder set"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("tasks page is missing %q", want)
+		}
+	}
+
+	// Toggle importance back off
+	resp, body = app.post(t, "/api/toggleImportance", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "isImportant": {"false"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if stored, _ := app.graph.Task(list.ID, task.ID); stored.Importance != "normal" {
+		t.Errorf("importance = %q, want normal", stored.Importance)
+	}
+}
+
+func TestEditKeepsStatusColumn(t *testing.T) {
+	boards := board.DefaultConfig()
+	boards.Default.Columns = append(boards.Default.Columns, models.ColumnDefinition{Title: "Waiting", Status: "waitingOnOthers"})
+	app := newTestApp(t, boards)
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Get sign-off", Status: "waitingOnOthers"})
+	app.login(t)
+
+	// The details modal offers the statuses of the board's columns
+	_, page := app.get(t, "/list/"+list.ID+"/tasks")
+	for _, want := range []string{`<option value="notStarted">Not Started</option>`, `<option value="waitingOnOthers">Waiting on Others</option>`, `<option value="completed">Completed</option>`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("tasks page is missing %q", want)
+		}
+	}
+	if strings.Contains(page, `<option value="deferred">`) {
+		t.Errorf("tasks page offers a status no column uses")
+	}
+
+	// Saving the details keeps the task's status, and so its column
+	edit := url.Values{"listId": {list.ID}, "taskId": {task.ID}, "title": {"Get sign-off from legal"}, "status": {"waitingOnOthers"}, "importance": {"normal"}}
+	if resp, body := app.post(t, "/api/updateTaskDetails", edit); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if stored, _ := app.graph.Task(list.ID, task.ID); stored.Status != "waitingOnOthers" {
+		t.Errorf("status = %q, want waitingOnOthers", stored.Status)
+	}
+	_, body := app.get(t, "/api/getTaskDetails?listId="+list.ID+"&taskId="+task.ID)
+	if !strings.Contains(body, `"column":"Waiting"`) {
+		t.Errorf("task left its column: %s", body)
+	}
+
+	// Statuses Graph doesn't have are refused
+	edit.Set("status", "blocked")
+	if resp, _ := app.post(t, "/api/updateTaskDetails", edit); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown status: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestEditConflictReturnsCurrentTask(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	app.login(t)
+
+	// Load the board and open the details, then have someone else edit the task
+	app.get(t, "/list/"+list.ID+"/tasks")
+	_, body := app.get(t, "/api/getTaskDetails?listId="+list.ID+"&taskId="+task.ID)
+	var opened map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &opened); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", body, err)
+	}
+	if opened["etag"] != task.ETag {
+		t.Fatalf("etag = %v, want %q", opened["etag"], task.ETag)
+	}
+	task.Title = "Plan sprint with the team"
+	app.graph.SetTask(list.ID, task)
+
+	resp, body := app.post(t, "/api/updateTaskDetails", url.Values{
+		"listId":     {list.ID},
+		"taskId":     {task.ID},
+		"etag":       {opened["etag"].(string)},
+		"title":      {"Plan next sprint"},
+		"status":     {"notStarted"},
+		"importance": {"normal"},
+	})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("status = %d, want 412: %s", resp.StatusCode, body)
+	}
+
+	var conflict struct {
+		Current map[string]interface{} `json:"current"`
+	}
+	if err := json.Unmarshal([]byte(body), &conflict); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", body, err)
+	}
+	current, _ := app.graph.Task(list.ID, task.ID)
+	if conflict.Current["title"] != "Plan sprint with the team" || conflict.Current["etag"] != current.ETag {
+		t.Errorf("unexpected current task: %v", conflict.Current)
+	}
+
+	// The conflict refreshes the cached list, so the board shows the other edit
+	if _, page := app.get(t, "/list/"+list.ID+"/tasks"); !strings.Contains(page, "Plan sprint with the team") {
+		t.Errorf("board still shows the cached title after a conflict")
+	}
+
+	// Saving again at the current version overwrites
+	resp, body = app.post(t, "/api/updateTaskDetails", url.Values{
+		"listId":     {list.ID},
+		"taskId":     {task.ID},
+		"etag":       {current.ETag},
+		"title":      {"Plan next sprint"},
+		"status":     {"notStarted"},
+		"importance": {"normal"},
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if stored, _ := app.graph.Task(list.ID, task.ID); stored.Title != "Plan next sprint" {
+		t.Errorf("title = %q, want Plan next sprint", stored.Title)
+	}
+}
+
+func TestMoveConflictRefreshesCache(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	app.login(t)
+
+	// Load the board, then have someone else tag the task
+	if resp, body := app.get(t, "/list/"+list.ID+"/tasks"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	task.Categories = []string{"Personal"}
+	app.graph.SetTask(list.ID, task)
+
+	// The move was worked out from the cached task, so it is refused rather than dropping the new category
+	resp, body := app.post(t, "/api/updateTask", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "column": {"Doing"}})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("status = %d, want 412: %s", resp.StatusCode, body)
+	}
+	if !strings.Contains(body, `"column":"Not Started"`) {
+		t.Errorf("conflict response lacks the current column: %s", body)
+	}
+
+	// The retry reads the task again and keeps the category
+	resp, body = app.post(t, "/api/updateTask", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "column": {"Doing"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if stored, _ := app.graph.Task(list.ID, task.ID); !hasCategory(stored.Categories, "Doing") || !hasCategory(stored.Categories, "Personal") {
+		t.Errorf("unexpected task after retry: %+v", stored)
+	}
+}
+
+func TestImportanceConflict(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	app.login(t)
+
+	// Load the board, then have someone else rename the task
+	app.get(t, "/list/"+list.ID+"/tasks")
+	task.Title = "Plan sprint with the team"
+	app.graph.SetTask(list.ID, task)
+
+	// Starring the card is refused rather than sent over the other edit, and returns the current task
+	resp, body := app.post(t, "/api/toggleImportance", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "isImportant": {"true"}})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("status = %d, want 412: %s", resp.StatusCode, body)
+	}
+	if !strings.Contains(body, `"title":"Plan sprint with the team"`) {
+		t.Errorf("conflict response lacks the current task: %s", body)
+	}
+	if stored, _ := app.graph.Task(list.ID, task.ID); stored.Importance != "normal" {
+		t.Errorf("importance = %q after a conflict, want normal", stored.Importance)
+	}
+
+	// Trying again uses the current version
+	resp, body = app.post(t, "/api/toggleImportance", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "isImportant": {"true"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if stored, _ := app.graph.Task(list.ID, task.ID); stored.Importance != "high" || stored.Title != "Plan sprint with the team" {
+		t.Errorf("unexpected task after retry: %+v", stored)
+	}
+	if header := app.graph.Requests()[len(app.graph.Requests())-1].Header.Get("If-Match"); header == "" {
+		t.Errorf("importance was changed without If-Match")
+	}
+}
+
+func TestWritesThroughCachingClient(t *testing.T) {
+	app := newCachingTestApp(t, nil)
+	app.cache.FreshFor = time.Hour
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	app.login(t)
+
+	// details opens the task, failing the test if it can't
+	details := func() map[string]interface{} {
+		t.Helper()
+
+		resp, body := app.get(t, "/api/getTaskDetails?listId="+list.ID+"&taskId="+task.ID)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d: %s", resp.StatusCode, body)
+		}
+		var details map[string]interface{}
+		if err := json.Unmarshal([]byte(body), &details); err != nil {
+			t.Fatalf("Invalid JSON response %q: %v", body, err)
+		}
+		return details
+	}
+
+	// Opening the task twice reads it from Graph once
+	app.get(t, "/list/"+list.ID+"/tasks")
+	opened := details()
+	hits := app.cache.Stats().Hits
+	details()
+	if app.cache.Stats().Hits != hits+1 {
+		t.Fatalf("second read wasn't served from the cache: %+v", app.cache.Stats())
+	}
+
+	// Someone else renames the task, which the cache can't see until something invalidates it
+	task.Title = "Plan sprint with the team"
+	app.graph.SetTask(list.ID, task)
+	if got := details(); got["title"] != "Plan sprint" {
+		t.Fatalf("title = %v, want the cached title", got["title"])
+	}
+
+	// Saving over the cached version is refused, and neither the conflict nor later reads show the cached task
+	edit := url.Values{"listId": {list.ID}, "taskId": {task.ID}, "etag": {opened["etag"].(string)}, "title": {"Plan next sprint"}, "status": {"notStarted"}, "importance": {"normal"}}
+	resp, body := app.post(t, "/api/updateTaskDetails", edit)
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("status = %d, want 412: %s", resp.StatusCode, body)
+	}
+	if !strings.Contains(body, `"title":"Plan sprint with the team"`) {
+		t.Errorf("conflict response shows the cached task: %s", body)
+	}
+	current := details()
+	if current["title"] != "Plan sprint with the team" {
+		t.Errorf("title = %v after a conflict, want the other edit", current["title"])
+	}
+
+	// Writes through the handlers are seen by the next read
+	edit.Set("etag", current["etag"].(string))
+	if resp, body := app.post(t, "/api/updateTaskDetails", edit); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if got := details(); got["title"] != "Plan next sprint" {
+		t.Errorf("title = %v after saving, want Plan next sprint", got["title"])
+	}
+	if resp, body := app.post(t, "/api/toggleImportance", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "isImportant": {"true"}}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if got := details(); got["importance"] != "high" {
+		t.Errorf("importance = %v after starring, want high", got["importance"])
+	}
+	if resp, body := app.post(t, "/api/createChecklistItem", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "displayName": {"Book the room"}}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if got := details(); got["etag"] == current["etag"] {
+		t.Errorf("etag unchanged after adding a checklist item")
+	}
+}
+
+func TestCreateReorderAndDeleteTask(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	first := app.graph.AddTask(list.ID, models.Task{Title: "First"})
+	app.login(t)
+
+	// Create
+	resp, body := app.post(t, "/api/createTask", url.Values{"listId": {list.ID}, "title": {"Second"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	var created struct {
+		TaskID string `json:"taskId"`
+	}
+	if err := json.Unmarshal([]byte(body), &created); err != nil || created.TaskID == "" {
+		t.Fatalf("Invalid create response %q: %v", body, err)
+	}
+
+	// Reorder so the new card comes first
+	order, _ := json.Marshal([]string{created.TaskID, first.ID})
+	resp, body = app.post(t, "/api/reorderTask", url.Values{"listId": {list.ID}, "taskId": {created.TaskID}, "order": {string(order)}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+
+	_, page := app.get(t, "/list/"+list.ID+"/tasks")
+	if strings.Index(page, "Second") > strings.Index(page, "First") {
+		t.Errorf("reordered card is not rendered first")
+	}
+
+	// Delete
+	resp, body = app.post(t, "/api/deleteTask", url.Values{"listId": {list.ID}, "taskId": {created.TaskID}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if _, ok := app.graph.Task(list.ID, created.TaskID); ok {
+		t.Errorf("task still exists after delete")
+	}
+	if ranks, _ := app.order.Ranks(list.ID); ranks[created.TaskID] != "" {
+		t.Errorf("rank for deleted task was not removed")
+	}
+
+	// Deleting it again reports the Graph 404
+	resp, _ = app.post(t, "/api/deleteTask", url.Values{"listId": {list.ID}, "taskId": {created.TaskID}})
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestReorderTaskRejectsBadOrders(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	other := app.graph.AddList("Home")
+	first := app.graph.AddTask(list.ID, models.Task{Title: "First"})
+	second := app.graph.AddTask(list.ID, models.Task{Title: "Second"})
+	elsewhere := app.graph.AddTask(other.ID, models.Task{Title: "Elsewhere"})
+	app.login(t)
+
+	tooLong := make([]string, 1001)
+	for i := range tooLong {
+		tooLong[i] = first.ID
+	}
+
+	for _, tc := range []struct {
+		name   string
+		listID string
+		order  []string
+		want   int
+	}{
+		{"task from another list", list.ID, []string{first.ID, elsewhere.ID}, http.StatusBadRequest},
+		{"unknown task", list.ID, []string{first.ID, "made-up"}, http.StatusBadRequest},
+		{"task twice", list.ID, []string{first.ID, second.ID, first.ID}, http.StatusBadRequest},
+		{"too many tasks", list.ID, tooLong, http.StatusBadRequest},
+		{"unknown list", "missing", []string{first.ID}, http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			order, _ := json.Marshal(tc.order)
+			resp, body := app.post(t, "/api/reorderTask", url.Values{"listId": {tc.listID}, "taskId": {first.ID}, "order": {string(order)}})
+			if resp.StatusCode != tc.want {
+				t.Errorf("status = %d, want %d: %s", resp.StatusCode, tc.want, body)
+			}
+		})
+	}
+
+	if ranks, _ := app.order.Ranks(list.ID); len(ranks) != 0 {
+		t.Errorf("rejected orders were saved: %v", ranks)
+	}
+	if ranks, _ := app.order.Ranks("missing"); len(ranks) != 0 {
+		t.Errorf("an order was saved for an unknown list: %v", ranks)
+	}
+}
+
+func TestEventsStreamBoardChanges(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	app.login(t)
+
+	nextEvent := app.openEvents(t, list.ID)
+
+	app.post(t, "/api/updateTask", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "column": {"Done"}, "clientId": {"tab-1"}})
+
+	name, data := nextEvent()
+	var event struct {
+		ListID string `json:"listId"`
+		TaskID string `json:"taskId"`
+		Source string `json:"source"`
+	}
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("Invalid event data %q: %v", data, err)
+	}
+	if name != "taskUpdated" || event.ListID != list.ID || event.TaskID != task.ID || event.Source != "tab-1" {
+		t.Errorf("unexpected event %s: %s", name, data)
+	}
+
+	app.post(t, "/api/deleteTask", url.Values{"listId": {list.ID}, "taskId": {task.ID}})
+	if name, data := nextEvent(); name != "taskDeleted" {
+		t.Errorf("unexpected event %s: %s", name, data)
+	}
+}
+
+func TestEventsRequireVisibleList(t *testing.T) {
+	app := newTestApp(t, nil)
+	app.login(t)
+
+	resp, _ := app.get(t, "/api/events?listId=missing")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestOutsideChangesReachOpenBoards(t *testing.T) {
+	app := newTestApp(t, nil)
+	notifier := notify.NewLocalNotifier(app.handler.ListChangedElsewhere)
+	app.handler.Notifier = notifier
+
+	list := app.graph.AddList("Work")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	app.login(t)
+
+	// Opening the board starts watching the list in the background
+	app.get(t, "/list/"+list.ID+"/tasks")
+	deadline := time.Now().Add(5 * time.Second)
+	for !notifier.Watching(list.ID) {
+		if time.Now().After(deadline) {
+			t.Fatalf("board did not start watching the list")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	nextEvent := app.openEvents(t, list.ID)
+
+	// A change made in another app reaches the board and the next page load
+	task.Title = "Renamed elsewhere"
+	app.graph.SetTask(list.ID, task)
+	notifier.Notify(notify.Change{ListID: list.ID, TaskID: task.ID, Type: notify.Updated})
+
+	name, data := nextEvent()
+	if name != "taskUpdated" || !strings.Contains(data, task.ID) {
+		t.Errorf("unexpected event %s: %s", name, data)
+	}
+
+	_, page := app.get(t, "/list/"+list.ID+"/tasks")
+	if !strings.Contains(page, "Renamed elsewhere") {
+		t.Errorf("board still shows the cached title")
+	}
+
+	// Missed notifications don't name a task, so boards are told to reload the list
+	task.Title = "Renamed while notifications were missed"
+	app.graph.SetTask(list.ID, task)
+	notifier.Notify(notify.Change{ListID: list.ID})
+
+	name, data = nextEvent()
+	if name != "listResync" || !strings.Contains(data, list.ID) {
+		t.Errorf("unexpected event %s: %s", name, data)
+	}
+	_, page = app.get(t, "/list/"+list.ID+"/tasks")
+	if !strings.Contains(page, "Renamed while notifications were missed") {
+		t.Errorf("board still shows the cached title after a resync")
+	}
+}
+
+func TestLogout(t *testing.T) {
+	app := newTestApp(t, nil)
+	app.login(t)
+
+	app.get(t, "/logout")
+
+	resp, _ := app.get(t, "/todoLists")
+	if resp.Request.URL.Path != "/" {
+		t.Errorf("lists page after logout ended at %s, want /", resp.Request.URL.Path)
+	}
+}
+
+func hasCategory(categories []string, name string) bool {
+	for _, c := range categories {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+func TestListManagement(t *testing.T) {
+	app := newTestApp(t, nil)
+	tasks := app.graph.AddList("Tasks")
+	tasks.WellknownListName = models.WellknownListDefault
+	app.graph.SetList(tasks)
+	shared := app.graph.AddList("Family")
+	shared.IsShared = true
+	app.graph.SetList(shared)
+	app.login(t)
+
+	// Create
+	resp, body := app.post(t, "/api/createList", url.Values{"displayName": {"  Groceries "}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	var list models.TodoList
+	if err := json.Unmarshal([]byte(body), &list); err != nil || list.ID == "" || list.DisplayName != "Groceries" || !list.IsOwner {
+		t.Fatalf("unexpected list %q: %v", body, err)
+	}
+	if resp, _ := app.post(t, "/api/createList", url.Values{"displayName": {" "}}); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("blank name: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	// Rename
+	resp, body = app.post(t, "/api/renameList", url.Values{"listId": {list.ID}, "displayName": {"Shopping"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+
+	// The lists page shows it, with the built-in and shared lists marked
+	_, page := app.get(t, "/todoLists")
+	for _, want := range []string{"Shopping", ">Built-in<", ">Shared<"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("lists page is missing %q", want)
+		}
+	}
+	if strings.Count(page, `class="button delete-list-button"`) != 2 {
+		t.Errorf("built-in lists should have no delete button")
+	}
+
+	// Built-in lists can't be renamed or deleted
+	if resp, _ := app.post(t, "/api/renameList", url.Values{"listId": {tasks.ID}, "displayName": {"Inbox"}}); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("renaming a built-in list: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if resp, _ := app.post(t, "/api/deleteList", url.Values{"listId": {tasks.ID}}); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("deleting a built-in list: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	// Delete, telling open boards and forgetting its card order
+	app.graph.AddTask(list.ID, models.Task{Title: "Milk"})
+	app.order.SetRanks(list.ID, map[string]string{"task": "m"})
+	nextEvent := app.openEvents(t, list.ID)
+	resp, body = app.post(t, "/api/deleteList", url.Values{"listId": {list.ID}, "clientId": {"tab-1"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if name, data := nextEvent(); name != "listDeleted" {
+		t.Errorf("unexpected event %s: %s", name, data)
+	}
+	if ranks, _ := app.order.Ranks(list.ID); len(ranks) != 0 {
+		t.Errorf("deleted list still has card order %v", ranks)
+	}
+	if resp, _ := app.get(t, "/list/"+list.ID+"/tasks"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("deleted list's board: status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if resp, _ := app.post(t, "/api/deleteList", url.Values{"listId": {list.ID}}); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("deleting a missing list: status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/coseguera/kanban-to-do/internal/auth"
+	"github.com/coseguera/kanban-to-do/internal/events"
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/service"
+)
+
+// MoveTaskHandler handles moving a task to another list. Microsoft To Do can't move tasks,
+// so the task is copied to the destination list, with its checklist, links and attachments,
+// and then deleted from its own list. If any step fails the copy is deleted again.
+func (h *Handler) MoveTaskHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the session ID from the cookie
+	sessionID, err := auth.GetSessionFromRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the session
+	session, ok := h.SessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Refresh the session if needed
+	if err := h.SessionManager.RefreshSessionIfNeeded(r.Context(), sessionID); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Extract form values; etag, if set, is the version of the task the browser is showing
+	listID := r.FormValue("listId")
+	taskID := r.FormValue("taskId")
+	targetListID := r.FormValue("targetListId")
+	etag := r.FormValue("etag")
+
+	if listID == "" || taskID == "" || targetListID == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+	if targetListID == listID {
+		http.Error(w, "The task is already in this list", http.StatusBadRequest)
+		return
+	}
+
+	// Get the task as it is now, refusing the move if it changed since the browser read it
+	task, err := h.Client.GetTaskDetails(r.Context(), session.AccessToken, listID, taskID)
+	if err != nil {
+		writeClientError(w, "Error fetching task", err)
+		return
+	}
+	if etag != "" && task.ETag != "" && task.ETag != etag {
+		h.Cache.Invalidate(listID)
+		h.writeConflict(w, r, session.AccessToken, listID, taskID)
+		return
+	}
+
+	// Move it
+	moved, err := h.moveTask(r.Context(), session.AccessToken, task, listID, targetListID)
+	if err != nil {
+		// The task changed while it was being copied, so the move was rolled back
+		if isPreconditionFailed(err) {
+			h.Cache.Invalidate(listID)
+			h.writeConflict(w, r, session.AccessToken, listID, taskID)
+			return
+		}
+		writeClientError(w, "Error moving task", err)
+		return
+	}
+
+	// Forget the task's position on its old board
+	if err := h.Order.DeleteRank(listID, taskID); err != nil {
+		log.Printf("Error removing card order for task %s: %v", taskID, err)
+	}
+
+	// Let open boards of both lists know
+	h.listChanged(r, events.Event{Type: events.TaskDeleted, ListID: listID, TaskID: taskID})
+	h.listChanged(r, events.Event{Type: events.TaskCreated, ListID: targetListID, TaskID: moved.ID})
+
+	// Send the task's new location
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"listId": targetListID, "taskId": moved.ID}); err != nil {
+		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// moveTask copies a task, with its links and attachments, to another list and deletes the original.
+// Until the original is deleted every failure deletes the copy, so the task ends up in exactly one list
+// unless that cleanup fails too, which the returned error says. The original is only deleted if it
+// hasn't changed since task was read, so edits made meanwhile aren't lost.
+func (h *Handler) moveTask(ctx context.Context, accessToken string, task *models.Task, listID string, targetListID string) (*models.Task, error) {
+	// Read what the task itself doesn't carry
+	links, err := h.Client.GetLinkedResources(ctx, accessToken, listID, task.ID)
+	if err != nil {
+		return nil, fmt.Errorf("reading links: %w", err)
+	}
+	attachments, err := h.Client.GetAttachments(ctx, accessToken, listID, task.ID)
+	if err != nil {
+		return nil, fmt.Errorf("reading attachments: %w", err)
+	}
+	for i := range attachments {
+		attachment, err := h.Client.GetAttachment(ctx, accessToken, listID, task.ID, attachments[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("reading attachment %s: %w", attachments[i].Name, err)
+		}
+		attachments[i] = *attachment
+	}
+
+	// Copy the task and its checklist
+	moved, err := h.Client.CopyTask(ctx, accessToken, targetListID, *task)
+	if err != nil {
+		return nil, fmt.Errorf("copying the task: %w", err)
+	}
+
+	// Copy its links and attachments
+	for _, link := range links {
+		if _, err := h.Client.CreateLinkedResource(ctx, accessToken, targetListID, moved.ID, link); err != nil {
+			return nil, h.discardCopy(ctx, accessToken, targetListID, moved.ID, fmt.Errorf("copying link %s: %w", link.WebURL, err))
+		}
+	}
+	for _, attachment := range attachments {
+		if _, err := h.Client.AddAttachment(ctx, accessToken, targetListID, moved.ID, attachment.Name, attachment.ContentType, attachment.ContentBytes); err != nil {
+			return nil, h.discardCopy(ctx, accessToken, targetListID, moved.ID, fmt.Errorf("copying attachment %s: %w", attachment.Name, err))
+		}
+	}
+
+	// Delete the original; if it is already gone, the move is complete anyway
+	err = h.Client.DeleteTask(ctx, accessToken, listID, task.ID, task.ETag)
+	if err == nil || isNotFound(err) {
+		return moved, nil
+	}
+	err = fmt.Errorf("deleting the original: %w", err)
+
+	// A delete can fail after Graph has applied it, so only roll back while the original still exists;
+	// deleting the copy then would lose the task
+	if !isPreconditionFailed(err) {
+		if _, readErr := h.Client.GetTaskDetails(context.WithoutCancel(ctx), accessToken, listID, task.ID); readErr != nil {
+			if isNotFound(readErr) {
+				return moved, nil
+			}
+			log.Printf("Error checking whether task %s was deleted: %v", task.ID, readErr)
+			return nil, fmt.Errorf("%w; the original may or may not have been deleted, so the task may be in both lists", err)
+		}
+	}
+	return nil, h.discardCopy(ctx, accessToken, targetListID, moved.ID, err)
+}
+
+// discardCopy rolls back a failed move by deleting the copy, returning the error that caused the rollback.
+// The rollback runs even if the request was cancelled, so a closed browser doesn't leave the copy behind.
+func (h *Handler) discardCopy(ctx context.Context, accessToken string, listID string, taskID string, cause error) error {
+	if err := h.Client.DeleteTask(context.WithoutCancel(ctx), accessToken, listID, taskID, ""); err != nil {
+		log.Printf("Error removing copy %s of a task that failed to move: %v", taskID, err)
+		return fmt.Errorf("%w; the copy in the destination list could not be removed, so the task is now in both lists", cause)
+	}
+	return cause
+}
+
+// isNotFound reports whether a backend error says the item doesn't exist
+func isNotFound(err error) bool {
+	var statusErr service.StatusError
+	return errors.As(err, &statusErr) && statusErr.HTTPStatus() == http.StatusNotFound
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+func TestMoveTask(t *testing.T) {
+	app := newTestApp(t, nil)
+	work := app.graph.AddList("Work")
+	home := app.graph.AddList("Home")
+	task := app.graph.AddTask(work.ID, models.Task{
+		Title:          "Fix the fence",
+		Importance:     "high",
+		Body:           &models.ItemBody{Content: "<p>Buy <b>nails</b></p>", ContentType: "html"},
+		DueDateTime:    models.NewDate("2025-06-02"),
+		Categories:     []string{"Doing"},
+		ChecklistItems: []models.ChecklistItem{{DisplayName: "Measure", IsChecked: true}, {DisplayName: "Paint"}},
+	})
+	app.login(t)
+
+	app.post(t, "/api/createLinkedResource", url.Values{"listId": {work.ID}, "taskId": {task.ID}, "webUrl": {"https://example.com/fence"}})
+	app.upload(t, work.ID, task.ID, "plan.txt", []byte("two posts"))
+
+	// The destination list's board hears about it
+	nextEvent := app.openEvents(t, home.ID)
+
+	resp, body := app.post(t, "/api/moveTask", url.Values{"listId": {work.ID}, "taskId": {task.ID}, "targetListId": {home.ID}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	var result struct {
+		ListID string `json:"listId"`
+		TaskID string `json:"taskId"`
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil || result.ListID != home.ID || result.TaskID == "" {
+		t.Fatalf("unexpected response %q: %v", body, err)
+	}
+	if name, data := nextEvent(); name != "taskCreated" {
+		t.Errorf("unexpected event %s: %s", name, data)
+	}
+
+	// The task is in the new list with everything it had, and gone from the old one
+	if len(app.graph.Tasks(work.ID)) != 0 {
+		t.Errorf("the original task is still in its list")
+	}
+	moved, ok := app.graph.Task(home.ID, result.TaskID)
+	if !ok {
+		t.Fatalf("moved task not found")
+	}
+	if moved.Title != "Fix the fence" || moved.Importance != "high" || moved.Body == nil || moved.Body.ContentType != "html" || moved.DueDateTime == nil || len(moved.Categories) != 1 {
+		t.Errorf("fields not kept: %+v", moved)
+	}
+	if len(moved.ChecklistItems) != 2 || !moved.ChecklistItems[0].IsChecked || moved.ChecklistItems[1].IsChecked {
+		t.Errorf("checklist not kept: %+v", moved.ChecklistItems)
+	}
+	_, body = app.get(t, "/api/linkedResources?listId="+home.ID+"&taskId="+moved.ID)
+	var links []models.LinkedResource
+	if err := json.Unmarshal([]byte(body), &links); err != nil || len(links) != 1 || links[0].WebURL != "https://example.com/fence" {
+		t.Errorf("links not kept: %s", body)
+	}
+	var attachments []models.Attachment
+	_, body = app.get(t, "/api/attachments?listId="+home.ID+"&taskId="+moved.ID)
+	if err := json.Unmarshal([]byte(body), &attachments); err != nil || len(attachments) != 1 {
+		t.Fatalf("attachments not kept: %s", body)
+	}
+	if _, content := app.get(t, "/api/downloadAttachment?listId="+home.ID+"&taskId="+moved.ID+"&attachmentId="+attachments[0].ID); content != "two posts" {
+		t.Errorf("attachment content = %q", content)
+	}
+
+	// A move into the same list is refused
+	if resp, _ := app.post(t, "/api/moveTask", url.Values{"listId": {home.ID}, "taskId": {moved.ID}, "targetListId": {home.ID}}); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("move within a list: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestMoveTaskRollsBack(t *testing.T) {
+	app := newTestApp(t, nil)
+	work := app.graph.AddList("Work")
+	home := app.graph.AddList("Home")
+	task := app.graph.AddTask(work.ID, models.Task{Title: "Fix the fence"})
+	app.login(t)
+
+	// The original can't be deleted, so the copy is removed again
+	app.graph.FailOn(http.MethodDelete, "/tasks/"+task.ID, http.StatusForbidden)
+	resp, _ := app.post(t, "/api/moveTask", url.Values{"listId": {work.ID}, "taskId": {task.ID}, "targetListId": {home.ID}})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	if _, ok := app.graph.Task(work.ID, task.ID); !ok {
+		t.Errorf("the original task was lost")
+	}
+	if tasks := app.graph.Tasks(home.ID); len(tasks) != 0 {
+		t.Errorf("the copy was left behind: %+v", tasks)
+	}
+
+	// A task changed since the browser read it isn't moved
+	resp, _ = app.post(t, "/api/moveTask", url.Values{"listId": {work.ID}, "taskId": {task.ID}, "targetListId": {home.ID}, "etag": {`W/"stale"`}})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("stale etag: status = %d, want %d", resp.StatusCode, http.StatusPreconditionFailed)
+	}
+	if tasks := app.graph.Tasks(home.ID); len(tasks) != 0 {
+		t.Errorf("a stale task was moved: %+v", tasks)
+	}
+}
+
+func TestMoveTaskDeleteFailsAfterApplying(t *testing.T) {
+	app := newTestApp(t, nil)
+	work := app.graph.AddList("Work")
+	home := app.graph.AddList("Home")
+	task := app.graph.AddTask(work.ID, models.Task{Title: "Fix the fence"})
+	app.login(t)
+
+	// Graph deletes the original but the response is an error, so the copy must be kept
+	app.graph.FailAfter(http.MethodDelete, "/tasks/"+task.ID, http.StatusBadGateway)
+	resp, body := app.post(t, "/api/moveTask", url.Values{"listId": {work.ID}, "taskId": {task.ID}, "targetListId": {home.ID}})
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d: %s", resp.StatusCode, body)
+	}
+	if _, ok := app.graph.Task(work.ID, task.ID); ok {
+		t.Errorf("the original task is still in its list")
+	}
+	if tasks := app.graph.Tasks(home.ID); len(tasks) != 1 || tasks[0].Title != "Fix the fence" {
+		t.Errorf("the copy was not kept: %+v", tasks)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/events"
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// recurrenceFromForm reads the recurrence edited in the details modal. It returns nil if the form
+// leaves the recurrence unchanged, and a recurrence with an empty pattern type if it was turned off.
+// The days, months and start of the pattern come from the task's due date, which recurring tasks need.
+func recurrenceFromForm(r *http.Request, dueDate string) (*models.PatternedRecurrence, error) {
+	if _, ok := r.Form["recurrence"]; !ok {
+		return nil, nil
+	}
+	frequency := r.FormValue("recurrence")
+	if frequency == "" || frequency == "none" {
+		return &models.PatternedRecurrence{}, nil
+	}
+
+	due, err := time.Parse("2006-01-02", dueDate)
+	if err != nil {
+		return nil, fmt.Errorf("a recurring task must have a due date")
+	}
+
+	interval := 1
+	if value := r.FormValue("recurrenceInterval"); value != "" {
+		interval, err = strconv.Atoi(value)
+		if err != nil || interval < 1 || interval > 99 {
+			return nil, fmt.Errorf("invalid recurrence interval: %s", value)
+		}
+	}
+
+	// Build the pattern
+	pattern := models.RecurrencePattern{Interval: interval, FirstDayOfWeek: "sunday"}
+	switch frequency {
+	case "daily":
+		pattern.Type = models.RecurrenceDaily
+	case "weekly":
+		pattern.Type = models.RecurrenceWeekly
+		for _, name := range strings.Split(r.FormValue("recurrenceDays"), ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if !isWeekday(name) {
+				return nil, fmt.Errorf("invalid day of the week: %s", name)
+			}
+			pattern.DaysOfWeek = append(pattern.DaysOfWeek, name)
+		}
+		if len(pattern.DaysOfWeek) == 0 {
+			pattern.DaysOfWeek = []string{strings.ToLower(due.Weekday().String())}
+		}
+	case "monthly":
+		pattern.Type = models.RecurrenceAbsoluteMonthly
+		pattern.DayOfMonth = due.Day()
+	case "yearly":
+		pattern.Type = models.RecurrenceAbsoluteYearly
+		pattern.DayOfMonth = due.Day()
+		pattern.Month = int(due.Month())
+	default:
+		return nil, fmt.Errorf("invalid recurrence: %s", frequency)
+	}
+
+	// Build the range
+	recurrenceRange := models.RecurrenceRange{Type: models.RecurrenceNoEnd, StartDate: dueDate}
+	switch end := r.FormValue("recurrenceEnd"); end {
+	case "", models.RecurrenceNoEnd:
+	case models.RecurrenceEndDate:
+		endDate := r.FormValue("recurrenceEndDate")
+		if t, err := time.Parse("2006-01-02", endDate); err != nil || t.Before(due) {
+			return nil, fmt.Errorf("invalid recurrence end date: %s", endDate)
+		}
+		recurrenceRange.Type = models.RecurrenceEndDate
+		recurrenceRange.EndDate = endDate
+	case models.RecurrenceNumbered:
+		count, err := strconv.Atoi(r.FormValue("recurrenceCount"))
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("invalid number of occurrences: %s", r.FormValue("recurrenceCount"))
+		}
+		recurrenceRange.Type = models.RecurrenceNumbered
+		recurrenceRange.NumberOfOccurrences = count
+	default:
+		return nil, fmt.Errorf("invalid recurrence end: %s", end)
+	}
+
+	return &models.PatternedRecurrence{Pattern: pattern, Range: recurrenceRange}, nil
+}
+
+// isWeekday reports whether name is a lowercase English day name
+func isWeekday(name string) bool {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if strings.ToLower(wd.String()) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// completesRecurringTask reports whether setting a task's status completes an occurrence of a recurring task
+func completesRecurringTask(task *models.Task, status string) bool {
+	return task != nil && task.Recurrence != nil && status == "completed" && task.Status != "completed"
+}
+
+// publishNextOccurrences finds the tasks Microsoft To Do created when a recurring task was completed,
+// and tells other open boards about them. It returns their IDs so the browser that completed the task can add them too.
+// before may come from a stale cache, so a task missing from it is only taken for an occurrence if it matches the completed task.
+func (h *Handler) publishNextOccurrences(r *http.Request, sessionID string, accessToken string, listID string, completed models.Task, before []models.Task) []string {
+	tasks, err := h.Cache.Tasks(r.Context(), sessionID, accessToken, listID)
+	if err != nil {
+		log.Printf("Error looking for the next occurrence in list %s: %v", listID, err)
+		return nil
+	}
+
+	known := make(map[string]bool, len(before))
+	for _, task := range before {
+		known[task.ID] = true
+	}
+
+	var created []string
+	for _, task := range tasks {
+		if !known[task.ID] && isNextOccurrence(completed, task) {
+			created = append(created, task.ID)
+			h.Events.Publish(events.Event{Type: events.TaskCreated, ListID: listID, TaskID: task.ID, Source: r.FormValue("clientId")})
+		}
+	}
+	return created
+}
+
+// isNextOccurrence reports whether task could be the occurrence created by completing a recurring task:
+// it has the same title and recurrence pattern, and is due later
+func isNextOccurrence(completed models.Task, task models.Task) bool {
+	if completed.Recurrence == nil || task.Recurrence == nil || task.Status == "completed" {
+		return false
+	}
+	if task.Title != completed.Title || task.Recurrence.Pattern.Type != completed.Recurrence.Pattern.Type || task.Recurrence.Pattern.Interval != completed.Recurrence.Pattern.Interval {
+		return false
+	}
+	due := models.DueDate(task)
+	return due != "" && due > models.DueDate(completed)
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+func TestRecurringTask(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Chores")
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Water plants"})
+	app.login(t)
+
+	edit := url.Values{
+		"listId":             {list.ID},
+		"taskId":             {task.ID},
+		"title":              {"Water plants"},
+		"status":             {"notStarted"},
+		"importance":         {"normal"},
+		"recurrence":         {"weekly"},
+		"recurrenceInterval": {"2"},
+		"recurrenceDays":     {"monday,thursday"},
+		"recurrenceEnd":      {"numbered"},
+		"recurrenceCount":    {"10"},
+	}
+
+	// Repeating needs a due date
+	resp, _ := app.post(t, "/api/updateTaskDetails", edit)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("recurrence without a due date: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	edit.Set("dueDate", "2025-06-02")
+	resp, body := app.post(t, "/api/updateTaskDetails", edit)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	stored, _ := app.graph.Task(list.ID, task.ID)
+	if stored.Recurrence == nil || stored.Recurrence.Pattern.Interval != 2 || len(stored.Recurrence.Pattern.DaysOfWeek) != 2 || stored.Recurrence.Range.NumberOfOccurrences != 10 {
+		t.Fatalf("unexpected recurrence: %+v", stored.Recurrence)
+	}
+
+	// Bad values are rejected
+	for field, value := range map[string]string{"recurrence": "hourly", "recurrenceInterval": "0", "recurrenceDays": "someday", "recurrenceCount": "none"} {
+		bad := url.Values{}
+		for key, values := range edit {
+			bad[key] = values
+		}
+		bad.Set(field, value)
+		if resp, _ := app.post(t, "/api/updateTaskDetails", bad); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s=%s: status = %d, want %d", field, value, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+
+	// The card and the details describe it
+	_, page := app.get(t, "/list/"+list.ID+"/tasks")
+	if !strings.Contains(page, "Every 2 weeks on Mon, Thu, 10 times") {
+		t.Errorf("tasks page lacks the recurrence")
+	}
+	_, body = app.get(t, "/api/getTaskDetails?listId="+list.ID+"&taskId="+task.ID)
+	var details map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &details); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", body, err)
+	}
+	if details["recurrenceText"] != "Every 2 weeks on Mon, Thu, 10 times" || details["recurrence"] == nil {
+		t.Errorf("unexpected recurrence details: %v", details)
+	}
+
+	// Moving it to Done adds the next occurrence
+	resp, body = app.post(t, "/api/updateTask", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "column": {"Done"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	var result struct {
+		NextOccurrences []string `json:"nextOccurrences"`
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", body, err)
+	}
+	if len(result.NextOccurrences) != 1 {
+		t.Fatalf("nextOccurrences = %v, want one task", result.NextOccurrences)
+	}
+	next, ok := app.graph.Task(list.ID, result.NextOccurrences[0])
+	if !ok || next.Status != "notStarted" || next.DueDateTime == nil || !strings.HasPrefix(next.DueDateTime.DateTime, "2025-06-05") {
+		t.Errorf("unexpected next occurrence: %+v", next)
+	}
+
+	// Turning the recurrence off keeps the due date
+	resp, body = app.post(t, "/api/updateTaskDetails", url.Values{
+		"listId":     {list.ID},
+		"taskId":     {next.ID},
+		"title":      {"Water plants"},
+		"status":     {"notStarted"},
+		"importance": {"normal"},
+		"dueDate":    {"2025-06-05"},
+		"recurrence": {"none"},
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if stored, _ := app.graph.Task(list.ID, next.ID); stored.Recurrence != nil || stored.DueDateTime == nil {
+		t.Errorf("unexpected task after turning the recurrence off: %+v", stored)
+	}
+}
+
+func TestNextOccurrenceIgnoresOutsideTasks(t *testing.T) {
+	app := newTestApp(t, nil)
+	list := app.graph.AddList("Chores")
+	weekly := &models.PatternedRecurrence{
+		Pattern: models.RecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"monday"}},
+		Range:   models.RecurrenceRange{Type: models.RecurrenceNoEnd, StartDate: "2025-06-02"},
+	}
+	task := app.graph.AddTask(list.ID, models.Task{Title: "Water plants", DueDateTime: models.NewDate("2025-06-02"), Recurrence: weekly})
+	app.login(t)
+
+	// The board caches the list's tasks, then tasks are added in another app
+	app.get(t, "/list/"+list.ID+"/tasks")
+	app.graph.AddTask(list.ID, models.Task{Title: "Buy soil"})
+	app.graph.AddTask(list.ID, models.Task{Title: "Water plants", DueDateTime: models.NewDate("2025-06-09")})
+	app.graph.AddTask(list.ID, models.Task{Title: "Take out bins", DueDateTime: models.NewDate("2025-06-09"), Recurrence: weekly})
+
+	resp, body := app.post(t, "/api/updateTask", url.Values{"listId": {list.ID}, "taskId": {task.ID}, "column": {"Done"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	var result struct {
+		NextOccurrences []string `json:"nextOccurrences"`
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", body, err)
+	}
+	if len(result.NextOccurrences) != 1 {
+		t.Fatalf("nextOccurrences = %v, want only the next occurrence", result.NextOccurrences)
+	}
+	if next, _ := app.graph.Task(list.ID, result.NextOccurrences[0]); next.Recurrence == nil || models.DueDate(next) != "2025-06-09" {
+		t.Errorf("unexpected next occurrence: %+v", next)
+	}
+}
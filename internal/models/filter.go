@@ -0,0 +1,92 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package models
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// TaskFilter narrows the tasks shown on a board. Empty fields don't filter.
+// Dates are YYYY-MM-DD and compare against the date part of a task's due date.
+type TaskFilter struct {
+	Search     string // text the title or notes must contain, ignoring case
+	Category   string // category the task must have
+	Importance string // "low", "normal" or "high"
+	DueBefore  string // tasks due before this date
+	DueAfter   string // tasks due after this date
+	Overdue    bool   // only tasks not completed and due before Today
+	Today      string // the date Overdue is measured against, in the server's time zone
+}
+
+// IsEmpty reports whether the filter lets every task through
+func (f TaskFilter) IsEmpty() bool {
+	return f.Search == "" && f.Category == "" && f.Importance == "" && f.DueBefore == "" && f.DueAfter == "" && !f.Overdue
+}
+
+// Matches reports whether a task passes every part of the filter
+func (f TaskFilter) Matches(task Task) bool {
+	if f.Search != "" && !containsFold(task.Title, f.Search) && !(task.Body != nil && containsFold(bodyText(task.Body), f.Search)) {
+		return false
+	}
+	if f.Category != "" && !hasCategory(task.Categories, f.Category) {
+		return false
+	}
+	if f.Importance != "" && task.Importance != f.Importance {
+		return false
+	}
+
+	due := DueDate(task)
+	if (f.DueBefore != "" || f.DueAfter != "" || f.Overdue) && due == "" {
+		return false
+	}
+	if f.DueBefore != "" && due >= f.DueBefore {
+		return false
+	}
+	if f.DueAfter != "" && due <= f.DueAfter {
+		return false
+	}
+	if f.Overdue && (task.Status == "completed" || due >= f.Today) {
+		return false
+	}
+	return true
+}
+
+// DueDate returns the YYYY-MM-DD date a task is due, or "" if it has no due date.
+// The date is read as Graph stores it, without converting time zones.
+func DueDate(task Task) string {
+	if task.DueDateTime == nil || len(task.DueDateTime.DateTime) < len("2006-01-02") {
+		return ""
+	}
+	return task.DueDateTime.DateTime[:len("2006-01-02")]
+}
+
+// htmlTag matches the tags of HTML notes, so searches only match their text
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// bodyText returns the text of a task's notes, with runs of white space collapsed as a browser shows them
+func bodyText(body *ItemBody) string {
+	text := body.Content
+	if body.ContentType == "html" {
+		text = html.UnescapeString(htmlTag.ReplaceAllString(text, " "))
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// containsFold reports whether s contains substr, ignoring case
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// hasCategory reports whether categories includes category
+func hasCategory(categories []string, category string) bool {
+	for _, c := range categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+func TestTaskFilterMatches(t *testing.T) {
+	task := models.Task{
+		Title:       "Fix the fence",
+		Status:      "notStarted",
+		Importance:  "high",
+		Body:        &models.ItemBody{Content: "<p>Buy <b>nails</b> &amp; paint</p>", ContentType: "html"},
+		DueDateTime: &models.DateTime{DateTime: "2025-06-02T00:00:00.0000000", TimeZone: "UTC"},
+		Categories:  []string{"Doing", "Home"},
+	}
+
+	tests := []struct {
+		name   string
+		filter models.TaskFilter
+		want   bool
+	}{
+		{"no filter", models.TaskFilter{}, true},
+		{"title, ignoring case", models.TaskFilter{Search: "FENCE"}, true},
+		{"notes text", models.TaskFilter{Search: "nails & paint"}, true},
+		{"not notes markup", models.TaskFilter{Search: "<b>"}, false},
+		{"category", models.TaskFilter{Category: "Home"}, true},
+		{"other category", models.TaskFilter{Category: "Work"}, false},
+		{"importance", models.TaskFilter{Importance: "high"}, true},
+		{"other importance", models.TaskFilter{Importance: "low"}, false},
+		{"due in range", models.TaskFilter{DueAfter: "2025-06-01", DueBefore: "2025-06-03"}, true},
+		{"due on the before date", models.TaskFilter{DueBefore: "2025-06-02"}, false},
+		{"due on the after date", models.TaskFilter{DueAfter: "2025-06-02"}, false},
+		{"overdue", models.TaskFilter{Overdue: true, Today: "2025-06-03"}, true},
+		{"due today isn't overdue", models.TaskFilter{Overdue: true, Today: "2025-06-02"}, false},
+		{"every part must match", models.TaskFilter{Search: "fence", Importance: "low"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.filter.Matches(task); got != test.want {
+				t.Errorf("Matches = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	// Completed and undated tasks are never overdue
+	completed := task
+	completed.Status = "completed"
+	if (models.TaskFilter{Overdue: true, Today: "2025-06-03"}).Matches(completed) {
+		t.Errorf("completed task matched the overdue filter")
+	}
+	undated := task
+	undated.DueDateTime = nil
+	if (models.TaskFilter{DueBefore: "2025-06-03"}).Matches(undated) {
+		t.Errorf("undated task matched a due date filter")
+	}
+}
@@ -7,26 +7,124 @@ package models
 
 import "time"
 
+// Well-known list names; lists with a well-known name other than WellknownListNone can't be renamed or deleted
+const (
+	WellknownListNone          = "none"
+	WellknownListDefault       = "defaultList"
+	WellknownListFlaggedEmails = "flaggedEmails"
+)
+
 // TodoList represents a Microsoft To Do list
 type TodoList struct {
-	ID          string `json:"id"`
-	DisplayName string `json:"displayName"`
+	ID                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	IsOwner           bool   `json:"isOwner"`                     // false for lists shared with the user by someone else
+	IsShared          bool   `json:"isShared"`                    // true if the list is shared with other people
+	WellknownListName string `json:"wellknownListName,omitempty"` // such as "defaultList" for the built-in Tasks list
+}
+
+// IsWellknown reports whether the list is one of Microsoft To Do's built-in lists
+func (l TodoList) IsWellknown() bool {
+	return l.WellknownListName != "" && l.WellknownListName != WellknownListNone
 }
 
 // TodoListResponse represents the response from the Microsoft Graph API
 type TodoListResponse struct {
-	Value []TodoList `json:"value"`
+	Value    []TodoList `json:"value"`
+	NextLink string     `json:"@odata.nextLink,omitempty"`
 }
 
 // Task represents a Microsoft To Do task
 type Task struct {
-	ID              string    `json:"id"`
-	Title           string    `json:"title"`
-	Status          string    `json:"status"`
-	Importance      string    `json:"importance"`
-	DueDateTime     *DateTime `json:"dueDateTime,omitempty"`
-	CreatedDateTime string    `json:"createdDateTime"`
-	Categories      []string  `json:"categories,omitempty"`
+	ID                string               `json:"id"`
+	Title             string               `json:"title"`
+	Status            string               `json:"status"`
+	Importance        string               `json:"importance"`
+	Body              *ItemBody            `json:"body,omitempty"` // notes
+	DueDateTime       *DateTime            `json:"dueDateTime,omitempty"`
+	StartDateTime     *DateTime            `json:"startDateTime,omitempty"`
+	CompletedDateTime *DateTime            `json:"completedDateTime,omitempty"` // set by the backend when the task is completed
+	IsReminderOn      bool                 `json:"isReminderOn"`
+	HasAttachments    bool                 `json:"hasAttachments"`
+	ReminderDateTime  *DateTime            `json:"reminderDateTime,omitempty"`
+	Recurrence        *PatternedRecurrence `json:"recurrence,omitempty"` // completing a recurring task creates its next occurrence
+	CreatedDateTime   string               `json:"createdDateTime"`
+	Categories        []string             `json:"categories,omitempty"`
+	ChecklistItems    []ChecklistItem      `json:"checklistItems,omitempty"` // subtasks
+	ETag              string               `json:"@odata.etag,omitempty"`    // version of the task, changed on every update
+}
+
+// ChecklistItem is a subtask of a Microsoft To Do task
+type ChecklistItem struct {
+	ID              string `json:"id"`
+	DisplayName     string `json:"displayName"`
+	IsChecked       bool   `json:"isChecked"`
+	CreatedDateTime string `json:"createdDateTime,omitempty"`
+	CheckedDateTime string `json:"checkedDateTime,omitempty"`
+}
+
+// LinkedResource is a link from a task to an item in another app, such as a pull request or a document
+type LinkedResource struct {
+	ID              string `json:"id,omitempty"`
+	WebURL          string `json:"webUrl"`
+	ApplicationName string `json:"applicationName,omitempty"`
+	DisplayName     string `json:"displayName,omitempty"`
+	ExternalID      string `json:"externalId,omitempty"`
+}
+
+// LinkedResourceResponse represents the response from the Microsoft Graph API for linked resources
+type LinkedResourceResponse struct {
+	Value    []LinkedResource `json:"value"`
+	NextLink string           `json:"@odata.nextLink,omitempty"`
+}
+
+// Attachment is a file attached to a task. ContentBytes is only set when a single attachment is read.
+type Attachment struct {
+	ID                   string `json:"id,omitempty"`
+	Name                 string `json:"name"`
+	ContentType          string `json:"contentType,omitempty"`
+	Size                 int64  `json:"size"`
+	LastModifiedDateTime string `json:"lastModifiedDateTime,omitempty"`
+	ContentBytes         []byte `json:"contentBytes,omitempty"` // base64 in JSON
+}
+
+// AttachmentResponse represents the response from the Microsoft Graph API for attachments
+type AttachmentResponse struct {
+	Value    []Attachment `json:"value"`
+	NextLink string       `json:"@odata.nextLink,omitempty"`
+}
+
+// UploadSession is where the content of a large attachment is uploaded in ranges
+type UploadSession struct {
+	UploadURL          string   `json:"uploadUrl"`
+	ExpirationDateTime string   `json:"expirationDateTime,omitempty"`
+	NextExpectedRanges []string `json:"nextExpectedRanges,omitempty"`
+}
+
+// ChecklistItemResponse represents the response from the Microsoft Graph API for checklist items
+type ChecklistItemResponse struct {
+	Value    []ChecklistItem `json:"value"`
+	NextLink string          `json:"@odata.nextLink,omitempty"`
+}
+
+// ItemBody is the content of a task's notes
+type ItemBody struct {
+	Content     string `json:"content"`
+	ContentType string `json:"contentType"` // "text" or "html"
+}
+
+// TaskUpdate is the set of fields edited in the task details modal
+type TaskUpdate struct {
+	Title      string
+	Status     string // a Graph status, such as "notStarted", "completed" or "waitingOnOthers"
+	Importance string
+	DueDate    string // YYYY-MM-DD, or empty to clear
+	StartDate  string // YYYY-MM-DD, or empty to clear
+	Categories []string
+	Body       *string // plain-text notes, or nil to leave them unchanged
+	ReminderOn bool
+	Reminder   time.Time            // when the reminder fires; ignored unless ReminderOn
+	Recurrence *PatternedRecurrence // nil leaves the recurrence unchanged; an empty pattern type removes it
 }
 
 // DateTime represents a date and time in Microsoft Graph API
@@ -35,15 +133,131 @@ type DateTime struct {
 	TimeZone string `json:"timeZone"`
 }
 
+// NewDate returns the DateTime used for a date-only value such as a due date, or nil if date is empty
+func NewDate(date string) *DateTime {
+	if date == "" {
+		return nil
+	}
+	return &DateTime{DateTime: date + "T00:00:00Z", TimeZone: "UTC"}
+}
+
+// NewDateTime returns t as a UTC DateTime
+func NewDateTime(t time.Time) *DateTime {
+	return &DateTime{DateTime: t.UTC().Format(time.RFC3339), TimeZone: "UTC"}
+}
+
+// graphDateTimeLayout is the zone-less format Microsoft Graph uses in DateTime values
+const graphDateTimeLayout = "2006-01-02T15:04:05.9999999"
+
+// Time parses the date and time, in its time zone if it doesn't carry an offset itself.
+// Unknown time zones are treated as UTC.
+func (d *DateTime) Time() (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, d.DateTime); err == nil {
+		return t, nil
+	}
+
+	loc, err := time.LoadLocation(d.TimeZone)
+	if err != nil || d.TimeZone == "" {
+		loc = time.UTC
+	}
+	return time.ParseInLocation(graphDateTimeLayout, d.DateTime, loc)
+}
+
 // TaskResponse represents the response from the Microsoft Graph API for tasks
 type TaskResponse struct {
-	Value []Task `json:"value"`
+	Value    []Task `json:"value"`
+	NextLink string `json:"@odata.nextLink,omitempty"`
+}
+
+// TaskDelta is the set of changes to a list's tasks reported by a delta query
+type TaskDelta struct {
+	Tasks     []Task   // tasks added or changed since the previous query
+	Removed   []string // IDs of tasks deleted since the previous query
+	DeltaLink string   // pass to the next delta query to get later changes
+}
+
+// Subscription is a Microsoft Graph change-notification subscription
+type Subscription struct {
+	ID                       string    `json:"id,omitempty"`
+	Resource                 string    `json:"resource"`
+	ChangeType               string    `json:"changeType"`
+	NotificationURL          string    `json:"notificationUrl"`
+	LifecycleNotificationURL string    `json:"lifecycleNotificationUrl,omitempty"`
+	ClientState              string    `json:"clientState,omitempty"`
+	ExpirationDateTime       time.Time `json:"expirationDateTime"`
+}
+
+// ChangeNotification is a change, or a subscription lifecycle event, posted to a notification URL
+type ChangeNotification struct {
+	SubscriptionID string        `json:"subscriptionId"`
+	ClientState    string        `json:"clientState"`
+	ChangeType     string        `json:"changeType,omitempty"`
+	Resource       string        `json:"resource,omitempty"`
+	ResourceData   *ResourceData `json:"resourceData,omitempty"`
+	LifecycleEvent string        `json:"lifecycleEvent,omitempty"`
+}
+
+// ResourceData identifies the item a change notification is about
+type ResourceData struct {
+	ID string `json:"id"`
+}
+
+// ChangeNotificationCollection is the body Microsoft Graph posts to a notification URL
+type ChangeNotificationCollection struct {
+	Value []ChangeNotification `json:"value"`
+}
+
+// ColumnDefinition defines a Kanban column by the Graph status and/or marker category of its tasks.
+// A column with neither set is the catch-all for tasks that match no other column.
+type ColumnDefinition struct {
+	Title    string `json:"title"`
+	Status   string `json:"status,omitempty"`   // Graph status, e.g. "completed"
+	Category string `json:"category,omitempty"` // marker category, e.g. "Doing" or "Blocked"
+	WIPLimit int    `json:"wipLimit,omitempty"` // maximum number of tasks, or 0 for no limit
+}
+
+// BoardDefinition is the ordered set of columns shown on a Kanban board
+type BoardDefinition struct {
+	Columns []ColumnDefinition `json:"columns"`
 }
 
 // KanbanColumn represents a column in the Kanban board
 type KanbanColumn struct {
-	Title string
-	Tasks []TaskDisplay
+	Title     string
+	Tasks     []TaskDisplay
+	Count     int  // tasks in the column, including any a filter hides
+	WIPLimit  int  // 0 if the column has no limit
+	OverLimit bool // true if the column holds more tasks than its limit
+}
+
+// Hidden returns how many of the column's tasks a filter hides
+func (c KanbanColumn) Hidden() int {
+	return c.Count - len(c.Tasks)
+}
+
+// TaskStatuses are the statuses a Microsoft To Do task can have, with their names as shown on the board
+var TaskStatuses = []StatusOption{
+	{Value: "notStarted", Label: "Not Started"},
+	{Value: "inProgress", Label: "In Progress"},
+	{Value: "waitingOnOthers", Label: "Waiting on Others"},
+	{Value: "deferred", Label: "Deferred"},
+	{Value: "completed", Label: "Completed"},
+}
+
+// StatusOption is a task status offered in the task details modal
+type StatusOption struct {
+	Value string
+	Label string
+}
+
+// IsTaskStatus reports whether status is one of TaskStatuses
+func IsTaskStatus(status string) bool {
+	for _, s := range TaskStatuses {
+		if s.Value == status {
+			return true
+		}
+	}
+	return false
 }
 
 // TaskViewModel is used for rendering tasks in the template
@@ -51,16 +265,34 @@ type TaskViewModel struct {
 	ListID   string
 	ListName string
 	Columns  []KanbanColumn
+	Statuses []StatusOption // statuses the task details modal offers
+	Lists    []TodoList     // lists shown on an aggregate board; empty on a single list's board
+	Filter   TaskFilter     // filter applied to a single list's board
+}
+
+// Aggregate reports whether the board shows the tasks of several lists
+func (m TaskViewModel) Aggregate() bool {
+	return len(m.Lists) > 0
 }
 
 // TaskDisplay is a simplified version of Task for display
 type TaskDisplay struct {
-	ID          string
-	Title       string
-	Status      bool     // true if completed
-	Importance  bool     // true if high importance
-	DueDateTime string   // formatted date string
-	Categories  []string // list of categories
+	ID                string
+	ListID            string // list the task belongs to
+	ListName          string // name of that list, shown on aggregate boards
+	Title             string
+	Status            bool     // true if completed
+	Importance        bool     // true if high importance
+	DueDateTime       string   // formatted date string
+	StartDateTime     string   // formatted date string
+	CompletedDateTime string   // formatted date string
+	HasNotes          bool     // true if the task has notes
+	ReminderOn        bool     // true if a reminder is set
+	HasAttachments    bool     // true if files are attached
+	Recurrence        string   // description of how the task repeats, if it does
+	ChecklistChecked  int      // number of checked checklist items
+	ChecklistTotal    int      // number of checklist items
+	Categories        []string // list of categories
 }
 
 // TokenResponse represents the OAuth token response
@@ -76,4 +308,5 @@ type Session struct {
 	AccessToken  string
 	RefreshToken string
 	ExpiresAt    time.Time
+	CreatedAt    time.Time
 }
@@ -0,0 +1,332 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Recurrence pattern types
+const (
+	RecurrenceDaily           = "daily"
+	RecurrenceWeekly          = "weekly"
+	RecurrenceAbsoluteMonthly = "absoluteMonthly"
+	RecurrenceRelativeMonthly = "relativeMonthly"
+	RecurrenceAbsoluteYearly  = "absoluteYearly"
+	RecurrenceRelativeYearly  = "relativeYearly"
+)
+
+// Recurrence range types
+const (
+	RecurrenceNoEnd    = "noEnd"
+	RecurrenceEndDate  = "endDate"
+	RecurrenceNumbered = "numbered"
+)
+
+// maxRecurrenceYears bounds how far Next looks for an occurrence
+const maxRecurrenceYears = 100
+
+// PatternedRecurrence is how a task repeats: when (the pattern) and for how long (the range)
+type PatternedRecurrence struct {
+	Pattern RecurrencePattern `json:"pattern"`
+	Range   RecurrenceRange   `json:"range"`
+}
+
+// RecurrencePattern is the frequency of a recurring task
+type RecurrencePattern struct {
+	Type           string   `json:"type"`
+	Interval       int      `json:"interval"`
+	Month          int      `json:"month,omitempty"`
+	DayOfMonth     int      `json:"dayOfMonth,omitempty"`
+	DaysOfWeek     []string `json:"daysOfWeek,omitempty"` // lowercase English day names, such as "monday"
+	FirstDayOfWeek string   `json:"firstDayOfWeek,omitempty"`
+	Index          string   `json:"index,omitempty"` // first, second, third, fourth or last, for relative patterns
+}
+
+// RecurrenceRange is the period a task recurs over; dates are formatted as 2006-01-02
+type RecurrenceRange struct {
+	Type                string `json:"type"`
+	StartDate           string `json:"startDate,omitempty"`
+	EndDate             string `json:"endDate,omitempty"`
+	RecurrenceTimeZone  string `json:"recurrenceTimeZone,omitempty"`
+	NumberOfOccurrences int    `json:"numberOfOccurrences,omitempty"`
+}
+
+// Next returns the first occurrence after the given date, or false if the range has ended.
+// Dates are compared by day, in UTC.
+func (rec *PatternedRecurrence) Next(after time.Time) (time.Time, bool) {
+	after = day(after)
+	start, err := time.Parse("2006-01-02", rec.Range.StartDate)
+	if err != nil {
+		start = after
+	}
+	end, hasEnd := rec.endDate()
+
+	count := 0
+	limit := start.AddDate(maxRecurrenceYears, 0, 0)
+	for d := start; !d.After(limit); d = d.AddDate(0, 0, 1) {
+		if hasEnd && d.After(end) {
+			return time.Time{}, false
+		}
+		if !rec.Pattern.matches(start, d) {
+			continue
+		}
+		count++
+		if rec.Range.Type == RecurrenceNumbered && count > rec.Range.NumberOfOccurrences {
+			return time.Time{}, false
+		}
+		if d.After(after) {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// NextOccurrence returns the task Microsoft To Do creates when a recurring task is completed:
+// a not started copy due on the next occurrence, with its start date and reminder moved along,
+// its checklist unchecked and no ID. It returns false if the task doesn't recur or its range has ended.
+func NextOccurrence(task Task) (Task, bool) {
+	if task.Recurrence == nil || task.DueDateTime == nil {
+		return Task{}, false
+	}
+	due, err := task.DueDateTime.Time()
+	if err != nil {
+		return Task{}, false
+	}
+	next, ok := task.Recurrence.Next(due)
+	if !ok {
+		return Task{}, false
+	}
+	shift := next.Sub(day(due))
+	move := func(dt *DateTime) *DateTime {
+		if dt == nil {
+			return nil
+		}
+		t, err := dt.Time()
+		if err != nil {
+			return nil
+		}
+		return NewDateTime(t.Add(shift))
+	}
+
+	recurrence := *task.Recurrence
+	recurrence.Pattern.DaysOfWeek = append([]string(nil), recurrence.Pattern.DaysOfWeek...)
+	occurrence := Task{
+		Title:            task.Title,
+		Status:           "notStarted",
+		Importance:       task.Importance,
+		DueDateTime:      NewDate(next.Format("2006-01-02")),
+		StartDateTime:    move(task.StartDateTime),
+		IsReminderOn:     task.IsReminderOn,
+		ReminderDateTime: move(task.ReminderDateTime),
+		Recurrence:       &recurrence,
+		Categories:       append([]string(nil), task.Categories...),
+		ChecklistItems:   make([]ChecklistItem, len(task.ChecklistItems)),
+	}
+	if task.Body != nil {
+		body := *task.Body
+		occurrence.Body = &body
+	}
+	for i, item := range task.ChecklistItems {
+		occurrence.ChecklistItems[i] = ChecklistItem{DisplayName: item.DisplayName}
+	}
+	return occurrence, true
+}
+
+// Describe returns a short description such as "Every 2 weeks on Mon, Thu, until Jun 30, 2025"
+func (rec *PatternedRecurrence) Describe() string {
+	p := rec.Pattern
+	interval := p.interval()
+
+	var text string
+	switch p.Type {
+	case RecurrenceDaily:
+		text = every(interval, "day", "days")
+	case RecurrenceWeekly:
+		days := make([]string, len(p.DaysOfWeek))
+		for i, name := range p.DaysOfWeek {
+			days[i] = capitalize(name)
+			if len(days[i]) > 3 {
+				days[i] = days[i][:3]
+			}
+		}
+		text = every(interval, "week", "weeks")
+		if len(days) > 0 {
+			text += " on " + strings.Join(days, ", ")
+		}
+	case RecurrenceAbsoluteMonthly:
+		text = fmt.Sprintf("%s on day %d", every(interval, "month", "months"), p.DayOfMonth)
+	case RecurrenceRelativeMonthly:
+		text = fmt.Sprintf("%s on the %s %s", every(interval, "month", "months"), p.Index, weekdayNames(p.DaysOfWeek))
+	case RecurrenceAbsoluteYearly:
+		text = fmt.Sprintf("%s on %s %d", every(interval, "year", "years"), monthName(p.Month), p.DayOfMonth)
+	case RecurrenceRelativeYearly:
+		text = fmt.Sprintf("%s on the %s %s of %s", every(interval, "year", "years"), p.Index, weekdayNames(p.DaysOfWeek), monthName(p.Month))
+	default:
+		text = "Repeats"
+	}
+
+	if end, ok := rec.endDate(); ok {
+		text += ", until " + end.Format("Jan 2, 2006")
+	} else if rec.Range.Type == RecurrenceNumbered && rec.Range.NumberOfOccurrences > 0 {
+		text += fmt.Sprintf(", %d times", rec.Range.NumberOfOccurrences)
+	}
+	return text
+}
+
+// endDate returns the last day of an endDate range
+func (rec *PatternedRecurrence) endDate() (time.Time, bool) {
+	if rec.Range.Type != RecurrenceEndDate {
+		return time.Time{}, false
+	}
+	end, err := time.Parse("2006-01-02", rec.Range.EndDate)
+	if err != nil || end.Year() <= 1 {
+		return time.Time{}, false
+	}
+	return end, true
+}
+
+// matches reports whether the pattern, starting at start, falls on day d
+func (p RecurrencePattern) matches(start time.Time, d time.Time) bool {
+	interval := p.interval()
+	months := (d.Year()-start.Year())*12 + int(d.Month()) - int(start.Month())
+
+	switch p.Type {
+	case RecurrenceDaily:
+		return int(d.Sub(start).Hours()/24)%interval == 0
+	case RecurrenceWeekly:
+		days := p.DaysOfWeek
+		if len(days) == 0 {
+			days = []string{weekdayName(start.Weekday())}
+		}
+		weeks := int(p.weekStart(d).Sub(p.weekStart(start)).Hours() / 24 / 7)
+		return weeks%interval == 0 && containsDay(days, d.Weekday())
+	case RecurrenceAbsoluteMonthly:
+		return months%interval == 0 && d.Day() == clampDay(d, p.dayOfMonth(start))
+	case RecurrenceRelativeMonthly:
+		return months%interval == 0 && p.isIndexedWeekday(d)
+	case RecurrenceAbsoluteYearly:
+		return (d.Year()-start.Year())%interval == 0 && d.Month() == p.month(start) && d.Day() == clampDay(d, p.dayOfMonth(start))
+	case RecurrenceRelativeYearly:
+		return (d.Year()-start.Year())%interval == 0 && d.Month() == p.month(start) && p.isIndexedWeekday(d)
+	}
+	return false
+}
+
+// isIndexedWeekday reports whether d is, for example, the second Tuesday or the last Friday of its month
+func (p RecurrencePattern) isIndexedWeekday(d time.Time) bool {
+	if !containsDay(p.DaysOfWeek, d.Weekday()) {
+		return false
+	}
+	if p.Index == "last" {
+		return d.AddDate(0, 0, 7).Month() != d.Month()
+	}
+	for i, index := range []string{"first", "second", "third", "fourth"} {
+		if p.Index == index {
+			return (d.Day()-1)/7 == i
+		}
+	}
+	return false
+}
+
+// weekStart returns the first day of d's week
+func (p RecurrencePattern) weekStart(d time.Time) time.Time {
+	first := time.Sunday
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if weekdayName(wd) == p.FirstDayOfWeek {
+			first = wd
+		}
+	}
+	return d.AddDate(0, 0, -((int(d.Weekday()) - int(first) + 7) % 7))
+}
+
+// interval returns the pattern's interval, treating 0 as 1
+func (p RecurrencePattern) interval() int {
+	if p.Interval < 1 {
+		return 1
+	}
+	return p.Interval
+}
+
+// dayOfMonth returns the pattern's day of the month, defaulting to the start's
+func (p RecurrencePattern) dayOfMonth(start time.Time) int {
+	if p.DayOfMonth < 1 {
+		return start.Day()
+	}
+	return p.DayOfMonth
+}
+
+// month returns the pattern's month, defaulting to the start's
+func (p RecurrencePattern) month(start time.Time) time.Month {
+	if p.Month < 1 || p.Month > 12 {
+		return start.Month()
+	}
+	return time.Month(p.Month)
+}
+
+// clampDay limits a day of the month to the length of d's month, so day 31 falls on the last day of shorter months
+func clampDay(d time.Time, dayOfMonth int) int {
+	last := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if dayOfMonth > last {
+		return last
+	}
+	return dayOfMonth
+}
+
+// day truncates a time to midnight UTC of its date
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// containsDay reports whether days names the weekday
+func containsDay(days []string, wd time.Weekday) bool {
+	for _, name := range days {
+		if strings.EqualFold(name, weekdayName(wd)) {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdayName returns the Graph name of a weekday, such as "monday"
+func weekdayName(wd time.Weekday) string {
+	return strings.ToLower(wd.String())
+}
+
+// weekdayNames joins day names for display, such as "Monday or Friday"
+func weekdayNames(days []string) string {
+	names := make([]string, len(days))
+	for i, name := range days {
+		names[i] = capitalize(name)
+	}
+	return strings.Join(names, " or ")
+}
+
+// capitalize upper-cases the first letter of a day name
+func capitalize(name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// monthName returns the short name of a month number
+func monthName(month int) string {
+	if month < 1 || month > 12 {
+		return ""
+	}
+	return time.Month(month).String()[:3]
+}
+
+// every returns "Every day" for an interval of 1 and "Every 3 days" otherwise
+func every(interval int, singular string, plural string) string {
+	if interval == 1 {
+		return "Every " + singular
+	}
+	return fmt.Sprintf("Every %d %s", interval, plural)
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// date parses a 2006-01-02 date for the tests
+func date(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	d, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatalf("bad test date %q: %v", value, err)
+	}
+	return d
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrence models.PatternedRecurrence
+		after      string
+		want       string // empty if the range has ended
+	}{
+		{
+			name:       "every other day",
+			recurrence: models.PatternedRecurrence{Pattern: models.RecurrencePattern{Type: models.RecurrenceDaily, Interval: 2}, Range: models.RecurrenceRange{Type: models.RecurrenceNoEnd, StartDate: "2025-06-02"}},
+			after:      "2025-06-02",
+			want:       "2025-06-04",
+		},
+		{
+			name: "Mondays and Thursdays",
+			recurrence: models.PatternedRecurrence{
+				Pattern: models.RecurrencePattern{Type: models.RecurrenceWeekly, Interval: 1, DaysOfWeek: []string{"monday", "thursday"}, FirstDayOfWeek: "sunday"},
+				Range:   models.RecurrenceRange{Type: models.RecurrenceNoEnd, StartDate: "2025-06-02"},
+			},
+			after: "2025-06-02",
+			want:  "2025-06-05",
+		},
+		{
+			name: "every two weeks",
+			recurrence: models.PatternedRecurrence{
+				Pattern: models.RecurrencePattern{Type: models.RecurrenceWeekly, Interval: 2, DaysOfWeek: []string{"monday"}, FirstDayOfWeek: "sunday"},
+				Range:   models.RecurrenceRange{Type: models.RecurrenceNoEnd, StartDate: "2025-06-02"},
+			},
+			after: "2025-06-02",
+			want:  "2025-06-16",
+		},
+		{
+			name:       "day 31 of short months",
+			recurrence: models.PatternedRecurrence{Pattern: models.RecurrencePattern{Type: models.RecurrenceAbsoluteMonthly, Interval: 1, DayOfMonth: 31}, Range: models.RecurrenceRange{Type: models.RecurrenceNoEnd, StartDate: "2025-01-31"}},
+			after:      "2025-01-31",
+			want:       "2025-02-28",
+		},
+		{
+			name: "last Friday of the month",
+			recurrence: models.PatternedRecurrence{
+				Pattern: models.RecurrencePattern{Type: models.RecurrenceRelativeMonthly, Interval: 1, DaysOfWeek: []string{"friday"}, Index: "last"},
+				Range:   models.RecurrenceRange{Type: models.RecurrenceNoEnd, StartDate: "2025-05-30"},
+			},
+			after: "2025-05-30",
+			want:  "2025-06-27",
+		},
+		{
+			name:       "yearly",
+			recurrence: models.PatternedRecurrence{Pattern: models.RecurrencePattern{Type: models.RecurrenceAbsoluteYearly, Interval: 1, Month: 3, DayOfMonth: 15}, Range: models.RecurrenceRange{Type: models.RecurrenceNoEnd, StartDate: "2025-03-15"}},
+			after:      "2025-03-15",
+			want:       "2026-03-15",
+		},
+		{
+			name:       "past the end date",
+			recurrence: models.PatternedRecurrence{Pattern: models.RecurrencePattern{Type: models.RecurrenceDaily, Interval: 7}, Range: models.RecurrenceRange{Type: models.RecurrenceEndDate, StartDate: "2025-06-02", EndDate: "2025-06-10"}},
+			after:      "2025-06-09",
+			want:       "",
+		},
+		{
+			name:       "out of occurrences",
+			recurrence: models.PatternedRecurrence{Pattern: models.RecurrencePattern{Type: models.RecurrenceDaily, Interval: 1}, Range: models.RecurrenceRange{Type: models.RecurrenceNumbered, StartDate: "2025-06-02", NumberOfOccurrences: 3}},
+			after:      "2025-06-04",
+			want:       "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			next, ok := test.recurrence.Next(date(t, test.after))
+			if test.want == "" {
+				if ok {
+					t.Errorf("Next = %s, want the range to have ended", next.Format("2006-01-02"))
+				}
+				return
+			}
+			if !ok || next.Format("2006-01-02") != test.want {
+				t.Errorf("Next = %s, %v, want %s", next.Format("2006-01-02"), ok, test.want)
+			}
+		})
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	task := models.Task{
+		ID:            "task-1",
+		Title:         "Water plants",
+		Status:        "completed",
+		DueDateTime:   models.NewDate("2025-06-02"),
+		StartDateTime: models.NewDate("2025-06-01"),
+		Recurrence: &models.PatternedRecurrence{
+			Pattern: models.RecurrencePattern{Type: models.RecurrenceWeekly, Interval: 1, DaysOfWeek: []string{"monday"}},
+			Range:   models.RecurrenceRange{Type: models.RecurrenceNoEnd, StartDate: "2025-06-02"},
+		},
+		ChecklistItems: []models.ChecklistItem{{ID: "item-1", DisplayName: "Balcony", IsChecked: true}},
+	}
+
+	next, ok := models.NextOccurrence(task)
+	if !ok {
+		t.Fatalf("NextOccurrence found no occurrence")
+	}
+	if next.ID != "" || next.Status != "notStarted" || next.Title != "Water plants" {
+		t.Errorf("unexpected occurrence: %+v", next)
+	}
+	if due, _ := next.DueDateTime.Time(); due.Format("2006-01-02") != "2025-06-09" {
+		t.Errorf("due = %v, want 2025-06-09", due)
+	}
+	if start, _ := next.StartDateTime.Time(); start.Format("2006-01-02") != "2025-06-08" {
+		t.Errorf("start = %v, want 2025-06-08", start)
+	}
+	if len(next.ChecklistItems) != 1 || next.ChecklistItems[0].IsChecked || next.ChecklistItems[0].ID != "" {
+		t.Errorf("checklist not reset: %+v", next.ChecklistItems)
+	}
+	next.Recurrence.Pattern.DaysOfWeek[0] = "friday"
+	if task.Recurrence.Pattern.DaysOfWeek[0] != "monday" {
+		t.Errorf("occurrence shares its recurrence with the completed task")
+	}
+
+	// Tasks without a due date don't recur
+	task.DueDateTime = nil
+	if _, ok := models.NextOccurrence(task); ok {
+		t.Errorf("NextOccurrence found an occurrence of a task without a due date")
+	}
+}
+
+func TestRecurrenceDescribe(t *testing.T) {
+	tests := []struct {
+		recurrence models.PatternedRecurrence
+		want       string
+	}{
+		{models.PatternedRecurrence{Pattern: models.RecurrencePattern{Type: models.RecurrenceDaily, Interval: 1}, Range: models.RecurrenceRange{Type: models.RecurrenceNoEnd}}, "Every day"},
+		{
+			models.PatternedRecurrence{
+				Pattern: models.RecurrencePattern{Type: models.RecurrenceWeekly, Interval: 2, DaysOfWeek: []string{"monday", "thursday"}},
+				Range:   models.RecurrenceRange{Type: models.RecurrenceEndDate, EndDate: "2025-06-30"},
+			},
+			"Every 2 weeks on Mon, Thu, until Jun 30, 2025",
+		},
+		{models.PatternedRecurrence{Pattern: models.RecurrencePattern{Type: models.RecurrenceAbsoluteMonthly, Interval: 1, DayOfMonth: 15}, Range: models.RecurrenceRange{Type: models.RecurrenceNumbered, NumberOfOccurrences: 10}}, "Every month on day 15, 10 times"},
+		{models.PatternedRecurrence{Pattern: models.RecurrencePattern{Type: models.RecurrenceAbsoluteYearly, Interval: 1, Month: 3, DayOfMonth: 15}, Range: models.RecurrenceRange{Type: models.RecurrenceNoEnd}}, "Every year on Mar 15"},
+	}
+
+	for _, test := range tests {
+		if got := test.recurrence.Describe(); got != test.want {
+			t.Errorf("Describe = %q, want %q", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/service"
+)
+
+const (
+	// DefaultExpiry is how long subscriptions last; Graph allows up to 4230 minutes for To Do tasks
+	DefaultExpiry = 48 * time.Hour
+	// DefaultRenewBefore is how long before expiry a subscription is renewed
+	DefaultRenewBefore = 12 * time.Hour
+	// DefaultMaxIdle is how long a list can go unwatched before its subscription is deleted
+	DefaultMaxIdle = 24 * time.Hour
+	// requestTimeout bounds each subscription call made in the background
+	requestTimeout = 30 * time.Second
+	// maxNotificationSize caps the body accepted from Graph
+	maxNotificationSize = 1 << 20
+)
+
+// key identifies the subscription of one session to one list
+type key struct {
+	sessionID string
+	listID    string
+}
+
+// subscription is a Graph subscription created by a GraphNotifier
+type subscription struct {
+	key         key
+	id          string
+	clientState string
+	expiresAt   time.Time
+	watchedAt   time.Time
+}
+
+// GraphNotifier subscribes to Microsoft Graph change notifications for the lists
+// users open, and receives them as an http.Handler at the notification URL
+type GraphNotifier struct {
+	client          service.SubscriptionService
+	notificationURL string
+	tokens          TokenSource
+	onChange        func(Change)
+
+	// Expiry is how long each subscription lasts when created or renewed
+	Expiry time.Duration
+	// RenewBefore is how long before expiry a subscription is renewed
+	RenewBefore time.Duration
+	// MaxIdle is how long a list can go unwatched before its subscription is deleted
+	MaxIdle time.Duration
+
+	mu       sync.Mutex
+	byKey    map[key]*subscription
+	byID     map[string]*subscription
+	creating map[key]bool
+}
+
+// NewGraphNotifier creates a GraphNotifier.
+// notificationURL must be a public HTTPS URL that routes to the notifier's ServeHTTP.
+func NewGraphNotifier(client service.SubscriptionService, notificationURL string, tokens TokenSource, onChange func(Change)) *GraphNotifier {
+	return &GraphNotifier{
+		client:          client,
+		notificationURL: notificationURL,
+		tokens:          tokens,
+		onChange:        onChange,
+		Expiry:          DefaultExpiry,
+		RenewBefore:     DefaultRenewBefore,
+		MaxIdle:         DefaultMaxIdle,
+		byKey:           make(map[key]*subscription),
+		byID:            make(map[string]*subscription),
+		creating:        make(map[key]bool),
+	}
+}
+
+// Watch subscribes to changes to a list's tasks, or keeps an existing subscription alive
+func (n *GraphNotifier) Watch(ctx context.Context, sessionID string, listID string) error {
+	k := key{sessionID: sessionID, listID: listID}
+
+	n.mu.Lock()
+	if sub, ok := n.byKey[k]; ok {
+		sub.watchedAt = time.Now()
+		n.mu.Unlock()
+		return nil
+	}
+	if n.creating[k] {
+		n.mu.Unlock()
+		return nil
+	}
+	n.creating[k] = true
+	n.mu.Unlock()
+
+	defer func() {
+		n.mu.Lock()
+		delete(n.creating, k)
+		n.mu.Unlock()
+	}()
+
+	accessToken, err := n.tokens(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	clientState, err := newClientState()
+	if err != nil {
+		return err
+	}
+
+	// Graph calls back to the notification URL to validate it before this returns
+	created, err := n.client.CreateSubscription(ctx, accessToken, models.Subscription{
+		Resource:                 fmt.Sprintf("/me/todo/lists/%s/tasks", listID),
+		ChangeType:               "created,updated,deleted",
+		NotificationURL:          n.notificationURL,
+		LifecycleNotificationURL: n.notificationURL,
+		ClientState:              clientState,
+		ExpirationDateTime:       time.Now().Add(n.Expiry).UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error subscribing to list %s: %w", listID, err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	sub := &subscription{
+		key:         k,
+		id:          created.ID,
+		clientState: clientState,
+		expiresAt:   created.ExpirationDateTime,
+		watchedAt:   time.Now(),
+	}
+	n.byKey[k] = sub
+	n.byID[sub.id] = sub
+	return nil
+}
+
+// Subscriptions returns the number of active subscriptions
+func (n *GraphNotifier) Subscriptions() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.byKey)
+}
+
+// Renew renews subscriptions close to expiry and deletes those for lists no longer watched
+func (n *GraphNotifier) Renew(ctx context.Context) {
+	now := time.Now()
+
+	n.mu.Lock()
+	var renew, remove []*subscription
+	for _, sub := range n.byKey {
+		switch {
+		case now.Sub(sub.watchedAt) > n.MaxIdle:
+			remove = append(remove, sub)
+		case sub.expiresAt.Sub(now) < n.RenewBefore:
+			renew = append(renew, sub)
+		}
+	}
+	n.mu.Unlock()
+
+	for _, sub := range remove {
+		n.drop(sub)
+		accessToken, err := n.tokens(ctx, sub.key.sessionID)
+		if err != nil {
+			// Without a token the subscription is left to expire
+			continue
+		}
+		if err := n.client.DeleteSubscription(ctx, accessToken, sub.id); err != nil {
+			log.Printf("Error deleting subscription %s: %v", sub.id, err)
+		}
+	}
+
+	for _, sub := range renew {
+		n.renew(ctx, sub)
+	}
+}
+
+// Start renews subscriptions in the background at the given interval.
+// It returns a function that stops the renewals.
+func (n *GraphNotifier) Start(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+				n.Renew(ctx)
+				cancel()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// ServeHTTP receives validation requests, change notifications and lifecycle events from Graph
+func (n *GraphNotifier) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Graph validates the URL by posting a token that must be echoed back as plain text
+	if token := r.URL.Query().Get("validationToken"); token != "" {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, token)
+		return
+	}
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var notifications models.ChangeNotificationCollection
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxNotificationSize)).Decode(&notifications); err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var changes []Change
+	var reauthorize []*subscription
+	n.mu.Lock()
+	for _, notification := range notifications.Value {
+		// Ignore notifications that don't carry the secret of one of our subscriptions
+		sub, ok := n.byID[notification.SubscriptionID]
+		if !ok || subtle.ConstantTimeCompare([]byte(sub.clientState), []byte(notification.ClientState)) != 1 {
+			log.Printf("Ignoring notification for unknown subscription %s", notification.SubscriptionID)
+			continue
+		}
+
+		switch notification.LifecycleEvent {
+		case "":
+			changes = append(changes, Change{ListID: sub.key.listID, TaskID: taskID(notification), Type: notification.ChangeType})
+		case "subscriptionRemoved":
+			// The next Watch subscribes again
+			n.dropLocked(sub)
+		case "reauthorizationRequired":
+			reauthorize = append(reauthorize, sub)
+		case "missed":
+			// Some changes were not delivered; treat the whole list as changed
+			changes = append(changes, Change{ListID: sub.key.listID})
+		}
+	}
+	n.mu.Unlock()
+
+	// Graph expects a quick acknowledgement
+	w.WriteHeader(http.StatusAccepted)
+
+	for _, change := range changes {
+		n.onChange(change)
+	}
+	for _, sub := range reauthorize {
+		go func(sub *subscription) {
+			ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+			defer cancel()
+			n.renew(ctx, sub)
+		}(sub)
+	}
+}
+
+// renew extends a subscription, dropping it if it can't be renewed
+func (n *GraphNotifier) renew(ctx context.Context, sub *subscription) {
+	accessToken, err := n.tokens(ctx, sub.key.sessionID)
+	if err != nil {
+		log.Printf("Dropping subscription %s: %v", sub.id, err)
+		n.drop(sub)
+		return
+	}
+
+	renewed, err := n.client.RenewSubscription(ctx, accessToken, sub.id, time.Now().Add(n.Expiry))
+	if err != nil {
+		var statusErr service.StatusError
+		if errors.As(err, &statusErr) && statusErr.HTTPStatus() == http.StatusNotFound {
+			// Graph already removed it; the next Watch subscribes again
+			n.drop(sub)
+			return
+		}
+		log.Printf("Error renewing subscription %s: %v", sub.id, err)
+		return
+	}
+
+	n.mu.Lock()
+	sub.expiresAt = renewed.ExpirationDateTime
+	n.mu.Unlock()
+}
+
+// drop forgets a subscription
+func (n *GraphNotifier) drop(sub *subscription) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.dropLocked(sub)
+}
+
+// dropLocked forgets a subscription; callers must hold the lock
+func (n *GraphNotifier) dropLocked(sub *subscription) {
+	if n.byKey[sub.key] == sub {
+		delete(n.byKey, sub.key)
+	}
+	delete(n.byID, sub.id)
+}
+
+// taskID returns the ID of the task a notification is about
+func taskID(notification models.ChangeNotification) string {
+	if notification.ResourceData != nil && notification.ResourceData.ID != "" {
+		return notification.ResourceData.ID
+	}
+	return path.Base(notification.Resource)
+}
+
+// newClientState returns a random secret that Graph echoes back in each notification
+func newClientState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating client state: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package notify_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/notify"
+	"github.com/coseguera/kanban-to-do/pkg/microsoft"
+	"github.com/coseguera/kanban-to-do/pkg/microsoft/graphtest"
+)
+
+// recorder collects the changes delivered by a notifier
+type recorder struct {
+	mu      sync.Mutex
+	changes []notify.Change
+}
+
+func (r *recorder) onChange(change notify.Change) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.changes = append(r.changes, change)
+}
+
+func (r *recorder) Changes() []notify.Change {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]notify.Change(nil), r.changes...)
+}
+
+// newTestNotifier serves a GraphNotifier at a local notification URL, subscribed through a fake Graph server
+func newTestNotifier(t *testing.T) (*graphtest.Server, *notify.GraphNotifier, *recorder) {
+	t.Helper()
+
+	graph := graphtest.NewServer()
+	t.Cleanup(graph.Close)
+
+	rec := &recorder{}
+	tokens := func(ctx context.Context, sessionID string) (string, error) {
+		return graphtest.AccessToken, nil
+	}
+
+	var notifier *notify.GraphNotifier
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notifier.ServeHTTP(w, r)
+	}))
+	t.Cleanup(endpoint.Close)
+
+	client := microsoft.NewClient(graph.Config("https://localhost/auth/callback"))
+	notifier = notify.NewGraphNotifier(client, endpoint.URL+"/api/graph/notifications", tokens, rec.onChange)
+	return graph, notifier, rec
+}
+
+func TestWatchCreatesOneSubscription(t *testing.T) {
+	graph, notifier, _ := newTestNotifier(t)
+	list := graph.AddList("Work")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := notifier.Watch(ctx, "session", list.ID); err != nil {
+			t.Fatalf("Watch failed: %v", err)
+		}
+	}
+
+	subs := graph.Subscriptions()
+	if len(subs) != 1 {
+		t.Fatalf("got %d subscriptions, want 1", len(subs))
+	}
+	if subs[0].Resource != "/me/todo/lists/"+list.ID+"/tasks" || subs[0].ClientState == "" {
+		t.Errorf("unexpected subscription: %+v", subs[0])
+	}
+	if time.Until(subs[0].ExpirationDateTime) < notify.DefaultExpiry-time.Minute {
+		t.Errorf("expiration = %v, want about %v from now", subs[0].ExpirationDateTime, notify.DefaultExpiry)
+	}
+}
+
+func TestNotificationsDeliverChanges(t *testing.T) {
+	graph, notifier, rec := newTestNotifier(t)
+	list := graph.AddList("Work")
+
+	if err := notifier.Watch(context.Background(), "session", list.ID); err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if err := graph.Notify(list.ID, "task-42", "updated"); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	changes := rec.Changes()
+	if len(changes) != 1 {
+		t.Fatalf("got %d changes, want 1", len(changes))
+	}
+	want := notify.Change{ListID: list.ID, TaskID: "task-42", Type: notify.Updated}
+	if changes[0] != want {
+		t.Errorf("change = %+v, want %+v", changes[0], want)
+	}
+}
+
+func TestNotificationsWithWrongClientStateAreIgnored(t *testing.T) {
+	graph, notifier, rec := newTestNotifier(t)
+	list := graph.AddList("Work")
+
+	if err := notifier.Watch(context.Background(), "session", list.ID); err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	sub := graph.Subscriptions()[0]
+
+	body := `{"value":[{"subscriptionId":"` + sub.ID + `","clientState":"forged","changeType":"deleted","resourceData":{"id":"task-1"}}]}`
+	w := httptest.NewRecorder()
+	notifier.ServeHTTP(w, httptest.NewRequest("POST", "/api/graph/notifications", strings.NewReader(body)))
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	if changes := rec.Changes(); len(changes) != 0 {
+		t.Errorf("forged notification was delivered: %+v", changes)
+	}
+}
+
+func TestValidationTokenIsEchoed(t *testing.T) {
+	_, notifier, _ := newTestNotifier(t)
+
+	w := httptest.NewRecorder()
+	notifier.ServeHTTP(w, httptest.NewRequest("POST", "/api/graph/notifications?validationToken=abc%20123", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "abc 123" || w.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("got %d %q (%s), want 200 \"abc 123\" as text/plain", w.Code, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+}
+
+func TestRenewExtendsAndDeletesIdleSubscriptions(t *testing.T) {
+	graph, notifier, _ := newTestNotifier(t)
+	work := graph.AddList("Work")
+	home := graph.AddList("Home")
+	ctx := context.Background()
+
+	notifier.Expiry = time.Hour
+	if err := notifier.Watch(ctx, "session", work.ID); err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	before := graph.Subscriptions()[0].ExpirationDateTime
+
+	// Everything is due for renewal
+	notifier.Expiry = 2 * time.Hour
+	notifier.RenewBefore = 3 * time.Hour
+	notifier.Renew(ctx)
+
+	after := graph.Subscriptions()[0].ExpirationDateTime
+	if !after.After(before) {
+		t.Errorf("expiration not extended: %v -> %v", before, after)
+	}
+
+	// A list nobody watches any more is unsubscribed
+	if err := notifier.Watch(ctx, "session", home.ID); err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	notifier.MaxIdle = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	notifier.Renew(ctx)
+
+	if n := len(graph.Subscriptions()); n != 0 {
+		t.Errorf("got %d subscriptions on Graph after idle cleanup, want 0", n)
+	}
+	if n := notifier.Subscriptions(); n != 0 {
+		t.Errorf("notifier still tracks %d subscriptions, want 0", n)
+	}
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	graph, notifier, rec := newTestNotifier(t)
+	list := graph.AddList("Work")
+	ctx := context.Background()
+
+	if err := notifier.Watch(ctx, "session", list.ID); err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	sub := graph.Subscriptions()[0]
+
+	// Missed notifications mark the whole list as changed
+	if err := graph.NotifyLifecycle(sub.ID, "missed"); err != nil {
+		t.Fatalf("NotifyLifecycle failed: %v", err)
+	}
+	if changes := rec.Changes(); len(changes) != 1 || changes[0].ListID != list.ID || changes[0].TaskID != "" {
+		t.Errorf("changes after missed = %+v, want one list-wide change", changes)
+	}
+
+	// A removed subscription is created again on the next Watch
+	if err := graph.NotifyLifecycle(sub.ID, "subscriptionRemoved"); err != nil {
+		t.Fatalf("NotifyLifecycle failed: %v", err)
+	}
+	if n := notifier.Subscriptions(); n != 0 {
+		t.Fatalf("notifier still tracks %d subscriptions after removal, want 0", n)
+	}
+	if err := notifier.Watch(ctx, "session", list.ID); err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if n := len(graph.Subscriptions()); n != 2 {
+		t.Errorf("got %d subscriptions on Graph, want a second one", n)
+	}
+}
+
+func TestLocalNotifier(t *testing.T) {
+	rec := &recorder{}
+	notifier := notify.NewLocalNotifier(rec.onChange)
+
+	if notifier.Notify(notify.Change{ListID: "work", TaskID: "task-1", Type: notify.Created}) {
+		t.Errorf("change for an unwatched list was delivered")
+	}
+
+	notifier.Watch(context.Background(), "session", "work")
+	if !notifier.Notify(notify.Change{ListID: "work", TaskID: "task-1", Type: notify.Created}) {
+		t.Errorf("change for a watched list was not delivered")
+	}
+	if changes := rec.Changes(); len(changes) != 1 || changes[0].TaskID != "task-1" {
+		t.Errorf("changes = %+v, want the created task", changes)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+// Package notify tells the server about task changes made outside it,
+// such as in the Microsoft To Do app
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// Change types reported by a Notifier
+const (
+	Created = "created"
+	Updated = "updated"
+	Deleted = "deleted"
+)
+
+// Change is a change to a task made outside this server.
+// An empty TaskID means any task in the list may have changed.
+type Change struct {
+	ListID string
+	TaskID string
+	Type   string
+}
+
+// Notifier watches lists for changes made outside this server
+type Notifier interface {
+	// Watch starts watching a list on behalf of a session, or keeps an existing watch alive
+	Watch(ctx context.Context, sessionID string, listID string) error
+}
+
+// TokenSource returns a current access token for a session
+type TokenSource func(ctx context.Context, sessionID string) (string, error)
+
+// LocalNotifier is a stand-in Notifier for tests and backends without change notifications.
+// It records which lists are watched and delivers the changes passed to Notify.
+type LocalNotifier struct {
+	onChange func(Change)
+
+	mu      sync.Mutex
+	watched map[string]bool
+}
+
+// NewLocalNotifier creates a LocalNotifier that delivers changes to onChange
+func NewLocalNotifier(onChange func(Change)) *LocalNotifier {
+	return &LocalNotifier{
+		onChange: onChange,
+		watched:  make(map[string]bool),
+	}
+}
+
+// Watch records that a list is being watched
+func (n *LocalNotifier) Watch(ctx context.Context, sessionID string, listID string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.watched[listID] = true
+	return nil
+}
+
+// Watching reports whether a list has been watched
+func (n *LocalNotifier) Watching(listID string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.watched[listID]
+}
+
+// Notify delivers a change if its list is watched, and reports whether it was delivered
+func (n *LocalNotifier) Notify(change Change) bool {
+	if !n.Watching(change.ListID) {
+		return false
+	}
+
+	n.onChange(change)
+	return true
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+// Package ordering stores the manual order of cards within a Kanban list
+package ordering
+
+import (
+	"sort"
+	"strings"
+)
+
+// digits is the alphabet of rank keys; keys compare lexicographically
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// Between returns a rank that sorts strictly after prev and before next.
+// An empty prev means the start of the list and an empty next means the end.
+// Keys produced here never end in '0', so there is always room for another key.
+func Between(prev, next string) string {
+	if next != "" && prev >= next {
+		// Inconsistent neighbours; fall back to ranking after prev
+		next = ""
+	}
+	return midpoint(prev, next)
+}
+
+// midpoint implements Between for prev < next
+func midpoint(a, b string) string {
+	if b != "" {
+		// Keep the common prefix and find the midpoint of what follows it
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(suffix(a, n), b[n:])
+		}
+	}
+
+	da := 0
+	if a != "" {
+		da = strings.IndexByte(digits, a[0])
+	}
+	db := len(digits)
+	if b != "" {
+		db = strings.IndexByte(digits, b[0])
+	}
+
+	if db-da > 1 {
+		return string(digits[(da+db)/2])
+	}
+
+	// The first digits are adjacent
+	if b != "" && len(b) > 1 {
+		return b[:1]
+	}
+	return string(digits[da]) + midpoint(suffix(a, 1), "")
+}
+
+// digitAt returns the digit of key at position i, treating missing digits as '0'
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return digits[0]
+}
+
+// suffix returns key from position i, or "" if key is shorter
+func suffix(key string, i int) string {
+	if i < len(key) {
+		return key[i:]
+	}
+	return ""
+}
+
+// Place computes the ranks needed to put taskID at its position in order, the full
+// top-to-bottom list of task IDs in a column. When both neighbours of the task already
+// have consistent ranks only the task is re-ranked; otherwise the whole column is.
+func Place(order []string, taskID string, ranks map[string]string) map[string]string {
+	pos := -1
+	for i, id := range order {
+		if id == taskID {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 {
+		return nil
+	}
+
+	prev, next := "", ""
+	prevOK, nextOK := true, true
+	if pos > 0 {
+		prev, prevOK = ranks[order[pos-1]]
+	}
+	if pos < len(order)-1 {
+		next, nextOK = ranks[order[pos+1]]
+	}
+
+	if prevOK && nextOK && (prev == "" || next == "" || prev < next) {
+		return map[string]string{taskID: Between(prev, next)}
+	}
+
+	// Rank every task in the column in order
+	changes := make(map[string]string, len(order))
+	rank := ""
+	for _, id := range order {
+		rank = Between(rank, "")
+		changes[id] = rank
+	}
+	return changes
+}
+
+// Sort orders items by rank, keeping ranked items first and the relative order of unranked items
+func Sort[T any](items []T, id func(T) string, ranks map[string]string) {
+	sort.SliceStable(items, func(i, j int) bool {
+		ri, iOK := ranks[id(items[i])]
+		rj, jOK := ranks[id(items[j])]
+		if iOK && jOK {
+			return ri < rj
+		}
+		return iOK && !jOK
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package ordering_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/ordering"
+)
+
+func TestBetween(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		prev, next string
+	}{
+		{"empty column", "", ""},
+		{"at the start", "", "i"},
+		{"at the end", "i", ""},
+		{"wide gap", "1", "y"},
+		{"adjacent digits", "a", "b"},
+		{"adjacent after a prefix", "ha", "hb"},
+		{"prefix of next", "h", "h5"},
+		{"next is one digit longer", "h", "i1"},
+		{"before the smallest key", "", "01"},
+		{"after the largest key", "z", ""},
+		{"after a run of z", "zzz", ""},
+		{"before a long key", "", "00001"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ordering.Between(tc.prev, tc.next)
+			if got <= tc.prev || (tc.next != "" && got >= tc.next) {
+				t.Errorf("Between(%q, %q) = %q, not strictly between them", tc.prev, tc.next, got)
+			}
+			if strings.HasSuffix(got, "0") {
+				t.Errorf("Between(%q, %q) = %q, which ends in 0", tc.prev, tc.next, got)
+			}
+		})
+	}
+}
+
+func TestBetweenInconsistentNeighbours(t *testing.T) {
+	// When prev doesn't sort before next, the rank goes after prev
+	for _, tc := range [][2]string{{"m", "m"}, {"m", "c"}} {
+		if got := ordering.Between(tc[0], tc[1]); got <= tc[0] {
+			t.Errorf("Between(%q, %q) = %q, want it after %q", tc[0], tc[1], got, tc[0])
+		}
+	}
+}
+
+func TestBetweenRepeatedInsertion(t *testing.T) {
+	// Inserting again and again into the same gap keeps producing keys in order
+	prev, next := "a", "b"
+	for i := 0; i < 100; i++ {
+		rank := ordering.Between(prev, next)
+		if rank <= prev || rank >= next {
+			t.Fatalf("insertion %d: Between(%q, %q) = %q", i, prev, next, rank)
+		}
+		next = rank
+	}
+	prev, next = "a", "b"
+	for i := 0; i < 100; i++ {
+		rank := ordering.Between(prev, next)
+		if rank <= prev || rank >= next {
+			t.Fatalf("insertion %d: Between(%q, %q) = %q", i, prev, next, rank)
+		}
+		prev = rank
+	}
+}
+
+func TestPlace(t *testing.T) {
+	// Consistent neighbours: only the moved task is re-ranked
+	ranks := map[string]string{"a": "1", "b": "2", "c": "3"}
+	changes := ordering.Place([]string{"a", "c", "b"}, "c", ranks)
+	if len(changes) != 1 || changes["c"] <= "1" || changes["c"] >= "2" {
+		t.Errorf("Place = %v, want only c ranked between a and b", changes)
+	}
+
+	// A neighbour without a rank re-ranks the whole column in order
+	changes = ordering.Place([]string{"a", "b", "new"}, "b", map[string]string{"a": "1", "b": "5"})
+	if len(changes) != 3 || !(changes["a"] < changes["b"] && changes["b"] < changes["new"]) {
+		t.Errorf("Place = %v, want the column ranked in order", changes)
+	}
+
+	// So do neighbours whose ranks are out of order
+	changes = ordering.Place([]string{"b", "x", "a"}, "x", map[string]string{"a": "1", "b": "2", "x": "5"})
+	if len(changes) != 3 || !(changes["b"] < changes["x"] && changes["x"] < changes["a"]) {
+		t.Errorf("Place = %v, want the column ranked in order", changes)
+	}
+
+	// The only card in a column, and a task missing from the order
+	if changes := ordering.Place([]string{"a"}, "a", nil); len(changes) != 1 || changes["a"] == "" {
+		t.Errorf("Place alone = %v", changes)
+	}
+	if changes := ordering.Place([]string{"a"}, "b", nil); changes != nil {
+		t.Errorf("Place of a missing task = %v, want nil", changes)
+	}
+}
+
+func TestSort(t *testing.T) {
+	items := []string{"u1", "b", "u2", "a", "c"}
+	ordering.Sort(items, func(s string) string { return s }, map[string]string{"a": "1", "b": "2", "c": "3"})
+
+	// Ranked items come first in rank order, then unranked ones in their original order
+	if got := strings.Join(items, ","); got != "a,b,c,u1,u2" {
+		t.Errorf("Sort = %s, want a,b,c,u1,u2", got)
+	}
+}
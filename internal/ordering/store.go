@@ -0,0 +1,167 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package ordering
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store persists task ranks per list
+type Store interface {
+	// Ranks returns the rank of every ranked task in a list, keyed by task ID
+	Ranks(listID string) (map[string]string, error)
+	// SetRanks creates or replaces the ranks of the given tasks
+	SetRanks(listID string, ranks map[string]string) error
+	// DeleteRank forgets a task's rank
+	DeleteRank(listID string, taskID string) error
+	// DeleteList forgets the ranks of every task in a list
+	DeleteList(listID string) error
+}
+
+// MemoryStore is a Store that keeps ranks in process memory
+type MemoryStore struct {
+	lists map[string]map[string]string
+	mu    sync.RWMutex
+}
+
+// NewMemoryStore creates a new in-memory rank store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		lists: make(map[string]map[string]string),
+	}
+}
+
+// Ranks returns a copy of the ranks for a list
+func (s *MemoryStore) Ranks(listID string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ranks := make(map[string]string, len(s.lists[listID]))
+	for taskID, rank := range s.lists[listID] {
+		ranks[taskID] = rank
+	}
+	return ranks, nil
+}
+
+// SetRanks creates or replaces ranks for a list
+func (s *MemoryStore) SetRanks(listID string, ranks map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lists[listID] == nil {
+		s.lists[listID] = make(map[string]string)
+	}
+	for taskID, rank := range ranks {
+		s.lists[listID][taskID] = rank
+	}
+	return nil
+}
+
+// DeleteRank forgets a task's rank
+func (s *MemoryStore) DeleteRank(listID string, taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.lists[listID], taskID)
+	if len(s.lists[listID]) == 0 {
+		delete(s.lists, listID)
+	}
+	return nil
+}
+
+// DeleteList forgets a list's ranks
+func (s *MemoryStore) DeleteList(listID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.lists, listID)
+	return nil
+}
+
+// FileStore is a Store that keeps ranks in a JSON file so the order survives restarts
+type FileStore struct {
+	path   string
+	memory *MemoryStore
+	mu     sync.Mutex
+}
+
+// NewFileStore opens or creates a JSON rank file
+func NewFileStore(path string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating order directory: %w", err)
+	}
+
+	s := &FileStore{
+		path:   path,
+		memory: NewMemoryStore(),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading order file: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.memory.lists); err != nil {
+			return nil, fmt.Errorf("error parsing order file: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// Ranks returns the ranks for a list
+func (s *FileStore) Ranks(listID string) (map[string]string, error) {
+	return s.memory.Ranks(listID)
+}
+
+// SetRanks creates or replaces ranks for a list and saves the file
+func (s *FileStore) SetRanks(listID string, ranks map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.memory.SetRanks(listID, ranks)
+	return s.save()
+}
+
+// DeleteRank forgets a task's rank and saves the file
+func (s *FileStore) DeleteRank(listID string, taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.memory.DeleteRank(listID, taskID)
+	return s.save()
+}
+
+// DeleteList forgets a list's ranks and saves the file
+func (s *FileStore) DeleteList(listID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.memory.DeleteList(listID)
+	return s.save()
+}
+
+// save atomically replaces the rank file
+func (s *FileStore) save() error {
+	s.memory.mu.RLock()
+	data, err := json.Marshal(s.memory.lists)
+	s.memory.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("error encoding order file: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing order file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("error writing order file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package ordering_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/ordering"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order", "ranks.json")
+	store, err := ordering.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	if err := store.SetRanks("work", map[string]string{"a": "1", "b": "2"}); err != nil {
+		t.Fatalf("SetRanks failed: %v", err)
+	}
+	if err := store.SetRanks("work", map[string]string{"b": "3"}); err != nil {
+		t.Fatalf("SetRanks failed: %v", err)
+	}
+	if err := store.DeleteRank("work", "a"); err != nil {
+		t.Fatalf("DeleteRank failed: %v", err)
+	}
+
+	// The ranks survive reopening the file
+	reopened, err := ordering.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("reopening the store failed: %v", err)
+	}
+	ranks, err := reopened.Ranks("work")
+	if err != nil || len(ranks) != 1 || ranks["b"] != "3" {
+		t.Errorf("Ranks = %v, %v, want b ranked 3", ranks, err)
+	}
+
+	// The returned ranks are a copy
+	ranks["c"] = "9"
+	if ranks, _ := reopened.Ranks("work"); ranks["c"] != "" {
+		t.Errorf("changing the returned ranks changed the store")
+	}
+
+	// Lists without ranks have none
+	if ranks, err := reopened.Ranks("home"); err != nil || len(ranks) != 0 {
+		t.Errorf("Ranks of an unranked list = %v, %v", ranks, err)
+	}
+
+	// A deleted list's ranks leave the file
+	if err := reopened.SetRanks("home", map[string]string{"c": "1"}); err != nil {
+		t.Fatalf("SetRanks failed: %v", err)
+	}
+	if err := reopened.DeleteList("work"); err != nil {
+		t.Fatalf("DeleteList failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading the order file: %v", err)
+	}
+	if string(data) != `{"home":{"c":"1"}}` {
+		t.Errorf("order file = %s, want only the home list", data)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+// Package local provides an offline to-do backend stored in a JSON file,
+// so the board can run without a Microsoft account
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/service"
+	"github.com/coseguera/kanban-to-do/internal/service/memory"
+)
+
+// Service implements the backend used by the handlers
+var _ service.TodoService = (*Service)(nil)
+
+// Service is a service.TodoService that keeps lists and tasks in a JSON file.
+// Sign-in is immediate: the login redirects straight back with a local session.
+type Service struct {
+	*memory.Service
+	path string
+	mu   sync.Mutex
+}
+
+// NewService opens the data file at path, creating it with sample data if it doesn't exist
+func NewService(path string) (*Service, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating data directory: %w", err)
+	}
+
+	s := &Service{
+		Service: memory.NewService(),
+		path:    path,
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		if err := s.seed(); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("error reading data file: %w", err)
+	default:
+		var snap memory.Snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return nil, fmt.Errorf("error parsing data file: %w", err)
+		}
+		s.Restore(snap)
+	}
+
+	// Save after every change
+	s.OnChange(func() {
+		if err := s.save(); err != nil {
+			log.Printf("Error saving local data: %v", err)
+		}
+	})
+
+	return s, nil
+}
+
+// seed creates a sample list so a new board isn't empty
+func (s *Service) seed() error {
+	list := s.AddList("Getting Started")
+	samples := []models.Task{
+		{Title: "Drag me to Doing"},
+		{Title: "Click me to see details", Importance: "high"},
+		{Title: "Add a task with the + button", Categories: []string{"Doing"}},
+		{Title: "Sign in with Microsoft to use your real lists", Status: "completed"},
+	}
+	for _, task := range samples {
+		if _, err := s.AddTask(list.ID, task); err != nil {
+			return err
+		}
+	}
+	return s.save()
+}
+
+// save atomically writes all data to the file
+func (s *Service) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(s.Snapshot(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding local data: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing data file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("error writing data file: %w", err)
+	}
+	return nil
+}
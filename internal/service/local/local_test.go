@@ -0,0 +1,206 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package local_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/service/local"
+	"github.com/coseguera/kanban-to-do/internal/service/memory"
+)
+
+// open opens the data file at path, failing the test if it can't
+func open(t *testing.T, path string) *local.Service {
+	t.Helper()
+	svc, err := local.NewService(path)
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	return svc
+}
+
+func TestMissingFileIsSeeded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "todo.json")
+	svc := open(t, path)
+
+	// The sample list is created and saved straight away
+	lists, err := svc.GetTodoLists(context.Background(), memory.AccessToken)
+	if err != nil || len(lists.Value) != 1 {
+		t.Fatalf("GetTodoLists = %v, %v, want the sample list", lists, err)
+	}
+	tasks, err := svc.GetListTasks(context.Background(), memory.AccessToken, lists.Value[0].ID)
+	if err != nil || len(tasks.Value) == 0 {
+		t.Errorf("sample list has no tasks: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("data file wasn't created: %v", err)
+	}
+}
+
+func TestUnreadableFile(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+	}{
+		{"empty", ""},
+		{"corrupt", "{not json"},
+		{"truncated", `{"lists": [{"id": "list-1"`},
+		{"wrong shape", `{"lists": {"id": "list-1"}}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "todo.json")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("writing the data file: %v", err)
+			}
+
+			if _, err := local.NewService(path); err == nil {
+				t.Fatalf("NewService opened an unreadable file")
+			}
+
+			// The file is left for the user to fix rather than replaced with sample data
+			if data, _ := os.ReadFile(path); string(data) != tc.content {
+				t.Errorf("data file was changed to %q", data)
+			}
+		})
+	}
+}
+
+func TestChangesSurviveReopening(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "todo.json")
+	svc := open(t, path)
+
+	list, err := svc.CreateList(ctx, memory.AccessToken, "Work")
+	if err != nil {
+		t.Fatalf("CreateList failed: %v", err)
+	}
+	taskID, err := svc.CreateTask(ctx, memory.AccessToken, list.ID, "Write report")
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		change func() error
+	}{
+		{"details", func() error {
+			notes := "Quarterly numbers"
+			return svc.UpdateTaskDetails(ctx, memory.AccessToken, list.ID, taskID, "", models.TaskUpdate{
+				Title: "Write the report", Status: "notStarted", Importance: "high", DueDate: "2025-06-02", Categories: []string{"Doing"}, Body: &notes,
+			})
+		}},
+		{"checklist", func() error {
+			_, err := svc.CreateChecklistItem(ctx, memory.AccessToken, list.ID, taskID, "Gather numbers")
+			return err
+		}},
+		{"link", func() error {
+			_, err := svc.CreateLinkedResource(ctx, memory.AccessToken, list.ID, taskID, models.LinkedResource{WebURL: "https://example.com/report", DisplayName: "Draft"})
+			return err
+		}},
+		{"attachment", func() error {
+			_, err := svc.AddAttachment(ctx, memory.AccessToken, list.ID, taskID, "numbers.csv", "text/csv", []byte("q1,q2\n1,2\n"))
+			return err
+		}},
+		{"rename list", func() error {
+			return svc.RenameList(ctx, memory.AccessToken, list.ID, "Office")
+		}},
+	} {
+		if err := tc.change(); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+	}
+
+	// A new service on the same file sees every change
+	want := svc.Snapshot()
+	reopened := open(t, path)
+	if got := reopened.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened data = %+v, want %+v", got, want)
+	}
+	attachments, err := reopened.GetAttachments(ctx, memory.AccessToken, list.ID, taskID)
+	if err != nil || len(attachments) != 1 {
+		t.Fatalf("GetAttachments = %v, %v", attachments, err)
+	}
+	attachment, err := reopened.GetAttachment(ctx, memory.AccessToken, list.ID, taskID, attachments[0].ID)
+	if err != nil || string(attachment.ContentBytes) != "q1,q2\n1,2\n" {
+		t.Errorf("attachment content = %q, %v", attachment.ContentBytes, err)
+	}
+
+	// Deletes are saved too
+	if err := reopened.DeleteList(ctx, memory.AccessToken, list.ID); err != nil {
+		t.Fatalf("DeleteList failed: %v", err)
+	}
+	if _, err := open(t, path).GetListDetails(ctx, memory.AccessToken, list.ID); err == nil {
+		t.Errorf("deleted list is back after reopening")
+	}
+}
+
+func TestETagsChangeAcrossReopening(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "todo.json")
+	svc := open(t, path)
+	list, _ := svc.CreateList(ctx, memory.AccessToken, "Work")
+	taskID, _ := svc.CreateTask(ctx, memory.AccessToken, list.ID, "Write report")
+
+	etag := func(svc *local.Service) string {
+		task, err := svc.GetTaskDetails(ctx, memory.AccessToken, list.ID, taskID)
+		if err != nil {
+			t.Fatalf("GetTaskDetails failed: %v", err)
+		}
+		return task.ETag
+	}
+	seen := map[string]string{etag(svc): "created"}
+
+	for _, tc := range []struct {
+		name     string
+		reopen   bool
+		change   func(svc *local.Service, etag string) error
+		wantSame bool
+	}{
+		{"importance", false, func(svc *local.Service, etag string) error {
+			return svc.UpdateTaskImportance(ctx, memory.AccessToken, list.ID, taskID, etag, "high")
+		}, false},
+		{"reopened", true, nil, true},
+		{"status after reopening", false, func(svc *local.Service, etag string) error {
+			return svc.UpdateTaskStatus(ctx, memory.AccessToken, list.ID, taskID, etag, "notStarted", []string{"Doing"})
+		}, false},
+		{"checklist item", false, func(svc *local.Service, etag string) error {
+			_, err := svc.CreateChecklistItem(ctx, memory.AccessToken, list.ID, taskID, "Gather numbers")
+			return err
+		}, false},
+		{"reopened again", true, nil, true},
+		{"details after reopening", false, func(svc *local.Service, etag string) error {
+			return svc.UpdateTaskDetails(ctx, memory.AccessToken, list.ID, taskID, etag, models.TaskUpdate{Title: "Write the report", Importance: "normal"})
+		}, false},
+	} {
+		before := etag(svc)
+		if tc.reopen {
+			svc = open(t, path)
+		} else if err := tc.change(svc, before); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		after := etag(svc)
+		if tc.wantSame {
+			if after != before {
+				t.Errorf("%s: etag changed from %s to %s", tc.name, before, after)
+			}
+			continue
+		}
+		if previous, ok := seen[after]; ok {
+			t.Errorf("%s: etag %s was already used after %s", tc.name, after, previous)
+		}
+		seen[after] = tc.name
+
+		// The old version can no longer be changed
+		if err := svc.UpdateTaskImportance(ctx, memory.AccessToken, list.ID, taskID, before, "low"); err == nil {
+			t.Errorf("%s: an update with the previous etag was applied", tc.name)
+		}
+	}
+}
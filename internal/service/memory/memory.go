@@ -0,0 +1,773 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+// Package memory provides an in-memory to-do backend for tests and development
+package memory
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/service"
+)
+
+// Service implements the backend used by the handlers
+var _ service.TodoService = (*Service)(nil)
+
+// AccessToken is the access token issued by the in-memory sign-in
+const AccessToken = "memory-access-token"
+
+// Service is an in-memory service.TodoService. All users share the same lists,
+// and any non-empty access token is accepted.
+type Service struct {
+	mu       sync.RWMutex
+	lists    []models.TodoList
+	tasks    map[string][]models.Task           // list ID -> tasks in creation order
+	links    map[string][]models.LinkedResource // task ID -> linked resources
+	files    map[string][]models.Attachment     // task ID -> attachments with their content
+	nextID   int
+	onChange func()
+}
+
+// Snapshot is the serializable state of a Service
+type Snapshot struct {
+	Lists  []models.TodoList        `json:"lists"`
+	Tasks  map[string][]models.Task `json:"tasks"`
+	NextID int                      `json:"nextId"`
+
+	LinkedResources map[string][]models.LinkedResource `json:"linkedResources,omitempty"`
+	Attachments     map[string][]models.Attachment     `json:"attachments,omitempty"`
+}
+
+// NewService creates an empty in-memory service
+func NewService() *Service {
+	return &Service{
+		tasks: make(map[string][]models.Task),
+		links: make(map[string][]models.LinkedResource),
+		files: make(map[string][]models.Attachment),
+	}
+}
+
+// OnChange registers a function called after every change to the data
+func (s *Service) OnChange(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onChange = fn
+}
+
+// Snapshot returns a copy of the service's data
+func (s *Service) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := Snapshot{
+		Lists:  append([]models.TodoList(nil), s.lists...),
+		Tasks:  make(map[string][]models.Task, len(s.tasks)),
+		NextID: s.nextID,
+
+		LinkedResources: make(map[string][]models.LinkedResource, len(s.links)),
+		Attachments:     make(map[string][]models.Attachment, len(s.files)),
+	}
+	for listID, tasks := range s.tasks {
+		copied := make([]models.Task, len(tasks))
+		for i, task := range tasks {
+			copied[i] = copyTask(task)
+		}
+		snap.Tasks[listID] = copied
+	}
+	for taskID, links := range s.links {
+		snap.LinkedResources[taskID] = append([]models.LinkedResource(nil), links...)
+	}
+	for taskID, files := range s.files {
+		snap.Attachments[taskID] = append([]models.Attachment(nil), files...)
+	}
+	return snap
+}
+
+// Restore replaces the service's data with a snapshot
+func (s *Service) Restore(snap Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lists = append([]models.TodoList(nil), snap.Lists...)
+	s.tasks = make(map[string][]models.Task, len(snap.Lists))
+	for _, list := range s.lists {
+		tasks := make([]models.Task, len(snap.Tasks[list.ID]))
+		for i, task := range snap.Tasks[list.ID] {
+			tasks[i] = copyTask(task)
+		}
+		s.tasks[list.ID] = tasks
+	}
+	s.links = make(map[string][]models.LinkedResource, len(snap.LinkedResources))
+	for taskID, links := range snap.LinkedResources {
+		s.links[taskID] = append([]models.LinkedResource(nil), links...)
+	}
+	s.files = make(map[string][]models.Attachment, len(snap.Attachments))
+	for taskID, files := range snap.Attachments {
+		s.files[taskID] = append([]models.Attachment(nil), files...)
+	}
+	s.nextID = snap.NextID
+}
+
+// changed notifies the OnChange function; callers must not hold the lock
+func (s *Service) changed() {
+	s.mu.RLock()
+	fn := s.onChange
+	s.mu.RUnlock()
+
+	if fn != nil {
+		fn()
+	}
+}
+
+// AddList adds a list and returns it; useful for seeding test data
+func (s *Service) AddList(displayName string) models.TodoList {
+	s.mu.Lock()
+	list := s.addList(displayName)
+	s.mu.Unlock()
+
+	s.changed()
+	return list
+}
+
+// addList adds a list owned by the user; callers must hold the lock
+func (s *Service) addList(displayName string) models.TodoList {
+	list := models.TodoList{ID: s.newID("list"), DisplayName: displayName, IsOwner: true, WellknownListName: models.WellknownListNone}
+	s.lists = append(s.lists, list)
+	s.tasks[list.ID] = []models.Task{}
+	return list
+}
+
+// AddTask adds a copy of a task to a list and returns it with its ID and creation time set
+func (s *Service) AddTask(listID string, task models.Task) (models.Task, error) {
+	s.mu.Lock()
+	if _, ok := s.tasks[listID]; !ok {
+		s.mu.Unlock()
+		return models.Task{}, service.NotFound("list %s not found", listID)
+	}
+
+	task = copyTask(task)
+	task.ID = s.newID("task")
+	task.ETag = s.newETag()
+	task.CreatedDateTime = time.Now().UTC().Format(time.RFC3339)
+	if task.Status == "" {
+		task.Status = "notStarted"
+	}
+	if task.Importance == "" {
+		task.Importance = "normal"
+	}
+	setCompletedDateTime(&task)
+	s.tasks[listID] = append(s.tasks[listID], task)
+	s.mu.Unlock()
+
+	s.changed()
+	return task, nil
+}
+
+// GetAuthURL returns a URL that signs in immediately by sending the browser straight to the callback
+func (s *Service) GetAuthURL(state string, codeChallenge string) string {
+	return "/auth/callback?code=memory&state=" + url.QueryEscape(state)
+}
+
+// ExchangeCodeForToken issues the in-memory access token for any code
+func (s *Service) ExchangeCodeForToken(ctx context.Context, code string, codeVerifier string) (*models.TokenResponse, error) {
+	return s.token(), nil
+}
+
+// RefreshToken issues the in-memory access token for any refresh token
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
+	return s.token(), nil
+}
+
+// GetTodoLists gets all lists
+func (s *Service) GetTodoLists(ctx context.Context, accessToken string) (*models.TodoListResponse, error) {
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lists := make([]models.TodoList, len(s.lists))
+	copy(lists, s.lists)
+	return &models.TodoListResponse{Value: lists}, nil
+}
+
+// GetListDetails gets a single list
+func (s *Service) GetListDetails(ctx context.Context, accessToken string, listID string) (*models.TodoList, error) {
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, list := range s.lists {
+		if list.ID == listID {
+			return &list, nil
+		}
+	}
+	return nil, service.NotFound("list %s not found", listID)
+}
+
+// CreateList creates a list
+func (s *Service) CreateList(ctx context.Context, accessToken string, displayName string) (*models.TodoList, error) {
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	list := s.addList(displayName)
+	s.mu.Unlock()
+
+	s.changed()
+	return &list, nil
+}
+
+// RenameList changes a list's name
+func (s *Service) RenameList(ctx context.Context, accessToken string, listID string, displayName string) error {
+	if err := checkToken(accessToken); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	i, err := s.editableList(listID)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.lists[i].DisplayName = displayName
+	s.mu.Unlock()
+
+	s.changed()
+	return nil
+}
+
+// DeleteList deletes a list with its tasks
+func (s *Service) DeleteList(ctx context.Context, accessToken string, listID string) error {
+	if err := checkToken(accessToken); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	i, err := s.editableList(listID)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.lists = append(s.lists[:i], s.lists[i+1:]...)
+	for _, task := range s.tasks[listID] {
+		delete(s.links, task.ID)
+		delete(s.files, task.ID)
+	}
+	delete(s.tasks, listID)
+	s.mu.Unlock()
+
+	s.changed()
+	return nil
+}
+
+// editableList returns the index of a list that can be renamed or deleted; callers must hold the lock
+func (s *Service) editableList(listID string) (int, error) {
+	for i, list := range s.lists {
+		if list.ID != listID {
+			continue
+		}
+		if list.IsWellknown() {
+			return -1, service.BadRequest("list %s is a built-in list and can't be changed", listID)
+		}
+		return i, nil
+	}
+	return -1, service.NotFound("list %s not found", listID)
+}
+
+// GetListTasks gets all tasks in a list
+func (s *Service) GetListTasks(ctx context.Context, accessToken string, listID string) (*models.TaskResponse, error) {
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks, ok := s.tasks[listID]
+	if !ok {
+		return nil, service.NotFound("list %s not found", listID)
+	}
+
+	result := make([]models.Task, len(tasks))
+	for i, task := range tasks {
+		result[i] = copyTask(task)
+	}
+	return &models.TaskResponse{Value: result}, nil
+}
+
+// GetTaskDetails gets a single task
+func (s *Service) GetTaskDetails(ctx context.Context, accessToken string, listID string, taskID string) (*models.Task, error) {
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, err := s.find(listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	result := copyTask(*task)
+	return &result, nil
+}
+
+// CreateTask creates a task with the given title
+func (s *Service) CreateTask(ctx context.Context, accessToken string, listID string, title string) (string, error) {
+	if err := checkToken(accessToken); err != nil {
+		return "", err
+	}
+
+	task, err := s.AddTask(listID, models.Task{Title: title})
+	if err != nil {
+		return "", err
+	}
+	return task.ID, nil
+}
+
+// CopyTask creates a task with the fields and checklist of another task
+func (s *Service) CopyTask(ctx context.Context, accessToken string, listID string, task models.Task) (*models.Task, error) {
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	copied := copyTask(task)
+	copied.ETag = ""
+	copied.HasAttachments = false
+	now := time.Now().UTC().Format(time.RFC3339)
+	s.mu.Lock()
+	for i := range copied.ChecklistItems {
+		copied.ChecklistItems[i].ID = s.newID("item")
+		copied.ChecklistItems[i].CreatedDateTime = now
+	}
+	s.mu.Unlock()
+
+	created, err := s.AddTask(listID, copied)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateTaskStatus sets a task's status and categories
+func (s *Service) UpdateTaskStatus(ctx context.Context, accessToken string, listID string, taskID string, etag string, status string, categories []string) error {
+	return s.update(accessToken, listID, taskID, etag, func(task *models.Task) error {
+		task.Status = status
+		task.Categories = append([]string(nil), categories...)
+		return nil
+	})
+}
+
+// UpdateTaskImportance sets a task's importance
+func (s *Service) UpdateTaskImportance(ctx context.Context, accessToken string, listID string, taskID string, etag string, importance string) error {
+	return s.update(accessToken, listID, taskID, etag, func(task *models.Task) error {
+		task.Importance = importance
+		return nil
+	})
+}
+
+// UpdateTaskDetails sets a task's editable fields the same way Microsoft Graph does
+func (s *Service) UpdateTaskDetails(ctx context.Context, accessToken string, listID string, taskID string, etag string, update models.TaskUpdate) error {
+	return s.update(accessToken, listID, taskID, etag, func(task *models.Task) error {
+		recurrence := task.Recurrence
+		if update.Recurrence != nil {
+			recurrence = nil
+			if update.Recurrence.Pattern.Type != "" {
+				recurrence = copyRecurrence(update.Recurrence)
+			}
+		}
+		if recurrence != nil && update.DueDate == "" {
+			return service.BadRequest("a recurring task must have a due date")
+		}
+
+		task.Title = update.Title
+		task.Importance = update.Importance
+		task.Status = update.Status
+		if task.Status == "" {
+			task.Status = "notStarted"
+		}
+		task.DueDateTime = models.NewDate(update.DueDate)
+		task.StartDateTime = models.NewDate(update.StartDate)
+		task.IsReminderOn = update.ReminderOn
+		if update.ReminderOn {
+			task.ReminderDateTime = models.NewDateTime(update.Reminder)
+		}
+		if update.Body != nil {
+			task.Body = &models.ItemBody{Content: *update.Body, ContentType: "text"}
+		}
+		task.Categories = append([]string(nil), update.Categories...)
+		task.Recurrence = recurrence
+		return nil
+	})
+}
+
+// DeleteTask deletes a task; if etag is set, only while the task is still at that version
+func (s *Service) DeleteTask(ctx context.Context, accessToken string, listID string, taskID string, etag string) error {
+	if err := checkToken(accessToken); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	tasks := s.tasks[listID]
+	for i := range tasks {
+		if tasks[i].ID == taskID {
+			if etag != "" && etag != tasks[i].ETag {
+				s.mu.Unlock()
+				return service.PreconditionFailed("task %s has changed", taskID)
+			}
+			s.tasks[listID] = append(tasks[:i], tasks[i+1:]...)
+			delete(s.links, taskID)
+			delete(s.files, taskID)
+			s.mu.Unlock()
+
+			s.changed()
+			return nil
+		}
+	}
+	s.mu.Unlock()
+
+	return service.NotFound("task %s not found", taskID)
+}
+
+// GetChecklistItems gets a task's checklist items
+func (s *Service) GetChecklistItems(ctx context.Context, accessToken string, listID string, taskID string) ([]models.ChecklistItem, error) {
+	task, err := s.GetTaskDetails(ctx, accessToken, listID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return task.ChecklistItems, nil
+}
+
+// CreateChecklistItem adds an unchecked item to a task's checklist
+func (s *Service) CreateChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, displayName string) (*models.ChecklistItem, error) {
+	var item models.ChecklistItem
+	err := s.update(accessToken, listID, taskID, "", func(task *models.Task) error {
+		item = models.ChecklistItem{
+			ID:              s.newID("item"),
+			DisplayName:     displayName,
+			CreatedDateTime: time.Now().UTC().Format(time.RFC3339),
+		}
+		task.ChecklistItems = append(task.ChecklistItems, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// UpdateChecklistItem renames a checklist item
+func (s *Service) UpdateChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, itemID string, displayName string) error {
+	return s.updateChecklistItem(accessToken, listID, taskID, itemID, func(item *models.ChecklistItem) {
+		item.DisplayName = displayName
+	})
+}
+
+// SetChecklistItemChecked checks or unchecks a checklist item
+func (s *Service) SetChecklistItemChecked(ctx context.Context, accessToken string, listID string, taskID string, itemID string, checked bool) error {
+	return s.updateChecklistItem(accessToken, listID, taskID, itemID, func(item *models.ChecklistItem) {
+		item.IsChecked = checked
+		item.CheckedDateTime = ""
+		if checked {
+			item.CheckedDateTime = time.Now().UTC().Format(time.RFC3339)
+		}
+	})
+}
+
+// DeleteChecklistItem deletes a checklist item
+func (s *Service) DeleteChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, itemID string) error {
+	return s.update(accessToken, listID, taskID, "", func(task *models.Task) error {
+		for i := range task.ChecklistItems {
+			if task.ChecklistItems[i].ID == itemID {
+				task.ChecklistItems = append(task.ChecklistItems[:i], task.ChecklistItems[i+1:]...)
+				return nil
+			}
+		}
+		return service.NotFound("checklist item %s not found", itemID)
+	})
+}
+
+// GetLinkedResources gets a task's linked resources
+func (s *Service) GetLinkedResources(ctx context.Context, accessToken string, listID string, taskID string) ([]models.LinkedResource, error) {
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.find(listID, taskID); err != nil {
+		return nil, err
+	}
+	return append([]models.LinkedResource{}, s.links[taskID]...), nil
+}
+
+// CreateLinkedResource links a task to an item in another app
+func (s *Service) CreateLinkedResource(ctx context.Context, accessToken string, listID string, taskID string, resource models.LinkedResource) (*models.LinkedResource, error) {
+	err := s.update(accessToken, listID, taskID, "", func(task *models.Task) error {
+		resource.ID = s.newID("link")
+		s.links[taskID] = append(s.links[taskID], resource)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// DeleteLinkedResource deletes a linked resource
+func (s *Service) DeleteLinkedResource(ctx context.Context, accessToken string, listID string, taskID string, resourceID string) error {
+	return s.update(accessToken, listID, taskID, "", func(task *models.Task) error {
+		links := s.links[taskID]
+		for i := range links {
+			if links[i].ID == resourceID {
+				s.links[taskID] = append(links[:i:i], links[i+1:]...)
+				return nil
+			}
+		}
+		return service.NotFound("linked resource %s not found", resourceID)
+	})
+}
+
+// GetAttachments gets a task's attachments without their content
+func (s *Service) GetAttachments(ctx context.Context, accessToken string, listID string, taskID string) ([]models.Attachment, error) {
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.find(listID, taskID); err != nil {
+		return nil, err
+	}
+	attachments := []models.Attachment{}
+	for _, attachment := range s.files[taskID] {
+		attachment.ContentBytes = nil
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+// GetAttachment gets an attachment with its content
+func (s *Service) GetAttachment(ctx context.Context, accessToken string, listID string, taskID string, attachmentID string) (*models.Attachment, error) {
+	if err := checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.find(listID, taskID); err != nil {
+		return nil, err
+	}
+	for _, attachment := range s.files[taskID] {
+		if attachment.ID == attachmentID {
+			attachment.ContentBytes = append([]byte(nil), attachment.ContentBytes...)
+			return &attachment, nil
+		}
+	}
+	return nil, service.NotFound("attachment %s not found", attachmentID)
+}
+
+// AddAttachment attaches a file to a task
+func (s *Service) AddAttachment(ctx context.Context, accessToken string, listID string, taskID string, name string, contentType string, content []byte) (*models.Attachment, error) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	var attachment models.Attachment
+	err := s.update(accessToken, listID, taskID, "", func(task *models.Task) error {
+		attachment = models.Attachment{
+			ID:                   s.newID("attachment"),
+			Name:                 name,
+			ContentType:          contentType,
+			Size:                 int64(len(content)),
+			LastModifiedDateTime: time.Now().UTC().Format(time.RFC3339),
+		}
+		stored := attachment
+		stored.ContentBytes = append([]byte(nil), content...)
+		s.files[taskID] = append(s.files[taskID], stored)
+		task.HasAttachments = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// DeleteAttachment deletes an attachment
+func (s *Service) DeleteAttachment(ctx context.Context, accessToken string, listID string, taskID string, attachmentID string) error {
+	return s.update(accessToken, listID, taskID, "", func(task *models.Task) error {
+		files := s.files[taskID]
+		for i := range files {
+			if files[i].ID == attachmentID {
+				s.files[taskID] = append(files[:i:i], files[i+1:]...)
+				task.HasAttachments = len(s.files[taskID]) > 0
+				return nil
+			}
+		}
+		return service.NotFound("attachment %s not found", attachmentID)
+	})
+}
+
+// updateChecklistItem applies fn to one of a task's checklist items under the write lock
+func (s *Service) updateChecklistItem(accessToken string, listID string, taskID string, itemID string, fn func(item *models.ChecklistItem)) error {
+	return s.update(accessToken, listID, taskID, "", func(task *models.Task) error {
+		for i := range task.ChecklistItems {
+			if task.ChecklistItems[i].ID == itemID {
+				fn(&task.ChecklistItems[i])
+				return nil
+			}
+		}
+		return service.NotFound("checklist item %s not found", itemID)
+	})
+}
+
+// update applies fn to a task under the write lock and gives it a new etag.
+// If etag is set and the task has changed since, nothing is applied and a 412 Error is returned.
+// If fn returns an error, it must leave the task unchanged.
+func (s *Service) update(accessToken string, listID string, taskID string, etag string, fn func(task *models.Task) error) error {
+	if err := checkToken(accessToken); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	task, err := s.find(listID, taskID)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if etag != "" && etag != task.ETag {
+		s.mu.Unlock()
+		return service.PreconditionFailed("task %s has changed", taskID)
+	}
+	wasCompleted := task.Status == "completed"
+	if err := fn(task); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	setCompletedDateTime(task)
+
+	// Completing a recurring task creates its next occurrence, which takes over the recurrence
+	next, recurs := models.NextOccurrence(*task)
+	recurs = recurs && !wasCompleted && task.Status == "completed"
+	if recurs {
+		task.Recurrence = nil
+	}
+	task.ETag = s.newETag()
+	if recurs {
+		s.addOccurrence(listID, next)
+	}
+	s.mu.Unlock()
+
+	s.changed()
+	return nil
+}
+
+// addOccurrence stores the next occurrence of a recurring task; callers must hold the lock
+func (s *Service) addOccurrence(listID string, task models.Task) {
+	task.ID = s.newID("task")
+	task.ETag = s.newETag()
+	task.CreatedDateTime = time.Now().UTC().Format(time.RFC3339)
+	for i := range task.ChecklistItems {
+		task.ChecklistItems[i].ID = s.newID("item")
+		task.ChecklistItems[i].CreatedDateTime = task.CreatedDateTime
+	}
+	s.tasks[listID] = append(s.tasks[listID], task)
+}
+
+// find returns a pointer to a stored task; callers must hold the lock
+func (s *Service) find(listID string, taskID string) (*models.Task, error) {
+	tasks, ok := s.tasks[listID]
+	if !ok {
+		return nil, service.NotFound("list %s not found", listID)
+	}
+	for i := range tasks {
+		if tasks[i].ID == taskID {
+			return &tasks[i], nil
+		}
+	}
+	return nil, service.NotFound("task %s not found", taskID)
+}
+
+// newID returns a unique ID; callers must hold the lock
+func (s *Service) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s-%d", prefix, s.nextID)
+}
+
+// newETag returns a task version that is never reused, since the counter it
+// shares with newID is saved in snapshots; callers must hold the lock
+func (s *Service) newETag() string {
+	s.nextID++
+	return fmt.Sprintf(`W/"%d"`, s.nextID)
+}
+
+// token returns a token response for the in-memory sign-in
+func (s *Service) token() *models.TokenResponse {
+	return &models.TokenResponse{
+		AccessToken:  AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    3600,
+		RefreshToken: "memory-refresh-token",
+	}
+}
+
+// checkToken rejects calls made without an access token
+func checkToken(accessToken string) error {
+	if accessToken == "" {
+		return service.Unauthorized("missing access token")
+	}
+	return nil
+}
+
+// setCompletedDateTime records when a task was completed, and clears it if the task was reopened, as Microsoft Graph does
+func setCompletedDateTime(task *models.Task) {
+	if task.Status != "completed" {
+		task.CompletedDateTime = nil
+	} else if task.CompletedDateTime == nil {
+		task.CompletedDateTime = models.NewDateTime(time.Now())
+	}
+}
+
+// copyTask returns a copy of a task that shares no slices or pointers with the original
+func copyTask(task models.Task) models.Task {
+	task.Categories = append([]string(nil), task.Categories...)
+	task.ChecklistItems = append([]models.ChecklistItem(nil), task.ChecklistItems...)
+	for _, dt := range []**models.DateTime{&task.DueDateTime, &task.StartDateTime, &task.CompletedDateTime, &task.ReminderDateTime} {
+		if *dt != nil {
+			copied := **dt
+			*dt = &copied
+		}
+	}
+	if task.Body != nil {
+		body := *task.Body
+		task.Body = &body
+	}
+	if task.Recurrence != nil {
+		task.Recurrence = copyRecurrence(task.Recurrence)
+	}
+	return task
+}
+
+// copyRecurrence returns a copy of a recurrence that shares no slices with the original
+func copyRecurrence(recurrence *models.PatternedRecurrence) *models.PatternedRecurrence {
+	copied := *recurrence
+	copied.Pattern.DaysOfWeek = append([]string(nil), recurrence.Pattern.DaysOfWeek...)
+	return &copied
+}
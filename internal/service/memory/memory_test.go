@@ -0,0 +1,171 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/service"
+	"github.com/coseguera/kanban-to-do/internal/service/memory"
+)
+
+// status returns the HTTP status of a backend error, or 0 if it has none
+func status(err error) int {
+	var statusErr service.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.HTTPStatus()
+	}
+	return 0
+}
+
+// getTask reads a task back, failing the test if it can't
+func getTask(t *testing.T, svc *memory.Service, listID string, taskID string) models.Task {
+	t.Helper()
+	task, err := svc.GetTaskDetails(context.Background(), memory.AccessToken, listID, taskID)
+	if err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+	return *task
+}
+
+func TestStaleETag(t *testing.T) {
+	ctx := context.Background()
+	svc := memory.NewService()
+	list := svc.AddList("Work")
+	task, err := svc.AddTask(list.ID, models.Task{Title: "Write report"})
+	if err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	// Every change gives the task a new etag
+	if err := svc.UpdateTaskImportance(ctx, memory.AccessToken, list.ID, task.ID, task.ETag, "high"); err != nil {
+		t.Fatalf("update with the current etag failed: %v", err)
+	}
+	current := getTask(t, svc, list.ID, task.ID)
+	if current.ETag == task.ETag {
+		t.Fatalf("the etag didn't change")
+	}
+
+	// Changes made with the old etag are refused and leave the task as it was
+	for name, err := range map[string]error{
+		"importance": svc.UpdateTaskImportance(ctx, memory.AccessToken, list.ID, task.ID, task.ETag, "low"),
+		"status":     svc.UpdateTaskStatus(ctx, memory.AccessToken, list.ID, task.ID, task.ETag, "completed", nil),
+		"details":    svc.UpdateTaskDetails(ctx, memory.AccessToken, list.ID, task.ID, task.ETag, models.TaskUpdate{Title: "Stale"}),
+		"delete":     svc.DeleteTask(ctx, memory.AccessToken, list.ID, task.ID, task.ETag),
+	} {
+		if status(err) != http.StatusPreconditionFailed {
+			t.Errorf("%s with a stale etag: err = %v, want a 412", name, err)
+		}
+	}
+	if got := getTask(t, svc, list.ID, task.ID); !reflect.DeepEqual(got, current) {
+		t.Errorf("task changed by refused updates: %+v, want %+v", got, current)
+	}
+
+	// Without an etag the change always applies
+	if err := svc.DeleteTask(ctx, memory.AccessToken, list.ID, task.ID, ""); err != nil {
+		t.Errorf("DeleteTask without an etag failed: %v", err)
+	}
+}
+
+func TestFailedUpdateLeavesTaskUnchanged(t *testing.T) {
+	ctx := context.Background()
+	svc := memory.NewService()
+	list := svc.AddList("Work")
+	task, err := svc.AddTask(list.ID, models.Task{Title: "Water plants", Categories: []string{"Home"}})
+	if err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	var changes int
+	svc.OnChange(func() { changes++ })
+
+	// A recurring task needs a due date
+	err = svc.UpdateTaskDetails(ctx, memory.AccessToken, list.ID, task.ID, task.ETag, models.TaskUpdate{
+		Title:      "Water plants weekly",
+		Status:     "completed",
+		Recurrence: &models.PatternedRecurrence{Pattern: models.RecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"monday"}}},
+	})
+	if status(err) != http.StatusBadRequest {
+		t.Errorf("recurrence without a due date: err = %v, want a 400", err)
+	}
+
+	// A checklist item that isn't there
+	if err := svc.DeleteChecklistItem(ctx, memory.AccessToken, list.ID, task.ID, "missing"); status(err) != http.StatusNotFound {
+		t.Errorf("deleting a missing checklist item: err = %v, want a 404", err)
+	}
+
+	if got := getTask(t, svc, list.ID, task.ID); !reflect.DeepEqual(got, task) {
+		t.Errorf("task changed by failed updates: %+v, want %+v", got, task)
+	}
+	if changes != 0 {
+		t.Errorf("failed updates reported %d changes", changes)
+	}
+
+	// The unchanged etag still works
+	if err := svc.UpdateTaskImportance(ctx, memory.AccessToken, list.ID, task.ID, task.ETag, "high"); err != nil {
+		t.Errorf("update after the failed ones: %v", err)
+	}
+}
+
+func TestTasksAreCopied(t *testing.T) {
+	ctx := context.Background()
+	svc := memory.NewService()
+	list := svc.AddList("Work")
+
+	added := models.Task{
+		Title:          "Plan trip",
+		Categories:     []string{"Travel"},
+		Body:           &models.ItemBody{Content: "Book flights", ContentType: "text"},
+		DueDateTime:    models.NewDate("2025-06-01"),
+		ChecklistItems: []models.ChecklistItem{{ID: "item", DisplayName: "Passport"}},
+		Recurrence:     &models.PatternedRecurrence{Pattern: models.RecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"monday"}}},
+	}
+	task, err := svc.AddTask(list.ID, added)
+	if err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	want := getTask(t, svc, list.ID, task.ID)
+
+	// mutate changes everything a task shares through slices and pointers
+	mutate := func(task *models.Task) {
+		task.Categories[0] = "Changed"
+		task.Body.Content = "Changed"
+		task.DueDateTime.DateTime = "2000-01-01T00:00:00.0000000"
+		task.ChecklistItems[0].DisplayName = "Changed"
+		task.Recurrence.Pattern.DaysOfWeek[0] = "friday"
+	}
+
+	// Neither the task that was added nor the tasks read back share anything with the stored one
+	mutate(&added)
+	read := getTask(t, svc, list.ID, task.ID)
+	mutate(&read)
+	listed, err := svc.GetListTasks(ctx, memory.AccessToken, list.ID)
+	if err != nil {
+		t.Fatalf("GetListTasks failed: %v", err)
+	}
+	mutate(&listed.Value[0])
+	if got := getTask(t, svc, list.ID, task.ID); !reflect.DeepEqual(got, want) {
+		t.Errorf("stored task was changed through a copy: %+v, want %+v", got, want)
+	}
+
+	// Snapshots and restored services have their own copies too
+	snap := svc.Snapshot()
+	mutate(&snap.Tasks[list.ID][0])
+	if got := getTask(t, svc, list.ID, task.ID); !reflect.DeepEqual(got, want) {
+		t.Errorf("stored task was changed through a snapshot: %+v, want %+v", got, want)
+	}
+	snap = svc.Snapshot()
+	restored := memory.NewService()
+	restored.Restore(snap)
+	mutate(&snap.Tasks[list.ID][0])
+	if got := getTask(t, restored, list.ID, task.ID); !reflect.DeepEqual(got, want) {
+		t.Errorf("restored task was changed through its snapshot: %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+// Package service defines the to-do backend the HTTP handlers run against
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// TodoService is a to-do backend: sign-in plus list and task operations.
+// microsoft.Client is the Microsoft Graph implementation.
+type TodoService interface {
+	// GetAuthURL returns the URL that starts sign-in for the given state and S256 PKCE code challenge
+	GetAuthURL(state string, codeChallenge string) string
+	// ExchangeCodeForToken exchanges an authorization code and its PKCE code verifier for tokens
+	ExchangeCodeForToken(ctx context.Context, code string, codeVerifier string) (*models.TokenResponse, error)
+	// RefreshToken exchanges a refresh token for new tokens
+	RefreshToken(ctx context.Context, refreshToken string) (*models.TokenResponse, error)
+
+	// GetTodoLists gets all of the user's lists
+	GetTodoLists(ctx context.Context, accessToken string) (*models.TodoListResponse, error)
+	// GetListDetails gets a single list
+	GetListDetails(ctx context.Context, accessToken string, listID string) (*models.TodoList, error)
+	// CreateList creates a list owned by the user and returns it
+	CreateList(ctx context.Context, accessToken string, displayName string) (*models.TodoList, error)
+	// RenameList changes a list's name; built-in lists can't be renamed
+	RenameList(ctx context.Context, accessToken string, listID string, displayName string) error
+	// DeleteList deletes a list and its tasks; built-in lists can't be deleted
+	DeleteList(ctx context.Context, accessToken string, listID string) error
+
+	// GetListTasks gets all of the tasks in a list
+	GetListTasks(ctx context.Context, accessToken string, listID string) (*models.TaskResponse, error)
+	// GetTaskDetails gets a single task
+	GetTaskDetails(ctx context.Context, accessToken string, listID string, taskID string) (*models.Task, error)
+	// CreateTask creates a task and returns its ID
+	CreateTask(ctx context.Context, accessToken string, listID string, title string) (string, error)
+	// CopyTask creates a task in a list with the fields and checklist of task, which may come from
+	// another list, and returns the new task. Links and attachments are not copied.
+	CopyTask(ctx context.Context, accessToken string, listID string, task models.Task) (*models.Task, error)
+	// UpdateTaskStatus sets a task's status and categories. If etag is set, the update
+	// only succeeds if the task is still at that version and fails with a 412 StatusError otherwise.
+	UpdateTaskStatus(ctx context.Context, accessToken string, listID string, taskID string, etag string, status string, categories []string) error
+	// UpdateTaskImportance sets a task's importance; etag works as in UpdateTaskStatus
+	UpdateTaskImportance(ctx context.Context, accessToken string, listID string, taskID string, etag string, importance string) error
+	// UpdateTaskDetails sets the fields edited in the details modal; etag works as in UpdateTaskStatus
+	UpdateTaskDetails(ctx context.Context, accessToken string, listID string, taskID string, etag string, update models.TaskUpdate) error
+	// DeleteTask deletes a task; etag works as in UpdateTaskStatus
+	DeleteTask(ctx context.Context, accessToken string, listID string, taskID string, etag string) error
+
+	// GetChecklistItems gets a task's checklist items
+	GetChecklistItems(ctx context.Context, accessToken string, listID string, taskID string) ([]models.ChecklistItem, error)
+	// CreateChecklistItem adds an unchecked item to a task's checklist and returns it
+	CreateChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, displayName string) (*models.ChecklistItem, error)
+	// UpdateChecklistItem renames a checklist item
+	UpdateChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, itemID string, displayName string) error
+	// SetChecklistItemChecked checks or unchecks a checklist item
+	SetChecklistItemChecked(ctx context.Context, accessToken string, listID string, taskID string, itemID string, checked bool) error
+	// DeleteChecklistItem deletes a checklist item
+	DeleteChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, itemID string) error
+
+	// GetLinkedResources gets the links from a task to items in other apps
+	GetLinkedResources(ctx context.Context, accessToken string, listID string, taskID string) ([]models.LinkedResource, error)
+	// CreateLinkedResource links a task to an item in another app and returns the link
+	CreateLinkedResource(ctx context.Context, accessToken string, listID string, taskID string, resource models.LinkedResource) (*models.LinkedResource, error)
+	// DeleteLinkedResource deletes a link
+	DeleteLinkedResource(ctx context.Context, accessToken string, listID string, taskID string, resourceID string) error
+
+	// GetAttachments gets the files attached to a task, without their content
+	GetAttachments(ctx context.Context, accessToken string, listID string, taskID string) ([]models.Attachment, error)
+	// GetAttachment gets an attached file with its content
+	GetAttachment(ctx context.Context, accessToken string, listID string, taskID string, attachmentID string) (*models.Attachment, error)
+	// AddAttachment attaches a file to a task
+	AddAttachment(ctx context.Context, accessToken string, listID string, taskID string, name string, contentType string, content []byte) (*models.Attachment, error)
+	// DeleteAttachment deletes an attached file
+	DeleteAttachment(ctx context.Context, accessToken string, listID string, taskID string, attachmentID string) error
+}
+
+// DeltaService is implemented by backends that can report only the tasks changed since a previous query
+type DeltaService interface {
+	// GetListTasksDelta gets the changes to a list's tasks since deltaLink, or every task if deltaLink is empty
+	GetListTasksDelta(ctx context.Context, accessToken string, listID string, deltaLink string) (*models.TaskDelta, error)
+}
+
+// FilterService is implemented by backends that can filter a list's tasks before returning them
+type FilterService interface {
+	// GetFilteredTasks gets the tasks in a list that pass the parts of filter the backend can apply.
+	// Other parts are ignored, so callers check each task with filter.Matches.
+	GetFilteredTasks(ctx context.Context, accessToken string, listID string, filter models.TaskFilter) (*models.TaskResponse, error)
+	// CanFilter reports whether the backend can apply any part of filter; if not, GetFilteredTasks
+	// returns every task, and callers holding the tasks already can filter those instead
+	CanFilter(filter models.TaskFilter) bool
+}
+
+// SubscriptionService is implemented by backends that can push change notifications to a URL
+type SubscriptionService interface {
+	// CreateSubscription creates a subscription; the backend validates its notification URL before returning
+	CreateSubscription(ctx context.Context, accessToken string, subscription models.Subscription) (*models.Subscription, error)
+	// RenewSubscription extends a subscription's expiration
+	RenewSubscription(ctx context.Context, accessToken string, subscriptionID string, expiration time.Time) (*models.Subscription, error)
+	// DeleteSubscription deletes a subscription
+	DeleteSubscription(ctx context.Context, accessToken string, subscriptionID string) error
+}
+
+// CachingService is implemented by backends that cache reads, so they can be told about changes made elsewhere
+type CachingService interface {
+	// Invalidate drops a task, if taskID is set, and its list from the cache
+	Invalidate(listID string, taskID string)
+}
+
+// sessionKey is the context key WithSession stores a session ID under
+type sessionKey struct{}
+
+// WithSession returns a context carrying the ID of the session a call is made for,
+// so backends that cache per session keep their cache when the session's token is refreshed
+func WithSession(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sessionID)
+}
+
+// SessionFromContext returns the session ID set by WithSession, or "" if there is none
+func SessionFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(sessionKey{}).(string)
+	return sessionID
+}
+
+// StatusError is implemented by backend errors that correspond to an HTTP status
+type StatusError interface {
+	error
+	HTTPStatus() int
+}
+
+// RetryAfterError is implemented by backend errors that say how long to wait before retrying
+type RetryAfterError interface {
+	error
+	RetryAfterDelay() time.Duration
+}
+
+// Error is a StatusError for backends without their own error types
+type Error struct {
+	Status  int
+	Message string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// HTTPStatus returns the HTTP status of the error
+func (e *Error) HTTPStatus() int {
+	return e.Status
+}
+
+// NotFound returns a 404 Error with a formatted message
+func NotFound(format string, args ...interface{}) *Error {
+	return &Error{Status: http.StatusNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// BadRequest returns a 400 Error with a formatted message
+func BadRequest(format string, args ...interface{}) *Error {
+	return &Error{Status: http.StatusBadRequest, Message: fmt.Sprintf(format, args...)}
+}
+
+// PreconditionFailed returns a 412 Error with a formatted message
+func PreconditionFailed(format string, args ...interface{}) *Error {
+	return &Error{Status: http.StatusPreconditionFailed, Message: fmt.Sprintf(format, args...)}
+}
+
+// Unauthorized returns a 401 Error with a formatted message
+func Unauthorized(format string, args ...interface{}) *Error {
+	return &Error{Status: http.StatusUnauthorized, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+// Package tasksync keeps a per-session cache of each list's tasks up to date,
+// using delta queries when the backend supports them
+package tasksync
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/service"
+)
+
+const (
+	// DefaultMaxAge is how long cached tasks are served before a read syncs them again
+	DefaultMaxAge = time.Minute
+	// DefaultMaxIdle is how long a list can go unread before its cache is dropped
+	DefaultMaxIdle = 15 * time.Minute
+	// syncTimeout bounds each background sync
+	syncTimeout = 30 * time.Second
+)
+
+// key identifies the cache of one list for one session
+type key struct {
+	sessionID string
+	listID    string
+}
+
+// entry is the cached state of one list for one session
+type entry struct {
+	mu        sync.Mutex // held while reading or syncing the entry
+	tasks     []models.Task
+	deltaLink string
+	syncedAt  time.Time
+	usedAt    time.Time
+	stale     atomic.Bool // set when the list changed through this server
+}
+
+// TokenSource returns a current access token for a session
+type TokenSource func(ctx context.Context, sessionID string) (string, error)
+
+// Cache holds the tasks of the lists each session has open
+type Cache struct {
+	client service.TodoService
+	tokens TokenSource
+
+	// MaxAge is how long cached tasks are served before a read syncs them again
+	MaxAge time.Duration
+	// MaxIdle is how long a list can go unread before its cache is dropped
+	MaxIdle time.Duration
+
+	mu      sync.Mutex
+	entries map[key]*entry
+}
+
+// NewCache creates a Cache that syncs from the given backend.
+// Background syncs get each session's access token from tokens, as the token of the last read may have expired.
+func NewCache(client service.TodoService, tokens TokenSource) *Cache {
+	return &Cache{
+		client:  client,
+		tokens:  tokens,
+		MaxAge:  DefaultMaxAge,
+		MaxIdle: DefaultMaxIdle,
+		entries: make(map[key]*entry),
+	}
+}
+
+// Tasks returns the tasks in a list, syncing first if the cache is missing, stale or too old
+func (c *Cache) Tasks(ctx context.Context, sessionID string, accessToken string, listID string) ([]models.Task, error) {
+	e := c.entry(sessionID, listID)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.usedAt = time.Now()
+	if e.syncedAt.IsZero() || e.stale.Load() || time.Since(e.syncedAt) > c.MaxAge {
+		if err := c.sync(service.WithSession(ctx, sessionID), e, accessToken, listID); err != nil {
+			return nil, err
+		}
+	}
+
+	return append([]models.Task(nil), e.tasks...), nil
+}
+
+// Task returns a single task from the cached list
+func (c *Cache) Task(ctx context.Context, sessionID string, accessToken string, listID string, taskID string) (*models.Task, error) {
+	tasks, err := c.Tasks(ctx, sessionID, accessToken, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tasks {
+		if tasks[i].ID == taskID {
+			return &tasks[i], nil
+		}
+	}
+	return nil, service.NotFound("task %s not found", taskID)
+}
+
+// Invalidate marks every session's cache of a list as stale,
+// so the next read picks up a change made through this server
+func (c *Cache) Invalidate(listID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.entries {
+		if k.listID == listID {
+			e.stale.Store(true)
+		}
+	}
+}
+
+// DeleteSession drops every list cached for a session
+func (c *Cache) DeleteSession(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		if k.sessionID == sessionID {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// SyncAll syncs every list read within MaxIdle and drops the rest
+func (c *Cache) SyncAll(ctx context.Context) {
+	c.mu.Lock()
+	entries := make(map[key]*entry, len(c.entries))
+	for k, e := range c.entries {
+		entries[k] = e
+	}
+	c.mu.Unlock()
+
+	for k, e := range entries {
+		e.mu.Lock()
+		idle := time.Since(e.usedAt) > c.MaxIdle
+		e.mu.Unlock()
+
+		if idle {
+			c.remove(k, e)
+			continue
+		}
+
+		// A session that has ended or can't be refreshed has nothing left to sync
+		accessToken, err := c.tokens(ctx, k.sessionID)
+		if err != nil {
+			log.Printf("Dropping cached tasks for list %s: %v", k.listID, err)
+			c.remove(k, e)
+			continue
+		}
+
+		e.mu.Lock()
+		err = c.sync(service.WithSession(ctx, k.sessionID), e, accessToken, k.listID)
+		e.mu.Unlock()
+		if err != nil {
+			log.Printf("Error syncing tasks for list %s: %v", k.listID, err)
+		}
+	}
+}
+
+// Start syncs every open list in the background at the given interval.
+// It returns a function that stops the background sync.
+func (c *Cache) Start(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+				c.SyncAll(ctx)
+				cancel()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// entry returns the cache entry for a session's list, creating it if needed
+func (c *Cache) entry(sessionID string, listID string) *entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := key{sessionID: sessionID, listID: listID}
+	e, ok := c.entries[k]
+	if !ok {
+		e = &entry{}
+		c.entries[k] = e
+	}
+	return e
+}
+
+// remove drops an entry unless it has been replaced
+func (c *Cache) remove(k key, e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries[k] == e {
+		delete(c.entries, k)
+	}
+}
+
+// sync brings an entry up to date; callers must hold the entry's lock
+func (c *Cache) sync(ctx context.Context, e *entry, accessToken string, listID string) error {
+	// Changes made while syncing leave the entry stale for the next read
+	e.stale.Store(false)
+
+	deltaClient, ok := c.client.(service.DeltaService)
+	if !ok {
+		// Without delta queries, re-read the whole list
+		taskResp, err := c.client.GetListTasks(ctx, accessToken, listID)
+		if err != nil {
+			e.stale.Store(true)
+			return err
+		}
+		e.tasks = taskResp.Value
+		e.syncedAt = time.Now()
+		return nil
+	}
+
+	delta, err := deltaClient.GetListTasksDelta(ctx, accessToken, listID, e.deltaLink)
+	if err != nil && e.deltaLink != "" && isSyncStateLost(err) {
+		// The delta token expired; start tracking again from a full read
+		e.deltaLink = ""
+		delta, err = deltaClient.GetListTasksDelta(ctx, accessToken, listID, "")
+	}
+	if err != nil {
+		e.stale.Store(true)
+		return err
+	}
+
+	// The first round of a delta query returns every task
+	if e.deltaLink == "" {
+		e.tasks = nil
+	}
+	e.tasks = applyDelta(e.tasks, delta)
+	e.deltaLink = delta.DeltaLink
+	e.syncedAt = time.Now()
+	return nil
+}
+
+// applyDelta returns tasks with the changes in delta applied, keeping the existing order
+func applyDelta(tasks []models.Task, delta *models.TaskDelta) []models.Task {
+	updated := make([]models.Task, len(tasks))
+	copy(updated, tasks)
+
+	index := make(map[string]int, len(updated))
+	for i, task := range updated {
+		index[task.ID] = i
+	}
+
+	for _, task := range delta.Tasks {
+		if i, ok := index[task.ID]; ok {
+			updated[i] = task
+		} else {
+			index[task.ID] = len(updated)
+			updated = append(updated, task)
+		}
+	}
+
+	if len(delta.Removed) == 0 {
+		return updated
+	}
+
+	removed := make(map[string]bool, len(delta.Removed))
+	for _, id := range delta.Removed {
+		removed[id] = true
+	}
+	kept := updated[:0]
+	for _, task := range updated {
+		if !removed[task.ID] {
+			kept = append(kept, task)
+		}
+	}
+	return kept
+}
+
+// isSyncStateLost reports whether a delta query failed because its token is no longer valid
+func isSyncStateLost(err error) bool {
+	var statusErr service.StatusError
+	return errors.As(err, &statusErr) && statusErr.HTTPStatus() == http.StatusGone
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package tasksync_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/service"
+	"github.com/coseguera/kanban-to-do/internal/service/memory"
+	"github.com/coseguera/kanban-to-do/internal/tasksync"
+	"github.com/coseguera/kanban-to-do/pkg/microsoft"
+	"github.com/coseguera/kanban-to-do/pkg/microsoft/graphtest"
+)
+
+// graphRequests counts the requests the fake server received for a path suffix
+func graphRequests(server *graphtest.Server, suffix string) int {
+	n := 0
+	for _, req := range server.Requests() {
+		if strings.HasSuffix(req.Path, suffix) {
+			n++
+		}
+	}
+	return n
+}
+
+// testTokens is the token source of the test caches: only "session" is signed in
+func testTokens(ctx context.Context, sessionID string) (string, error) {
+	if sessionID != "session" {
+		return "", errors.New("session not found")
+	}
+	return graphtest.AccessToken, nil
+}
+
+func newTestCache(t *testing.T) (*graphtest.Server, *tasksync.Cache) {
+	t.Helper()
+
+	server := graphtest.NewServer()
+	t.Cleanup(server.Close)
+
+	return server, tasksync.NewCache(microsoft.NewClient(server.Config("https://localhost/auth/callback")), testTokens)
+}
+
+func TestTasksServedFromCache(t *testing.T) {
+	server, cache := newTestCache(t)
+	list := server.AddList("Work")
+	server.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		tasks, err := cache.Tasks(ctx, "session", graphtest.AccessToken, list.ID)
+		if err != nil {
+			t.Fatalf("Tasks failed: %v", err)
+		}
+		if len(tasks) != 1 {
+			t.Fatalf("got %d tasks, want 1", len(tasks))
+		}
+	}
+
+	if n := graphRequests(server, "/tasks/delta"); n != 1 {
+		t.Errorf("delta queried %d times, want 1", n)
+	}
+}
+
+func TestInvalidateAppliesChanges(t *testing.T) {
+	server, cache := newTestCache(t)
+	list := server.AddList("Work")
+	first := server.AddTask(list.ID, models.Task{Title: "First"})
+	second := server.AddTask(list.ID, models.Task{Title: "Second"})
+	ctx := context.Background()
+
+	if _, err := cache.Tasks(ctx, "session", graphtest.AccessToken, list.ID); err != nil {
+		t.Fatalf("Tasks failed: %v", err)
+	}
+
+	// Change the list behind the cache's back, then invalidate it
+	first.Title = "First, renamed"
+	server.SetTask(list.ID, first)
+	server.RemoveTask(list.ID, second.ID)
+	third := server.AddTask(list.ID, models.Task{Title: "Third"})
+	cache.Invalidate(list.ID)
+
+	tasks, err := cache.Tasks(ctx, "session", graphtest.AccessToken, list.ID)
+	if err != nil {
+		t.Fatalf("Tasks failed: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Title != "First, renamed" || tasks[1].ID != third.ID {
+		t.Errorf("unexpected tasks after sync: %+v", tasks)
+	}
+
+	// The second round used the delta token
+	requests := server.Requests()
+	last := requests[len(requests)-1]
+	if last.Query.Get("$deltatoken") == "" {
+		t.Errorf("sync after invalidation did not use the delta token: %s?%s", last.Path, last.Query.Encode())
+	}
+}
+
+func TestExpiredDeltaTokenResyncs(t *testing.T) {
+	server, cache := newTestCache(t)
+	list := server.AddList("Work")
+	server.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	ctx := context.Background()
+
+	if _, err := cache.Tasks(ctx, "session", graphtest.AccessToken, list.ID); err != nil {
+		t.Fatalf("Tasks failed: %v", err)
+	}
+
+	// A 410 Gone makes the cache start over from a full read
+	server.FailNext(1, http.StatusGone, "")
+	cache.Invalidate(list.ID)
+
+	tasks, err := cache.Tasks(ctx, "session", graphtest.AccessToken, list.ID)
+	if err != nil {
+		t.Fatalf("Tasks failed after the delta token expired: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Errorf("got %d tasks, want 1", len(tasks))
+	}
+}
+
+func TestTaskNotFound(t *testing.T) {
+	server, cache := newTestCache(t)
+	list := server.AddList("Work")
+
+	_, err := cache.Task(context.Background(), "session", graphtest.AccessToken, list.ID, "missing")
+
+	var statusErr service.StatusError
+	if !errors.As(err, &statusErr) || statusErr.HTTPStatus() != http.StatusNotFound {
+		t.Errorf("error = %v, want a 404", err)
+	}
+}
+
+func TestSyncAllRefreshesAndEvicts(t *testing.T) {
+	server, cache := newTestCache(t)
+	list := server.AddList("Work")
+	ctx := context.Background()
+
+	if _, err := cache.Tasks(ctx, "session", graphtest.AccessToken, list.ID); err != nil {
+		t.Fatalf("Tasks failed: %v", err)
+	}
+
+	// A background sync picks up changes made elsewhere
+	server.AddTask(list.ID, models.Task{Title: "Added elsewhere"})
+	cache.SyncAll(ctx)
+	before := graphRequests(server, "/tasks/delta")
+
+	tasks, err := cache.Tasks(ctx, "session", graphtest.AccessToken, list.ID)
+	if err != nil {
+		t.Fatalf("Tasks failed: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Errorf("got %d tasks after background sync, want 1", len(tasks))
+	}
+	if graphRequests(server, "/tasks/delta") != before {
+		t.Errorf("read after background sync went to Graph")
+	}
+
+	// Lists nobody reads are dropped instead of synced
+	cache.MaxIdle = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	cache.SyncAll(ctx)
+	if graphRequests(server, "/tasks/delta") != before {
+		t.Errorf("idle list was synced")
+	}
+}
+
+func TestSyncAllUsesCurrentTokens(t *testing.T) {
+	server := graphtest.NewServer()
+	t.Cleanup(server.Close)
+	list := server.AddList("Work")
+	ctx := context.Background()
+
+	// The token a list was last read with may have expired, so background syncs ask for a current one
+	asked := map[string]int{}
+	cache := tasksync.NewCache(microsoft.NewClient(server.Config("https://localhost/auth/callback")), func(ctx context.Context, sessionID string) (string, error) {
+		asked[sessionID]++
+		return testTokens(ctx, sessionID)
+	})
+	for _, sessionID := range []string{"session", "ended"} {
+		if _, err := cache.Tasks(ctx, sessionID, graphtest.AccessToken, list.ID); err != nil {
+			t.Fatalf("Tasks failed: %v", err)
+		}
+	}
+
+	before := graphRequests(server, "/tasks/delta")
+	cache.SyncAll(ctx)
+	if asked["session"] != 1 || asked["ended"] != 1 {
+		t.Errorf("tokens asked for = %v, want one per session", asked)
+	}
+	if n := graphRequests(server, "/tasks/delta") - before; n != 1 {
+		t.Errorf("background sync queried Graph %d times, want once", n)
+	}
+
+	// The session without a token is dropped rather than synced again
+	cache.SyncAll(ctx)
+	if asked["ended"] != 1 {
+		t.Errorf("a session without a token was synced again")
+	}
+}
+
+func TestBackendWithoutDelta(t *testing.T) {
+	backend := memory.NewService()
+	list := backend.AddList("Work")
+	backend.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	cache := tasksync.NewCache(backend, testTokens)
+	ctx := context.Background()
+
+	tasks, err := cache.Tasks(ctx, "session", memory.AccessToken, list.ID)
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("Tasks = %d tasks, %v; want 1 task", len(tasks), err)
+	}
+
+	backend.AddTask(list.ID, models.Task{Title: "Write code"})
+	cache.Invalidate(list.ID)
+
+	tasks, err = cache.Tasks(ctx, "session", memory.AccessToken, list.ID)
+	if err != nil || len(tasks) != 2 {
+		t.Errorf("Tasks after invalidation = %d tasks, %v; want 2 tasks", len(tasks), err)
+	}
+}
@@ -44,5 +44,11 @@ func LoadTemplates(templatesDir string) error {
 	}
 	Templates["tasks"] = tasksTmpl
 
+	loginErrorTmpl, err := template.ParseFiles(filepath.Join(templatesDir, "loginError.html"))
+	if err != nil {
+		return err
+	}
+	Templates["loginError"] = loginErrorTmpl
+
 	return nil
 }
@@ -0,0 +1,293 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package microsoft
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+const (
+	// MaxInlineAttachmentSize is the largest file sent in a single request; larger files use an upload session
+	MaxInlineAttachmentSize = 3 << 20
+	// uploadChunkSize is the size of each range sent to an upload session, which must be a multiple of 320 KiB
+	uploadChunkSize = 10 * 320 << 10
+)
+
+// linkedResourcesURL returns the linked resources collection URL for a task
+func (c *Client) linkedResourcesURL(listID string, taskID string) string {
+	return fmt.Sprintf("%s/%s/tasks/%s/linkedResources", c.config.GraphURL, listID, taskID)
+}
+
+// attachmentsURL returns the attachments collection URL for a task
+func (c *Client) attachmentsURL(listID string, taskID string) string {
+	return fmt.Sprintf("%s/%s/tasks/%s/attachments", c.config.GraphURL, listID, taskID)
+}
+
+// GetLinkedResources gets all of a task's linked resources, following pagination
+func (c *Client) GetLinkedResources(ctx context.Context, accessToken string, listID string, taskID string) ([]models.LinkedResource, error) {
+	var resources []models.LinkedResource
+	err := c.walkPages(c.linkedResourcesURL(listID, taskID), func(pageURL string) (string, error) {
+		var page models.LinkedResourceResponse
+		if err := c.getPage(ctx, accessToken, pageURL, &page); err != nil {
+			return "", err
+		}
+		resources = append(resources, page.Value...)
+		return page.NextLink, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
+
+// CreateLinkedResource links a task to an item in another app
+func (c *Client) CreateLinkedResource(ctx context.Context, accessToken string, listID string, taskID string, resource models.LinkedResource) (*models.LinkedResource, error) {
+	resource.ID = ""
+
+	var created models.LinkedResource
+	if err := c.postJSON(ctx, accessToken, c.linkedResourcesURL(listID, taskID), resource, &created); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// DeleteLinkedResource deletes a linked resource
+func (c *Client) DeleteLinkedResource(ctx context.Context, accessToken string, listID string, taskID string, resourceID string) error {
+	return c.deleteItem(ctx, accessToken, c.linkedResourcesURL(listID, taskID)+"/"+resourceID)
+}
+
+// GetAttachments gets all of a task's attachments, following pagination.
+// Graph does not return content when listing attachments.
+func (c *Client) GetAttachments(ctx context.Context, accessToken string, listID string, taskID string) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := c.walkPages(c.attachmentsURL(listID, taskID), func(pageURL string) (string, error) {
+		var page models.AttachmentResponse
+		if err := c.getPage(ctx, accessToken, pageURL, &page); err != nil {
+			return "", err
+		}
+		attachments = append(attachments, page.Value...)
+		return page.NextLink, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment gets an attachment with its content
+func (c *Client) GetAttachment(ctx context.Context, accessToken string, listID string, taskID string, attachmentID string) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := c.getPage(ctx, accessToken, c.attachmentsURL(listID, taskID)+"/"+attachmentID, &attachment); err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// AddAttachment attaches a file to a task. Files up to MaxInlineAttachmentSize are sent in one request,
+// larger ones through an upload session.
+func (c *Client) AddAttachment(ctx context.Context, accessToken string, listID string, taskID string, name string, contentType string, content []byte) (*models.Attachment, error) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	if len(content) > MaxInlineAttachmentSize {
+		return c.uploadAttachment(ctx, accessToken, listID, taskID, name, contentType, content)
+	}
+
+	var attachment models.Attachment
+	err := c.postJSON(ctx, accessToken, c.attachmentsURL(listID, taskID), map[string]interface{}{
+		"@odata.type":  "#microsoft.graph.taskFileAttachment",
+		"name":         name,
+		"contentType":  contentType,
+		"contentBytes": content,
+	}, &attachment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// DeleteAttachment deletes an attachment
+func (c *Client) DeleteAttachment(ctx context.Context, accessToken string, listID string, taskID string, attachmentID string) error {
+	return c.deleteItem(ctx, accessToken, c.attachmentsURL(listID, taskID)+"/"+attachmentID)
+}
+
+// uploadAttachment creates an upload session and sends the content to it in ranges
+func (c *Client) uploadAttachment(ctx context.Context, accessToken string, listID string, taskID string, name string, contentType string, content []byte) (*models.Attachment, error) {
+	var session models.UploadSession
+	err := c.postJSON(ctx, accessToken, c.attachmentsURL(listID, taskID)+"/createUploadSession", map[string]interface{}{
+		"attachmentInfo": map[string]interface{}{
+			"attachmentType": "file",
+			"name":           name,
+			"contentType":    contentType,
+			"size":           len(content),
+		},
+	}, &session)
+	if err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(content); start += uploadChunkSize {
+		end := start + uploadChunkSize
+		if end > len(content) {
+			end = len(content)
+		}
+
+		attachment, err := c.uploadRange(ctx, session.UploadURL, content[start:end], start, len(content))
+		if err != nil {
+			c.cancelUpload(session.UploadURL)
+			return nil, err
+		}
+		if attachment != nil {
+			return attachment, nil
+		}
+	}
+
+	c.cancelUpload(session.UploadURL)
+	return nil, fmt.Errorf("upload session for %s did not complete", name)
+}
+
+// uploadRange sends one range of an upload. It returns the attachment once the last range has been accepted.
+// The upload URL is pre-authenticated, so no access token is sent.
+func (c *Client) uploadRange(ctx context.Context, uploadURL string, chunk []byte, start int, total int) (*models.Attachment, error) {
+	req, err := http.NewRequestWithContext(ctx, "PUT", uploadURL, bytes.NewReader(chunk))
+	if err != nil {
+		return nil, fmt.Errorf("error creating upload request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/octet-stream")
+	req.Header.Add("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+len(chunk)-1, total))
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error uploading attachment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted:
+		// More ranges are expected
+		io.Copy(io.Discard, resp.Body)
+		return nil, nil
+	case http.StatusCreated:
+	default:
+		return nil, newGraphError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading API response: %w", err)
+	}
+
+	var attachment models.Attachment
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &attachment); err != nil {
+			return nil, fmt.Errorf("error parsing API response: %w", err)
+		}
+	}
+	if attachment.ID == "" {
+		attachment.ID = attachmentIDFromLocation(resp.Header.Get("Location"))
+	}
+	attachment.ContentBytes = nil
+
+	return &attachment, nil
+}
+
+// cancelUpload deletes an unfinished upload session so Graph can discard the ranges already sent
+func (c *Client) cancelUpload(uploadURL string) {
+	req, err := http.NewRequest("DELETE", uploadURL, nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// attachmentIDFromLocation reads the attachment ID from the Location of a completed upload,
+// which Graph gives as either .../attachments/{id} or .../attachments('{id}')
+func attachmentIDFromLocation(location string) string {
+	i := strings.LastIndex(location, "attachments")
+	if i < 0 {
+		return ""
+	}
+	id := location[i+len("attachments"):]
+	id = strings.TrimPrefix(id, "/")
+	id = strings.TrimPrefix(id, "('")
+	id = strings.TrimSuffix(id, "')")
+	return id
+}
+
+// postJSON creates an item in a collection and decodes the created item into v
+func (c *Client) postJSON(ctx context.Context, accessToken string, collectionURL string, payload interface{}, v interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", collectionURL, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("error creating API request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("error calling Microsoft Graph API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return newGraphError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading API response: %w", err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("error parsing API response: %w", err)
+	}
+
+	return nil
+}
+
+// deleteItem deletes a single item of a task
+func (c *Client) deleteItem(ctx context.Context, accessToken string, itemURL string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", itemURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating API request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("error calling Microsoft Graph API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return newGraphError(resp)
+	}
+
+	return nil
+}
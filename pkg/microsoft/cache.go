@@ -0,0 +1,384 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package microsoft
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/service"
+)
+
+// CachingClient implements the same backend as Client
+var (
+	_ service.TodoService         = (*CachingClient)(nil)
+	_ service.DeltaService        = (*CachingClient)(nil)
+	_ service.FilterService       = (*CachingClient)(nil)
+	_ service.SubscriptionService = (*CachingClient)(nil)
+	_ service.CachingService      = (*CachingClient)(nil)
+)
+
+const (
+	// DefaultCacheFreshFor is how long a cached task is served without asking Graph
+	DefaultCacheFreshFor = 10 * time.Second
+	// DefaultCacheMaxIdle is how long a session's cache is kept after its last use
+	DefaultCacheMaxIdle = time.Hour
+)
+
+// CacheStats counts how task reads were served by a CachingClient
+type CacheStats struct {
+	Hits          int64   `json:"hits"`          // served from the cache without calling Graph
+	Revalidations int64   `json:"revalidations"` // confirmed unchanged by Graph with a 304
+	Misses        int64   `json:"misses"`        // downloaded from Graph
+	HitRate       float64 `json:"hitRate"`       // share of reads that didn't download the task
+}
+
+// cachedTask is a task with the time it was last confirmed current
+type cachedTask struct {
+	task      models.Task
+	checkedAt time.Time
+}
+
+// cachedList is the full set of tasks in a list, as last downloaded
+type cachedList struct {
+	tasks     []models.Task
+	fetchedAt time.Time
+}
+
+// taskKey identifies a task
+type taskKey struct {
+	listID string
+	taskID string
+}
+
+// sessionCache holds what one session has read. Sessions are told apart by the session ID
+// in the context of each call, or by their access token when there is none.
+type sessionCache struct {
+	tasks  map[taskKey]cachedTask
+	lists  map[string]cachedList
+	usedAt time.Time
+}
+
+// CachingClient is a Client with a per-session read-through cache of tasks.
+// Cached tasks are served directly for FreshFor, then revalidated with If-None-Match
+// using their @odata.etag. Writes through the client invalidate what they change.
+type CachingClient struct {
+	*Client
+
+	// FreshFor is how long a cached task is served without asking Graph
+	FreshFor time.Duration
+	// MaxIdle is how long a session's cache is kept after its last use
+	MaxIdle time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*sessionCache
+	// generation counts invalidations, so a read that overlapped one doesn't cache what it read
+	generation uint64
+
+	hits          atomic.Int64
+	revalidations atomic.Int64
+	misses        atomic.Int64
+}
+
+// NewCachingClient wraps a Client with a task cache
+func NewCachingClient(client *Client) *CachingClient {
+	return &CachingClient{
+		Client:   client,
+		FreshFor: DefaultCacheFreshFor,
+		MaxIdle:  DefaultCacheMaxIdle,
+		sessions: make(map[string]*sessionCache),
+	}
+}
+
+// Stats returns how task reads have been served so far
+func (c *CachingClient) Stats() CacheStats {
+	stats := CacheStats{
+		Hits:          c.hits.Load(),
+		Revalidations: c.revalidations.Load(),
+		Misses:        c.misses.Load(),
+	}
+	if total := stats.Hits + stats.Revalidations + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits+stats.Revalidations) / float64(total)
+	}
+	return stats
+}
+
+// GetListTasks gets all of the tasks in a list, from the cache if they were downloaded within FreshFor
+func (c *CachingClient) GetListTasks(ctx context.Context, accessToken string, listID string) (*models.TaskResponse, error) {
+	c.mu.Lock()
+	list, ok := c.session(ctx, accessToken).lists[listID]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && time.Since(list.fetchedAt) < c.FreshFor {
+		c.hits.Add(1)
+		return &models.TaskResponse{Value: append([]models.Task(nil), list.tasks...)}, nil
+	}
+
+	taskResp, err := c.Client.GetListTasks(ctx, accessToken, listID)
+	if err != nil {
+		return nil, err
+	}
+	c.misses.Add(1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return taskResp, nil
+	}
+	session := c.session(ctx, accessToken)
+	session.lists[listID] = cachedList{tasks: append([]models.Task(nil), taskResp.Value...), fetchedAt: time.Now()}
+	c.storeTasks(session, listID, taskResp.Value)
+	return taskResp, nil
+}
+
+// GetListTasksDelta gets the changes to a list's tasks, caching the tasks it returns
+func (c *CachingClient) GetListTasksDelta(ctx context.Context, accessToken string, listID string, deltaLink string) (*models.TaskDelta, error) {
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	delta, err := c.Client.GetListTasksDelta(ctx, accessToken, listID, deltaLink)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return delta, nil
+	}
+	session := c.session(ctx, accessToken)
+	c.storeTasks(session, listID, delta.Tasks)
+	for _, taskID := range delta.Removed {
+		delete(session.tasks, taskKey{listID: listID, taskID: taskID})
+	}
+	if len(delta.Tasks) > 0 || len(delta.Removed) > 0 {
+		delete(session.lists, listID)
+	}
+	return delta, nil
+}
+
+// GetTaskDetails gets a single task, from the cache while it is fresh or unchanged on Graph
+func (c *CachingClient) GetTaskDetails(ctx context.Context, accessToken string, listID string, taskID string) (*models.Task, error) {
+	k := taskKey{listID: listID, taskID: taskID}
+
+	c.mu.Lock()
+	cached, ok := c.session(ctx, accessToken).tasks[k]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && time.Since(cached.checkedAt) < c.FreshFor {
+		c.hits.Add(1)
+		task := cached.task
+		return &task, nil
+	}
+
+	// Ask Graph whether the cached version is still current
+	var etag string
+	if ok {
+		etag = cached.task.ETag
+	}
+	task, notModified, err := c.Client.getTask(ctx, accessToken, listID, taskID, etag)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Only cache what was read if nothing was invalidated meanwhile
+	store := c.generation == generation
+	session := c.session(ctx, accessToken)
+	if notModified {
+		c.revalidations.Add(1)
+		if store {
+			session.tasks[k] = cachedTask{task: cached.task, checkedAt: time.Now()}
+		}
+		task := cached.task
+		return &task, nil
+	}
+
+	c.misses.Add(1)
+	if store {
+		session.tasks[k] = cachedTask{task: *task, checkedAt: time.Now()}
+	}
+	return task, nil
+}
+
+// CreateTask creates a task and invalidates the cached list
+func (c *CachingClient) CreateTask(ctx context.Context, accessToken string, listID string, title string) (string, error) {
+	taskID, err := c.Client.CreateTask(ctx, accessToken, listID, title)
+	c.Invalidate(listID, "")
+	return taskID, err
+}
+
+// CopyTask creates a copy of a task and invalidates the cached list it was added to
+func (c *CachingClient) CopyTask(ctx context.Context, accessToken string, listID string, task models.Task) (*models.Task, error) {
+	created, err := c.Client.CopyTask(ctx, accessToken, listID, task)
+	c.Invalidate(listID, "")
+	return created, err
+}
+
+// UpdateTaskStatus updates a task's status and categories and invalidates the cached task
+func (c *CachingClient) UpdateTaskStatus(ctx context.Context, accessToken string, listID string, taskID string, etag string, status string, categories []string) error {
+	err := c.Client.UpdateTaskStatus(ctx, accessToken, listID, taskID, etag, status, categories)
+	c.Invalidate(listID, taskID)
+	return err
+}
+
+// UpdateTaskImportance updates a task's importance and invalidates the cached task
+func (c *CachingClient) UpdateTaskImportance(ctx context.Context, accessToken string, listID string, taskID string, etag string, importance string) error {
+	err := c.Client.UpdateTaskImportance(ctx, accessToken, listID, taskID, etag, importance)
+	c.Invalidate(listID, taskID)
+	return err
+}
+
+// UpdateTaskDetails updates a task's details and invalidates the cached task
+func (c *CachingClient) UpdateTaskDetails(ctx context.Context, accessToken string, listID string, taskID string, etag string, update models.TaskUpdate) error {
+	err := c.Client.UpdateTaskDetails(ctx, accessToken, listID, taskID, etag, update)
+	c.Invalidate(listID, taskID)
+	return err
+}
+
+// DeleteTask deletes a task and invalidates the cached task
+func (c *CachingClient) DeleteTask(ctx context.Context, accessToken string, listID string, taskID string, etag string) error {
+	err := c.Client.DeleteTask(ctx, accessToken, listID, taskID, etag)
+	c.Invalidate(listID, taskID)
+	return err
+}
+
+// CreateChecklistItem adds a checklist item and invalidates the cached task, which includes its checklist
+func (c *CachingClient) CreateChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, displayName string) (*models.ChecklistItem, error) {
+	item, err := c.Client.CreateChecklistItem(ctx, accessToken, listID, taskID, displayName)
+	c.Invalidate(listID, taskID)
+	return item, err
+}
+
+// UpdateChecklistItem renames a checklist item and invalidates the cached task
+func (c *CachingClient) UpdateChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, itemID string, displayName string) error {
+	err := c.Client.UpdateChecklistItem(ctx, accessToken, listID, taskID, itemID, displayName)
+	c.Invalidate(listID, taskID)
+	return err
+}
+
+// SetChecklistItemChecked checks or unchecks a checklist item and invalidates the cached task
+func (c *CachingClient) SetChecklistItemChecked(ctx context.Context, accessToken string, listID string, taskID string, itemID string, checked bool) error {
+	err := c.Client.SetChecklistItemChecked(ctx, accessToken, listID, taskID, itemID, checked)
+	c.Invalidate(listID, taskID)
+	return err
+}
+
+// DeleteChecklistItem deletes a checklist item and invalidates the cached task
+func (c *CachingClient) DeleteChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, itemID string) error {
+	err := c.Client.DeleteChecklistItem(ctx, accessToken, listID, taskID, itemID)
+	c.Invalidate(listID, taskID)
+	return err
+}
+
+// CreateLinkedResource links a task to another app's item and invalidates the cached task
+func (c *CachingClient) CreateLinkedResource(ctx context.Context, accessToken string, listID string, taskID string, resource models.LinkedResource) (*models.LinkedResource, error) {
+	created, err := c.Client.CreateLinkedResource(ctx, accessToken, listID, taskID, resource)
+	c.Invalidate(listID, taskID)
+	return created, err
+}
+
+// DeleteLinkedResource deletes a linked resource and invalidates the cached task
+func (c *CachingClient) DeleteLinkedResource(ctx context.Context, accessToken string, listID string, taskID string, resourceID string) error {
+	err := c.Client.DeleteLinkedResource(ctx, accessToken, listID, taskID, resourceID)
+	c.Invalidate(listID, taskID)
+	return err
+}
+
+// AddAttachment attaches a file and invalidates the cached task, whose hasAttachments may change
+func (c *CachingClient) AddAttachment(ctx context.Context, accessToken string, listID string, taskID string, name string, contentType string, content []byte) (*models.Attachment, error) {
+	attachment, err := c.Client.AddAttachment(ctx, accessToken, listID, taskID, name, contentType, content)
+	c.Invalidate(listID, taskID)
+	return attachment, err
+}
+
+// DeleteAttachment deletes an attachment and invalidates the cached task
+func (c *CachingClient) DeleteAttachment(ctx context.Context, accessToken string, listID string, taskID string, attachmentID string) error {
+	err := c.Client.DeleteAttachment(ctx, accessToken, listID, taskID, attachmentID)
+	c.Invalidate(listID, taskID)
+	return err
+}
+
+// DeleteList deletes a list and drops it and all of its tasks from the cache
+func (c *CachingClient) DeleteList(ctx context.Context, accessToken string, listID string) error {
+	err := c.Client.DeleteList(ctx, accessToken, listID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, session := range c.sessions {
+		delete(session.lists, listID)
+		for key := range session.tasks {
+			if key.listID == listID {
+				delete(session.tasks, key)
+			}
+		}
+	}
+	return err
+}
+
+// Invalidate drops a task, if taskID is set, and its list from every session's cache.
+// Writes call it even when they fail, since the failure may have come after Graph applied them.
+// Reads already under way when it is called don't cache their results, as they may predate the change.
+func (c *CachingClient) Invalidate(listID string, taskID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, session := range c.sessions {
+		delete(session.lists, listID)
+		if taskID != "" {
+			delete(session.tasks, taskKey{listID: listID, taskID: taskID})
+		}
+	}
+}
+
+// storeTasks caches tasks read from Graph; callers must hold the lock
+func (c *CachingClient) storeTasks(session *sessionCache, listID string, tasks []models.Task) {
+	now := time.Now()
+	for _, task := range tasks {
+		session.tasks[taskKey{listID: listID, taskID: task.ID}] = cachedTask{task: task, checkedAt: now}
+	}
+}
+
+// session returns the cache for the session in ctx, or for an access token without one,
+// creating it and sweeping idle sessions if needed; callers must hold the lock
+func (c *CachingClient) session(ctx context.Context, accessToken string) *sessionCache {
+	var k string
+	if sessionID := service.SessionFromContext(ctx); sessionID != "" {
+		k = "session:" + sessionID
+	} else {
+		hash := sha256.Sum256([]byte(accessToken))
+		k = "token:" + hex.EncodeToString(hash[:])
+	}
+	now := time.Now()
+
+	session, ok := c.sessions[k]
+	if !ok {
+		for other, s := range c.sessions {
+			if now.Sub(s.usedAt) > c.MaxIdle {
+				delete(c.sessions, other)
+			}
+		}
+		session = &sessionCache{
+			tasks: make(map[taskKey]cachedTask),
+			lists: make(map[string]cachedList),
+		}
+		c.sessions[k] = session
+	}
+	session.usedAt = now
+	return session
+}
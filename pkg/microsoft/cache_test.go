@@ -0,0 +1,251 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package microsoft_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/service"
+	"github.com/coseguera/kanban-to-do/pkg/microsoft"
+	"github.com/coseguera/kanban-to-do/pkg/microsoft/graphtest"
+)
+
+// newTestCachingClient starts a fake Graph server and a caching client pointed at it
+func newTestCachingClient(t *testing.T) (*graphtest.Server, *microsoft.CachingClient) {
+	t.Helper()
+
+	server, client := newTestClient(t)
+	return server, microsoft.NewCachingClient(client)
+}
+
+// roundTripFunc is an http.RoundTripper made from a function
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// taskReads counts the single-task GETs the fake server received
+func taskReads(server *graphtest.Server) int {
+	n := 0
+	for _, req := range server.Requests() {
+		if req.Method == "GET" && strings.Contains(req.Path, "/tasks/") && !strings.HasSuffix(req.Path, "/delta") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCachedTaskServedWhileFresh(t *testing.T) {
+	server, client := newTestCachingClient(t)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		got, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID)
+		if err != nil {
+			t.Fatalf("GetTaskDetails failed: %v", err)
+		}
+		if got.Title != "Plan sprint" || got.ETag == "" {
+			t.Errorf("unexpected task: %+v", got)
+		}
+	}
+
+	if n := taskReads(server); n != 1 {
+		t.Errorf("Graph was asked %d times, want 1", n)
+	}
+	if stats := client.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 2 hits and 1 miss", stats)
+	}
+}
+
+func TestStaleTaskRevalidatedWithETag(t *testing.T) {
+	server, client := newTestCachingClient(t)
+	client.FreshFor = 0
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	ctx := context.Background()
+
+	// Unchanged on Graph: a 304 confirms the cached copy
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID); err != nil {
+			t.Fatalf("GetTaskDetails failed: %v", err)
+		}
+	}
+	requests := server.Requests()
+	if got := requests[len(requests)-1].Header.Get("If-None-Match"); got != task.ETag {
+		t.Errorf("If-None-Match = %q, want %q", got, task.ETag)
+	}
+	if stats := client.Stats(); stats.Revalidations != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 1 revalidation and 1 miss", stats)
+	}
+
+	// Changed elsewhere: the new version is downloaded
+	task.Title = "Renamed elsewhere"
+	server.SetTask(list.ID, task)
+
+	got, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID)
+	if err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+	if got.Title != "Renamed elsewhere" {
+		t.Errorf("title = %q, want the new title", got.Title)
+	}
+	if stats := client.Stats(); stats.Misses != 2 {
+		t.Errorf("stats = %+v, want 2 misses", stats)
+	}
+}
+
+func TestListReadsFillTaskCache(t *testing.T) {
+	server, client := newTestCachingClient(t)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	ctx := context.Background()
+
+	if _, err := client.GetListTasks(ctx, graphtest.AccessToken, list.ID); err != nil {
+		t.Fatalf("GetListTasks failed: %v", err)
+	}
+	if _, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID); err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+
+	if n := taskReads(server); n != 0 {
+		t.Errorf("opening a task just listed asked Graph %d times, want 0", n)
+	}
+}
+
+func TestWritesInvalidateCache(t *testing.T) {
+	server, client := newTestCachingClient(t)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	ctx := context.Background()
+
+	if _, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID); err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+	if err := client.UpdateTaskImportance(ctx, graphtest.AccessToken, list.ID, task.ID, "", "high"); err != nil {
+		t.Fatalf("UpdateTaskImportance failed: %v", err)
+	}
+
+	got, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID)
+	if err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+	if got.Importance != "high" {
+		t.Errorf("importance = %q after our own write, want high", got.Importance)
+	}
+
+	// Creating a task makes the next list read go to Graph
+	if _, err := client.GetListTasks(ctx, graphtest.AccessToken, list.ID); err != nil {
+		t.Fatalf("GetListTasks failed: %v", err)
+	}
+	if _, err := client.CreateTask(ctx, graphtest.AccessToken, list.ID, "Write code"); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	taskResp, err := client.GetListTasks(ctx, graphtest.AccessToken, list.ID)
+	if err != nil {
+		t.Fatalf("GetListTasks failed: %v", err)
+	}
+	if len(taskResp.Value) != 2 {
+		t.Errorf("got %d tasks after create, want 2", len(taskResp.Value))
+	}
+}
+
+func TestSessionsAreCachedSeparately(t *testing.T) {
+	server, client := newTestCachingClient(t)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	ctx := context.Background()
+
+	if _, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID); err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+
+	// Another session's token must not be served from this session's cache
+	if _, err := client.GetTaskDetails(ctx, "someone-else", list.ID, task.ID); err == nil {
+		t.Errorf("another session was served a cached task without Graph checking its token")
+	}
+}
+
+func TestSessionKeepsCacheAcrossTokens(t *testing.T) {
+	server, client := newTestCachingClient(t)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	ctx := service.WithSession(context.Background(), "session")
+
+	if _, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID); err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+
+	// A refreshed token is still the same session, so its cache is kept
+	if _, err := client.GetTaskDetails(ctx, "refreshed-token", list.ID, task.ID); err != nil {
+		t.Errorf("GetTaskDetails with a refreshed token failed: %v", err)
+	}
+	if n := taskReads(server); n != 1 {
+		t.Errorf("Graph was asked %d times, want 1", n)
+	}
+
+	// Another session has its own cache
+	other := service.WithSession(context.Background(), "other")
+	if _, err := client.GetTaskDetails(other, "someone-else", list.ID, task.ID); err == nil {
+		t.Errorf("another session was served a cached task without Graph checking its token")
+	}
+}
+
+func TestReadOverlappingInvalidateNotCached(t *testing.T) {
+	server := graphtest.NewServer()
+	t.Cleanup(server.Close)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	ctx := context.Background()
+
+	// A write invalidates the task while the first read is waiting for Graph
+	var client *microsoft.CachingClient
+	overlap := true
+	config := server.Config("https://localhost/auth/callback")
+	config.HTTPClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if overlap {
+			overlap = false
+			client.Invalidate(list.ID, task.ID)
+		}
+		return resp, err
+	})}
+	client = microsoft.NewCachingClient(microsoft.NewClient(config))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID); err != nil {
+			t.Fatalf("GetTaskDetails failed: %v", err)
+		}
+	}
+
+	// What the first read returned may predate the write, so the second read asks Graph again
+	if n := taskReads(server); n != 2 {
+		t.Errorf("Graph was asked %d times, want 2", n)
+	}
+}
+
+func TestCacheHitRate(t *testing.T) {
+	server, client := newTestCachingClient(t)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Plan sprint"})
+	ctx := context.Background()
+
+	if stats := client.Stats(); stats.HitRate != 0 {
+		t.Errorf("hit rate before any reads = %v, want 0", stats.HitRate)
+	}
+	for i := 0; i < 4; i++ {
+		client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID)
+	}
+	if stats := client.Stats(); stats.HitRate != 0.75 {
+		t.Errorf("hit rate = %v, want 0.75", stats.HitRate)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package microsoft
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// checklistURL returns the checklist items collection URL for a task
+func (c *Client) checklistURL(listID string, taskID string) string {
+	return fmt.Sprintf("%s/%s/tasks/%s/checklistItems", c.config.GraphURL, listID, taskID)
+}
+
+// GetChecklistItems gets all of a task's checklist items, following pagination
+func (c *Client) GetChecklistItems(ctx context.Context, accessToken string, listID string, taskID string) ([]models.ChecklistItem, error) {
+	var items []models.ChecklistItem
+	err := c.walkPages(c.checklistURL(listID, taskID), func(pageURL string) (string, error) {
+		var page models.ChecklistItemResponse
+		if err := c.getPage(ctx, accessToken, pageURL, &page); err != nil {
+			return "", err
+		}
+		items = append(items, page.Value...)
+		return page.NextLink, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// CreateChecklistItem adds an unchecked item to a task's checklist
+func (c *Client) CreateChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, displayName string) (*models.ChecklistItem, error) {
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"displayName": displayName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling checklist item: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.checklistURL(listID, taskID), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("error creating API request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling Microsoft Graph API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, newGraphError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading API response: %w", err)
+	}
+
+	var item models.ChecklistItem
+	if err := json.Unmarshal(body, &item); err != nil {
+		return nil, fmt.Errorf("error parsing API response: %w", err)
+	}
+
+	return &item, nil
+}
+
+// UpdateChecklistItem renames a checklist item
+func (c *Client) UpdateChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, itemID string, displayName string) error {
+	return c.patchChecklistItem(ctx, accessToken, listID, taskID, itemID, map[string]interface{}{
+		"displayName": displayName,
+	})
+}
+
+// SetChecklistItemChecked checks or unchecks a checklist item
+func (c *Client) SetChecklistItemChecked(ctx context.Context, accessToken string, listID string, taskID string, itemID string, checked bool) error {
+	return c.patchChecklistItem(ctx, accessToken, listID, taskID, itemID, map[string]interface{}{
+		"isChecked": checked,
+	})
+}
+
+// DeleteChecklistItem deletes a checklist item
+func (c *Client) DeleteChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, itemID string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.checklistURL(listID, taskID)+"/"+itemID, nil)
+	if err != nil {
+		return fmt.Errorf("error creating API request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("error calling Microsoft Graph API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return newGraphError(resp)
+	}
+
+	return nil
+}
+
+// patchChecklistItem sends a partial update of a checklist item
+func (c *Client) patchChecklistItem(ctx context.Context, accessToken string, listID string, taskID string, itemID string, payload map[string]interface{}) error {
+	return c.patchItem(ctx, accessToken, c.checklistURL(listID, taskID)+"/"+itemID, payload)
+}
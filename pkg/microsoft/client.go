@@ -7,67 +7,211 @@ package microsoft
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/internal/service"
+)
+
+// Client implements the backend used by the handlers, including delta queries, filtering and subscriptions
+var (
+	_ service.TodoService         = (*Client)(nil)
+	_ service.DeltaService        = (*Client)(nil)
+	_ service.FilterService       = (*Client)(nil)
+	_ service.SubscriptionService = (*Client)(nil)
 )
 
 // Config contains the configuration for the Microsoft client
 type Config struct {
 	ClientID     string
 	ClientSecret string
+	// PublicClient runs the authorization-code flow without a client secret, relying on PKCE alone
+	PublicClient bool
 	RedirectURI  string
 	AuthURL      string
 	TokenURL     string
 	Scope        string
 	GraphURL     string
+	// SubscriptionsURL is the Graph subscriptions collection; if empty it is derived from GraphURL
+	SubscriptionsURL string
+
+	// PageSize is the $top value requested for collection calls (DefaultPageSize if zero)
+	PageSize int
+	// MaxPages caps how many pages a collection call will follow (DefaultMaxPages if zero)
+	MaxPages int
+
+	// MaxRetries is how many times a throttled or unavailable request is retried (DefaultMaxRetries if zero, none if negative)
+	MaxRetries int
+	// RetryBaseDelay is the first retry delay, doubled on each attempt (DefaultRetryBaseDelay if zero)
+	RetryBaseDelay time.Duration
+
+	// HTTPClient is used for every request; set it to inject a custom transport.
+	// If nil, a client with Timeout is created and shared by all calls.
+	HTTPClient *http.Client
+	// Timeout bounds each request made by the default HTTP client (DefaultTimeout if zero)
+	Timeout time.Duration
 }
 
+const (
+	// DefaultPageSize is the page size used when Config.PageSize is not set
+	DefaultPageSize = 100
+	// DefaultMaxPages is the page cap used when Config.MaxPages is not set
+	DefaultMaxPages = 50
+	// DefaultMaxRetries is the retry count used when Config.MaxRetries is not set
+	DefaultMaxRetries = 3
+	// DefaultRetryBaseDelay is the first retry delay used when Config.RetryBaseDelay is not set
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	// DefaultTimeout is the per-request timeout used when Config.Timeout is not set
+	DefaultTimeout = 30 * time.Second
+	// maxRetryDelay caps how long a single retry waits
+	maxRetryDelay = 30 * time.Second
+)
+
 // Client is a client for Microsoft Graph API
 type Client struct {
-	config Config
+	config     Config
+	httpClient *http.Client
 }
 
 // NewClient creates a new Microsoft client
 func NewClient(config Config) *Client {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		httpClient = &http.Client{Timeout: timeout}
+	}
+
 	return &Client{
-		config: config,
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+// do sends a request, retrying with exponential backoff when Graph responds with
+// 429, 503 or 504 and honoring any Retry-After header. Waiting stops early if the
+// request's context is cancelled. Requests that aren't idempotent are only retried
+// after a 429, since Graph may have applied them before a 503 or 504.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	maxRetries := c.config.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultMaxRetries
+	}
+	delay := c.config.RetryBaseDelay
+	if delay <= 0 {
+		delay = DefaultRetryBaseDelay
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isRetryable(req, resp.StatusCode) || attempt >= maxRetries {
+			return resp, nil
+		}
+
+		// Requests with a body can only be retried if it can be replayed
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		wait := parseRetryAfter(resp.Header.Get("Retry-After"))
+		if wait == 0 {
+			wait = delay << attempt
+		}
+		if wait > maxRetryDelay {
+			wait = maxRetryDelay
+		}
+
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// isRetryable reports whether a request that got a status code may succeed if retried.
+// A throttled request was never applied, so it can always be retried; after a 503 or 504
+// only requests that can't apply twice are: reads, deletes and updates sent with If-Match.
+func isRetryable(req *http.Request, statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(req)
+	}
+	return false
+}
+
+// isIdempotent reports whether sending a request twice has the same effect as sending it once
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	case http.MethodPatch:
+		return req.Header.Get("If-Match") != ""
 	}
+	return false
 }
 
-// GetAuthURL returns the authorization URL
-func (c *Client) GetAuthURL(state string) string {
-	return fmt.Sprintf("%s?client_id=%s&response_type=code&redirect_uri=%s&scope=%s&response_mode=query&state=%s",
-		c.config.AuthURL, c.config.ClientID, url.QueryEscape(c.config.RedirectURI), url.QueryEscape(c.config.Scope), state)
+// GetAuthURL returns the authorization URL for the given state and S256 PKCE code challenge
+func (c *Client) GetAuthURL(state string, codeChallenge string) string {
+	return fmt.Sprintf("%s?client_id=%s&response_type=code&redirect_uri=%s&scope=%s&response_mode=query&state=%s&code_challenge=%s&code_challenge_method=S256",
+		c.config.AuthURL, c.config.ClientID, url.QueryEscape(c.config.RedirectURI), url.QueryEscape(c.config.Scope),
+		url.QueryEscape(state), url.QueryEscape(codeChallenge))
 }
 
-// ExchangeCodeForToken exchanges an authorization code for an access token
-func (c *Client) ExchangeCodeForToken(code string) (*models.TokenResponse, error) {
+// ExchangeCodeForToken exchanges an authorization code and its PKCE code verifier for an access token
+func (c *Client) ExchangeCodeForToken(ctx context.Context, code string, codeVerifier string) (*models.TokenResponse, error) {
 	tokenData := url.Values{}
 	tokenData.Set("client_id", c.config.ClientID)
-	tokenData.Set("client_secret", c.config.ClientSecret)
+	c.setClientSecret(tokenData)
 	tokenData.Set("code", code)
+	tokenData.Set("code_verifier", codeVerifier)
 	tokenData.Set("redirect_uri", c.config.RedirectURI)
 	tokenData.Set("grant_type", "authorization_code")
 
-	req, err := http.NewRequest("POST", c.config.TokenURL, strings.NewReader(tokenData.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.config.TokenURL, strings.NewReader(tokenData.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("error creating token request: %w", err)
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error exchanging code for token: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, newGraphError(resp)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("error reading token response: %w", err)
@@ -81,27 +225,37 @@ func (c *Client) ExchangeCodeForToken(code string) (*models.TokenResponse, error
 	return &tokenResp, nil
 }
 
+// setClientSecret adds the client secret to a token request unless running as a public client
+func (c *Client) setClientSecret(tokenData url.Values) {
+	if !c.config.PublicClient {
+		tokenData.Set("client_secret", c.config.ClientSecret)
+	}
+}
+
 // RefreshToken refreshes an access token
-func (c *Client) RefreshToken(refreshToken string) (*models.TokenResponse, error) {
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
 	tokenData := url.Values{}
 	tokenData.Set("client_id", c.config.ClientID)
-	tokenData.Set("client_secret", c.config.ClientSecret)
+	c.setClientSecret(tokenData)
 	tokenData.Set("refresh_token", refreshToken)
 	tokenData.Set("grant_type", "refresh_token")
 
-	req, err := http.NewRequest("POST", c.config.TokenURL, strings.NewReader(tokenData.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.config.TokenURL, strings.NewReader(tokenData.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("error creating refresh token request: %w", err)
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error refreshing token: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, newGraphError(resp)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("error reading refresh token response: %w", err)
@@ -115,58 +269,56 @@ func (c *Client) RefreshToken(refreshToken string) (*models.TokenResponse, error
 	return &tokenResp, nil
 }
 
-// GetTodoLists gets the user's to-do lists
-func (c *Client) GetTodoLists(accessToken string) (*models.TodoListResponse, error) {
-	req, err := http.NewRequest("GET", c.config.GraphURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating API request: %w", err)
-	}
-	req.Header.Add("Authorization", "Bearer "+accessToken)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// GetTodoLists gets all of the user's to-do lists, following pagination
+func (c *Client) GetTodoLists(ctx context.Context, accessToken string) (*models.TodoListResponse, error) {
+	var listResp models.TodoListResponse
+	err := c.walkPages(c.pagedURL(c.config.GraphURL), func(pageURL string) (string, error) {
+		page, err := c.GetTodoListsPage(ctx, accessToken, pageURL)
+		if err != nil {
+			return "", err
+		}
+		listResp.Value = append(listResp.Value, page.Value...)
+		return page.NextLink, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error calling Microsoft Graph API: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Microsoft Graph API returned error: %s - %s", resp.Status, string(body))
-	}
+	return &listResp, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading API response: %w", err)
+// GetTodoListsPage gets a single page of the user's to-do lists.
+// Pass an empty pageURL for the first page, then the NextLink of the previous page.
+func (c *Client) GetTodoListsPage(ctx context.Context, accessToken string, pageURL string) (*models.TodoListResponse, error) {
+	if pageURL == "" {
+		pageURL = c.pagedURL(c.config.GraphURL)
 	}
 
 	var listResp models.TodoListResponse
-	if err := json.Unmarshal(body, &listResp); err != nil {
-		return nil, fmt.Errorf("error parsing API response: %w", err)
+	if err := c.getPage(ctx, accessToken, pageURL, &listResp); err != nil {
+		return nil, err
 	}
 
 	return &listResp, nil
 }
 
 // GetListDetails gets details of a specific to-do list
-func (c *Client) GetListDetails(accessToken string, listID string) (*models.TodoList, error) {
+func (c *Client) GetListDetails(ctx context.Context, accessToken string, listID string) (*models.TodoList, error) {
 	url := fmt.Sprintf("%s/%s", c.config.GraphURL, listID)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating API request: %w", err)
 	}
 	req.Header.Add("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error calling Microsoft Graph API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Microsoft Graph API returned error: %s - %s", resp.Status, string(body))
+		return nil, newGraphError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -182,42 +334,168 @@ func (c *Client) GetListDetails(accessToken string, listID string) (*models.Todo
 	return &list, nil
 }
 
-// GetListTasks gets the tasks for a specific to-do list
-func (c *Client) GetListTasks(accessToken string, listID string) (*models.TaskResponse, error) {
-	url := fmt.Sprintf("%s/%s/tasks", c.config.GraphURL, listID)
-	req, err := http.NewRequest("GET", url, nil)
+// GetListTasks gets all of the tasks for a specific to-do list with their checklist items, following pagination
+func (c *Client) GetListTasks(ctx context.Context, accessToken string, listID string) (*models.TaskResponse, error) {
+	var taskResp models.TaskResponse
+	err := c.walkPages(c.pagedURL(c.tasksURL(listID)+"?"+expandChecklist), func(pageURL string) (string, error) {
+		page, err := c.GetListTasksPage(ctx, accessToken, listID, pageURL)
+		if err != nil {
+			return "", err
+		}
+		taskResp.Value = append(taskResp.Value, page.Value...)
+		return page.NextLink, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error creating API request: %w", err)
+		return nil, err
+	}
+
+	return &taskResp, nil
+}
+
+// GetListTasksPage gets a single page of tasks for a specific to-do list.
+// Pass an empty pageURL for the first page, then the NextLink of the previous page.
+func (c *Client) GetListTasksPage(ctx context.Context, accessToken string, listID string, pageURL string) (*models.TaskResponse, error) {
+	if pageURL == "" {
+		pageURL = c.pagedURL(c.tasksURL(listID) + "?" + expandChecklist)
+	}
+
+	var taskResp models.TaskResponse
+	if err := c.getPage(ctx, accessToken, pageURL, &taskResp); err != nil {
+		return nil, err
+	}
+
+	return &taskResp, nil
+}
+
+// deltaPage is one page of a tasks delta query
+type deltaPage struct {
+	Value []struct {
+		models.Task
+		Removed *struct {
+			Reason string `json:"reason"`
+		} `json:"@removed,omitempty"`
+	} `json:"value"`
+	NextLink  string `json:"@odata.nextLink,omitempty"`
+	DeltaLink string `json:"@odata.deltaLink,omitempty"`
+}
+
+// GetListTasksDelta gets the tasks added, changed or deleted in a list since deltaLink,
+// following every page. Pass an empty deltaLink to start tracking, which returns every task,
+// then the DeltaLink of the previous result. Tasks include their checklist items.
+func (c *Client) GetListTasksDelta(ctx context.Context, accessToken string, listID string, deltaLink string) (*models.TaskDelta, error) {
+	firstURL := deltaLink
+	if firstURL == "" {
+		firstURL = c.tasksURL(listID) + "/delta?" + expandChecklist
+	}
+
+	var delta models.TaskDelta
+	err := c.walkPages(firstURL, func(pageURL string) (string, error) {
+		var page deltaPage
+		if err := c.getPage(ctx, accessToken, pageURL, &page); err != nil {
+			return "", err
+		}
+		for _, item := range page.Value {
+			if item.Removed != nil {
+				delta.Removed = append(delta.Removed, item.ID)
+			} else {
+				delta.Tasks = append(delta.Tasks, item.Task)
+			}
+		}
+		delta.DeltaLink = page.DeltaLink
+		return page.NextLink, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &delta, nil
+}
+
+// expandChecklist is the query that includes checklist items with tasks, so cards can show their progress
+const expandChecklist = "$expand=checklistItems"
+
+// tasksURL returns the tasks collection URL for a list
+func (c *Client) tasksURL(listID string) string {
+	return fmt.Sprintf("%s/%s/tasks", c.config.GraphURL, listID)
+}
+
+// pagedURL adds the configured page size ($top) to a collection URL
+func (c *Client) pagedURL(collectionURL string) string {
+	separator := "?"
+	if strings.Contains(collectionURL, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%s$top=%d", collectionURL, separator, c.pageSize())
+}
+
+// pageSize returns the configured page size or the default
+func (c *Client) pageSize() int {
+	if c.config.PageSize > 0 {
+		return c.config.PageSize
+	}
+	return DefaultPageSize
+}
+
+// maxPages returns the configured page cap or the default
+func (c *Client) maxPages() int {
+	if c.config.MaxPages > 0 {
+		return c.config.MaxPages
+	}
+	return DefaultMaxPages
+}
+
+// walkPages calls fetch for firstURL and then for every @odata.nextLink it returns,
+// stopping with an error if the collection is longer than the configured page cap
+func (c *Client) walkPages(firstURL string, fetch func(pageURL string) (nextLink string, err error)) error {
+	pageURL := firstURL
+	for pages := 0; pageURL != ""; pages++ {
+		if pages >= c.maxPages() {
+			return fmt.Errorf("collection exceeded the maximum of %d pages", c.maxPages())
+		}
+
+		nextLink, err := fetch(pageURL)
+		if err != nil {
+			return err
+		}
+		pageURL = nextLink
+	}
+
+	return nil
+}
+
+// getPage fetches a single page of a collection and decodes it into v
+func (c *Client) getPage(ctx context.Context, accessToken string, pageURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating API request: %w", err)
 	}
 	req.Header.Add("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error calling Microsoft Graph API: %w", err)
+		return fmt.Errorf("error calling Microsoft Graph API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Microsoft Graph API returned error: %s - %s", resp.Status, string(body))
+		return newGraphError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading API response: %w", err)
+		return fmt.Errorf("error reading API response: %w", err)
 	}
 
-	var taskResp models.TaskResponse
-	if err := json.Unmarshal(body, &taskResp); err != nil {
-		return nil, fmt.Errorf("error parsing API response: %w", err)
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("error parsing API response: %w", err)
 	}
 
-	return &taskResp, nil
+	return nil
 }
 
-// UpdateTaskStatus updates a task's status and categories
-func (c *Client) UpdateTaskStatus(accessToken string, listID string, taskID string, status string, categories []string) error {
+// UpdateTaskStatus updates a task's status and categories.
+// If etag is set the update is sent with If-Match and fails with a 412 GraphError if the task has changed since.
+func (c *Client) UpdateTaskStatus(ctx context.Context, accessToken string, listID string, taskID string, etag string, status string, categories []string) error {
 	url := fmt.Sprintf("%s/%s/tasks/%s", c.config.GraphURL, listID, taskID)
 
 	// Prepare the update payload
@@ -231,31 +509,32 @@ func (c *Client) UpdateTaskStatus(accessToken string, listID string, taskID stri
 		return fmt.Errorf("error marshaling task update: %w", err)
 	}
 
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("error creating API request: %w", err)
 	}
 
 	req.Header.Add("Authorization", "Bearer "+accessToken)
 	req.Header.Add("Content-Type", "application/json")
+	if etag != "" {
+		req.Header.Add("If-Match", etag)
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("error updating task: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Microsoft Graph API returned error: %s - %s", resp.Status, string(body))
+		return newGraphError(resp)
 	}
 
 	return nil
 }
 
-// UpdateTaskImportance updates a task's importance
-func (c *Client) UpdateTaskImportance(accessToken string, listID string, taskID string, importance string) error {
+// UpdateTaskImportance updates a task's importance; etag works as in UpdateTaskStatus
+func (c *Client) UpdateTaskImportance(ctx context.Context, accessToken string, listID string, taskID string, etag string, importance string) error {
 	url := fmt.Sprintf("%s/%s/tasks/%s", c.config.GraphURL, listID, taskID)
 
 	// Prepare the update payload
@@ -268,89 +547,115 @@ func (c *Client) UpdateTaskImportance(accessToken string, listID string, taskID
 		return fmt.Errorf("error marshaling task update: %w", err)
 	}
 
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("error creating API request: %w", err)
 	}
 
 	req.Header.Add("Authorization", "Bearer "+accessToken)
 	req.Header.Add("Content-Type", "application/json")
+	if etag != "" {
+		req.Header.Add("If-Match", etag)
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("error updating task: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Microsoft Graph API returned error: %s - %s", resp.Status, string(body))
+		return newGraphError(resp)
 	}
 
 	return nil
 }
 
 // GetTaskDetails retrieves details for a specific task
-func (c *Client) GetTaskDetails(accessToken string, listID string, taskID string) (*models.Task, error) {
-	url := fmt.Sprintf("%s/%s/tasks/%s", c.config.GraphURL, listID, taskID)
-	req, err := http.NewRequest("GET", url, nil)
+func (c *Client) GetTaskDetails(ctx context.Context, accessToken string, listID string, taskID string) (*models.Task, error) {
+	task, _, err := c.getTask(ctx, accessToken, listID, taskID, "")
+	return task, err
+}
+
+// getTask gets a single task with its checklist items. If etag is set it is sent as If-None-Match,
+// and notModified is true when Graph reports the task is unchanged.
+func (c *Client) getTask(ctx context.Context, accessToken string, listID string, taskID string, etag string) (task *models.Task, notModified bool, err error) {
+	url := fmt.Sprintf("%s/%s/tasks/%s?%s", c.config.GraphURL, listID, taskID, expandChecklist)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("error creating API request: %w", err)
+		return nil, false, fmt.Errorf("error creating API request: %w", err)
 	}
 	req.Header.Add("Authorization", "Bearer "+accessToken)
+	if etag != "" {
+		req.Header.Add("If-None-Match", etag)
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error calling Microsoft Graph API: %w", err)
+		return nil, false, fmt.Errorf("error calling Microsoft Graph API: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if etag != "" && resp.StatusCode == http.StatusNotModified {
+		return nil, true, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Microsoft Graph API returned error: %s - %s", resp.Status, string(body))
+		return nil, false, newGraphError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading API response: %w", err)
+		return nil, false, fmt.Errorf("error reading API response: %w", err)
 	}
 
-	var task models.Task
 	if err := json.Unmarshal(body, &task); err != nil {
-		return nil, fmt.Errorf("error parsing API response: %w", err)
+		return nil, false, fmt.Errorf("error parsing API response: %w", err)
 	}
 
-	return &task, nil
+	return task, false, nil
 }
 
-// UpdateTaskDetails updates a task's details
-func (c *Client) UpdateTaskDetails(accessToken string, listID string, taskID string, title string, status string, importance string, dueDate string, categories []string) error {
+// UpdateTaskDetails updates a task's details; etag works as in UpdateTaskStatus
+func (c *Client) UpdateTaskDetails(ctx context.Context, accessToken string, listID string, taskID string, etag string, update models.TaskUpdate) error {
 	url := fmt.Sprintf("%s/%s/tasks/%s", c.config.GraphURL, listID, taskID)
 
 	// Build the request body
 	requestBody := make(map[string]interface{})
-	requestBody["title"] = title
-	requestBody["importance"] = importance
+	requestBody["title"] = update.Title
+	requestBody["importance"] = update.Importance
 
-	// Handle status
-	if status == "completed" {
-		requestBody["status"] = "completed"
-	} else {
+	// Handle status, sent as given so statuses such as waitingOnOthers are kept
+	requestBody["status"] = update.Status
+	if update.Status == "" {
 		requestBody["status"] = "notStarted"
 	}
 
-	// Handle due date
-	if dueDate != "" {
-		requestBody["dueDateTime"] = map[string]string{
-			"dateTime": dueDate + "T00:00:00Z",
-			"timeZone": "UTC",
+	// Handle dates; an empty date is sent as null, which clears it
+	requestBody["dueDateTime"] = models.NewDate(update.DueDate)
+	requestBody["startDateTime"] = models.NewDate(update.StartDate)
+
+	// Handle the reminder
+	requestBody["isReminderOn"] = update.ReminderOn
+	if update.ReminderOn {
+		requestBody["reminderDateTime"] = models.NewDateTime(update.Reminder)
+	}
+
+	// Replace the notes only if they were edited, so HTML notes written elsewhere survive other edits
+	if update.Body != nil {
+		requestBody["body"] = models.ItemBody{Content: *update.Body, ContentType: "text"}
+	}
+
+	// Replace the recurrence only if it was edited; an empty pattern is sent as null, which stops it
+	if update.Recurrence != nil {
+		if update.Recurrence.Pattern.Type == "" {
+			requestBody["recurrence"] = nil
+		} else {
+			requestBody["recurrence"] = update.Recurrence
 		}
 	}
 
 	// Add categories
-	requestBody["categories"] = categories
+	requestBody["categories"] = update.Categories
 
 	// Convert to JSON
 	jsonData, err := json.Marshal(requestBody)
@@ -359,30 +664,31 @@ func (c *Client) UpdateTaskDetails(accessToken string, listID string, taskID str
 	}
 
 	// Create PATCH request
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("error creating API request: %w", err)
 	}
 	req.Header.Add("Authorization", "Bearer "+accessToken)
 	req.Header.Add("Content-Type", "application/json")
+	if etag != "" {
+		req.Header.Add("If-Match", etag)
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("error calling Microsoft Graph API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Microsoft Graph API returned error: %s - %s", resp.Status, string(body))
+		return newGraphError(resp)
 	}
 
 	return nil
 }
 
 // CreateTask creates a new task in a list
-func (c *Client) CreateTask(accessToken string, listID string, title string) (string, error) {
+func (c *Client) CreateTask(ctx context.Context, accessToken string, listID string, title string) (string, error) {
 	url := fmt.Sprintf("%s/%s/tasks", c.config.GraphURL, listID)
 
 	// Build the request body with minimal required fields
@@ -397,23 +703,21 @@ func (c *Client) CreateTask(accessToken string, listID string, title string) (st
 	}
 
 	// Create POST request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("error creating API request: %w", err)
 	}
 	req.Header.Add("Authorization", "Bearer "+accessToken)
 	req.Header.Add("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("error calling Microsoft Graph API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Microsoft Graph API returned error: %s - %s", resp.Status, string(body))
+		return "", newGraphError(resp)
 	}
 
 	// Read the response body
@@ -431,27 +735,80 @@ func (c *Client) CreateTask(accessToken string, listID string, title string) (st
 	return task.ID, nil
 }
 
-// DeleteTask deletes a task from a list
-func (c *Client) DeleteTask(accessToken string, listID string, taskID string) error {
+// CopyTask creates a task in a list with another task's fields, then adds its checklist items.
+// If an item can't be added the new task is deleted again, so the copy is all or nothing.
+func (c *Client) CopyTask(ctx context.Context, accessToken string, listID string, task models.Task) (*models.Task, error) {
+	payload := map[string]interface{}{
+		"title":        task.Title,
+		"status":       task.Status,
+		"importance":   task.Importance,
+		"isReminderOn": task.IsReminderOn,
+	}
+	if len(task.Categories) > 0 {
+		payload["categories"] = task.Categories
+	}
+	if task.Body != nil {
+		payload["body"] = task.Body
+	}
+	if task.Recurrence != nil {
+		payload["recurrence"] = task.Recurrence
+	}
+	for name, value := range map[string]*models.DateTime{
+		"dueDateTime":       task.DueDateTime,
+		"startDateTime":     task.StartDateTime,
+		"reminderDateTime":  task.ReminderDateTime,
+		"completedDateTime": task.CompletedDateTime,
+	} {
+		if value != nil {
+			payload[name] = value
+		}
+	}
+
+	var created models.Task
+	if err := c.postJSON(ctx, accessToken, c.tasksURL(listID), payload, &created); err != nil {
+		return nil, err
+	}
+
+	for _, item := range task.ChecklistItems {
+		var copied models.ChecklistItem
+		err := c.postJSON(ctx, accessToken, c.checklistURL(listID, created.ID), map[string]interface{}{
+			"displayName": item.DisplayName,
+			"isChecked":   item.IsChecked,
+		}, &copied)
+		if err != nil {
+			if deleteErr := c.DeleteTask(ctx, accessToken, listID, created.ID, ""); deleteErr != nil {
+				return nil, fmt.Errorf("%w (and the partial copy %s could not be deleted: %v)", err, created.ID, deleteErr)
+			}
+			return nil, err
+		}
+		created.ChecklistItems = append(created.ChecklistItems, copied)
+	}
+
+	return &created, nil
+}
+
+// DeleteTask deletes a task from a list; etag works as in UpdateTaskStatus
+func (c *Client) DeleteTask(ctx context.Context, accessToken string, listID string, taskID string, etag string) error {
 	url := fmt.Sprintf("%s/%s/tasks/%s", c.config.GraphURL, listID, taskID)
 
 	// Create DELETE request
-	req, err := http.NewRequest("DELETE", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return fmt.Errorf("error creating API request: %w", err)
 	}
 	req.Header.Add("Authorization", "Bearer "+accessToken)
+	if etag != "" {
+		req.Header.Add("If-Match", etag)
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("error calling Microsoft Graph API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Microsoft Graph API returned error: %s - %s", resp.Status, string(body))
+		return newGraphError(resp)
 	}
 
 	return nil
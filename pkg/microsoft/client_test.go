@@ -0,0 +1,952 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package microsoft_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/pkg/microsoft"
+	"github.com/coseguera/kanban-to-do/pkg/microsoft/graphtest"
+)
+
+// newTestClient starts a fake Graph server and a client pointed at it
+func newTestClient(t *testing.T) (*graphtest.Server, *microsoft.Client) {
+	t.Helper()
+
+	server := graphtest.NewServer()
+	t.Cleanup(server.Close)
+
+	return server, microsoft.NewClient(server.Config("https://localhost/auth/callback"))
+}
+
+func TestGetAuthURL(t *testing.T) {
+	server, client := newTestClient(t)
+
+	authURL, err := url.Parse(client.GetAuthURL("state 1", "challenge"))
+	if err != nil {
+		t.Fatalf("GetAuthURL returned an invalid URL: %v", err)
+	}
+
+	query := authURL.Query()
+	if !strings.HasPrefix(authURL.String(), server.URL+"/authorize?") {
+		t.Errorf("auth URL = %s, want it to start with %s/authorize", authURL, server.URL)
+	}
+	for key, want := range map[string]string{
+		"client_id":             graphtest.ClientID,
+		"state":                 "state 1",
+		"code_challenge":        "challenge",
+		"code_challenge_method": "S256",
+		"redirect_uri":          "https://localhost/auth/callback",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestExchangeCodeForToken(t *testing.T) {
+	server, client := newTestClient(t)
+	server.RequireSecret = true
+
+	tokenResp, err := client.ExchangeCodeForToken(context.Background(), graphtest.AuthCode, "verifier")
+	if err != nil {
+		t.Fatalf("ExchangeCodeForToken failed: %v", err)
+	}
+	if tokenResp.AccessToken != graphtest.AccessToken || tokenResp.RefreshToken != graphtest.RefreshToken {
+		t.Errorf("unexpected token response: %+v", tokenResp)
+	}
+
+	requests := server.Requests()
+	form, _ := url.ParseQuery(requests[len(requests)-1].Body)
+	if form.Get("code_verifier") != "verifier" {
+		t.Errorf("code_verifier = %q, want %q", form.Get("code_verifier"), "verifier")
+	}
+}
+
+func TestExchangeCodeForTokenRejected(t *testing.T) {
+	_, client := newTestClient(t)
+
+	_, err := client.ExchangeCodeForToken(context.Background(), "wrong-code", "verifier")
+
+	var graphErr *microsoft.GraphError
+	if !errors.As(err, &graphErr) {
+		t.Fatalf("error = %v, want a *GraphError", err)
+	}
+	if graphErr.StatusCode != http.StatusBadRequest || graphErr.Code != "invalid_grant" {
+		t.Errorf("unexpected error: %+v", graphErr)
+	}
+}
+
+func TestPublicClientOmitsSecret(t *testing.T) {
+	server := graphtest.NewServer()
+	defer server.Close()
+
+	config := server.Config("https://localhost/auth/callback")
+	config.ClientSecret = ""
+	config.PublicClient = true
+	client := microsoft.NewClient(config)
+
+	if _, err := client.RefreshToken(context.Background(), graphtest.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken failed: %v", err)
+	}
+
+	requests := server.Requests()
+	form, _ := url.ParseQuery(requests[len(requests)-1].Body)
+	if _, ok := form["client_secret"]; ok {
+		t.Errorf("public client sent a client_secret")
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	_, client := newTestClient(t)
+
+	tokenResp, err := client.RefreshToken(context.Background(), graphtest.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken failed: %v", err)
+	}
+	if tokenResp.AccessToken != graphtest.AccessToken {
+		t.Errorf("AccessToken = %q, want %q", tokenResp.AccessToken, graphtest.AccessToken)
+	}
+
+	if _, err := client.RefreshToken(context.Background(), "expired"); err == nil {
+		t.Errorf("RefreshToken with an unknown token succeeded")
+	}
+}
+
+func TestGetTodoListsFollowsPagination(t *testing.T) {
+	server, client := newTestClient(t)
+	for i := 0; i < 25; i++ {
+		server.AddList(fmt.Sprintf("List %d", i))
+	}
+
+	lists, err := client.GetTodoLists(context.Background(), graphtest.AccessToken)
+	if err != nil {
+		t.Fatalf("GetTodoLists failed: %v", err)
+	}
+	if len(lists.Value) != 25 {
+		t.Fatalf("got %d lists, want 25", len(lists.Value))
+	}
+	if lists.Value[24].DisplayName != "List 24" {
+		t.Errorf("last list = %q, want %q", lists.Value[24].DisplayName, "List 24")
+	}
+}
+
+func TestGetListTasksFollowsPagination(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	for i := 0; i < 23; i++ {
+		server.AddTask(list.ID, models.Task{Title: fmt.Sprintf("Task %d", i)})
+	}
+
+	tasks, err := client.GetListTasks(context.Background(), graphtest.AccessToken, list.ID)
+	if err != nil {
+		t.Fatalf("GetListTasks failed: %v", err)
+	}
+	if len(tasks.Value) != 23 {
+		t.Fatalf("got %d tasks, want 23", len(tasks.Value))
+	}
+	if tasks.NextLink != "" {
+		t.Errorf("NextLink = %q, want empty after collecting every page", tasks.NextLink)
+	}
+}
+
+func TestGetListTasksPageStreamsPages(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	for i := 0; i < 15; i++ {
+		server.AddTask(list.ID, models.Task{Title: fmt.Sprintf("Task %d", i)})
+	}
+
+	first, err := client.GetListTasksPage(context.Background(), graphtest.AccessToken, list.ID, "")
+	if err != nil {
+		t.Fatalf("GetListTasksPage failed: %v", err)
+	}
+	if len(first.Value) != 10 || first.NextLink == "" {
+		t.Fatalf("first page has %d tasks and nextLink %q, want 10 and a link", len(first.Value), first.NextLink)
+	}
+
+	second, err := client.GetListTasksPage(context.Background(), graphtest.AccessToken, list.ID, first.NextLink)
+	if err != nil {
+		t.Fatalf("GetListTasksPage failed: %v", err)
+	}
+	if len(second.Value) != 5 || second.NextLink != "" {
+		t.Errorf("second page has %d tasks and nextLink %q, want 5 and none", len(second.Value), second.NextLink)
+	}
+}
+
+func TestPageSizeAndMaxPages(t *testing.T) {
+	server := graphtest.NewServer()
+	defer server.Close()
+
+	list := server.AddList("Work")
+	for i := 0; i < 9; i++ {
+		server.AddTask(list.ID, models.Task{Title: fmt.Sprintf("Task %d", i)})
+	}
+
+	config := server.Config("https://localhost/auth/callback")
+	config.PageSize = 2
+	config.MaxPages = 3
+	client := microsoft.NewClient(config)
+
+	if _, err := client.GetListTasks(context.Background(), graphtest.AccessToken, list.ID); err == nil {
+		t.Fatalf("GetListTasks succeeded past the page cap")
+	}
+	if got := server.Requests()[0].Query.Get("$top"); got != "2" {
+		t.Errorf("$top = %q, want %q", got, "2")
+	}
+}
+
+func TestRetriesThrottledRequests(t *testing.T) {
+	server, client := newTestClient(t)
+	server.AddList("Work")
+	server.FailNext(2, http.StatusTooManyRequests, "")
+
+	lists, err := client.GetTodoLists(context.Background(), graphtest.AccessToken)
+	if err != nil {
+		t.Fatalf("GetTodoLists failed after retries: %v", err)
+	}
+	if len(lists.Value) != 1 {
+		t.Errorf("got %d lists, want 1", len(lists.Value))
+	}
+	if n := len(server.Requests()); n != 3 {
+		t.Errorf("server saw %d requests, want 3", n)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	server, client := newTestClient(t)
+	server.AddList("Work")
+	server.FailNext(1, http.StatusServiceUnavailable, "1")
+
+	start := time.Now()
+	if _, err := client.GetTodoLists(context.Background(), graphtest.AccessToken); err != nil {
+		t.Fatalf("GetTodoLists failed after retry: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least the 1s Retry-After", elapsed)
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	server, client := newTestClient(t)
+	server.FailNext(10, http.StatusGatewayTimeout, "")
+
+	_, err := client.GetTodoLists(context.Background(), graphtest.AccessToken)
+
+	var graphErr *microsoft.GraphError
+	if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("error = %v, want a 504 GraphError", err)
+	}
+	if n := len(server.Requests()); n != microsoft.DefaultMaxRetries+1 {
+		t.Errorf("server saw %d requests, want %d", n, microsoft.DefaultMaxRetries+1)
+	}
+}
+
+func TestRetryStopsWhenContextCancelled(t *testing.T) {
+	server, client := newTestClient(t)
+	server.FailNext(1, http.StatusTooManyRequests, "30")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetTodoLists(ctx, graphtest.AccessToken)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestUnauthorizedReturnsGraphError(t *testing.T) {
+	_, client := newTestClient(t)
+
+	_, err := client.GetTodoLists(context.Background(), "bad-token")
+
+	var graphErr *microsoft.GraphError
+	if !errors.As(err, &graphErr) {
+		t.Fatalf("error = %v, want a *GraphError", err)
+	}
+	if graphErr.StatusCode != http.StatusUnauthorized || graphErr.Code != "InvalidAuthenticationToken" || graphErr.RequestID == "" {
+		t.Errorf("unexpected error: %+v", graphErr)
+	}
+}
+
+func TestGetListDetails(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+
+	got, err := client.GetListDetails(context.Background(), graphtest.AccessToken, list.ID)
+	if err != nil {
+		t.Fatalf("GetListDetails failed: %v", err)
+	}
+	if got.DisplayName != "Work" {
+		t.Errorf("DisplayName = %q, want %q", got.DisplayName, "Work")
+	}
+
+	_, err = client.GetListDetails(context.Background(), graphtest.AccessToken, "missing")
+	var graphErr *microsoft.GraphError
+	if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusNotFound {
+		t.Errorf("error = %v, want a 404 GraphError", err)
+	}
+}
+
+func TestListLifecycle(t *testing.T) {
+	server, client := newTestClient(t)
+	ctx := context.Background()
+
+	// Create
+	list, err := client.CreateList(ctx, graphtest.AccessToken, "Groceries")
+	if err != nil {
+		t.Fatalf("CreateList failed: %v", err)
+	}
+	if list.ID == "" || list.DisplayName != "Groceries" || !list.IsOwner || list.IsShared || list.IsWellknown() {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	// Rename
+	if err := client.RenameList(ctx, graphtest.AccessToken, list.ID, "Shopping"); err != nil {
+		t.Fatalf("RenameList failed: %v", err)
+	}
+	if got, _ := client.GetListDetails(ctx, graphtest.AccessToken, list.ID); got.DisplayName != "Shopping" {
+		t.Errorf("DisplayName = %q after rename, want Shopping", got.DisplayName)
+	}
+
+	// Built-in lists are refused
+	tasks := server.AddList("Tasks")
+	tasks.WellknownListName = models.WellknownListDefault
+	server.SetList(tasks)
+	var graphErr *microsoft.GraphError
+	if err := client.RenameList(ctx, graphtest.AccessToken, tasks.ID, "Inbox"); !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("renaming a built-in list: error = %v, want a 400 GraphError", err)
+	}
+	if err := client.DeleteList(ctx, graphtest.AccessToken, tasks.ID); !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("deleting a built-in list: error = %v, want a 400 GraphError", err)
+	}
+
+	// Delete, through the cache so the list's tasks are dropped too
+	cache := microsoft.NewCachingClient(client)
+	task := server.AddTask(list.ID, models.Task{Title: "Milk"})
+	if _, err := cache.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID); err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+	if err := cache.DeleteList(ctx, graphtest.AccessToken, list.ID); err != nil {
+		t.Fatalf("DeleteList failed: %v", err)
+	}
+	if _, err := cache.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID); err == nil {
+		t.Errorf("task of a deleted list is still served from the cache")
+	}
+	lists, err := client.GetTodoLists(ctx, graphtest.AccessToken)
+	if err != nil {
+		t.Fatalf("GetTodoLists failed: %v", err)
+	}
+	if len(lists.Value) != 1 || lists.Value[0].ID != tasks.ID || lists.Value[0].WellknownListName != models.WellknownListDefault {
+		t.Errorf("unexpected lists after delete: %+v", lists.Value)
+	}
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	ctx := context.Background()
+
+	// Create
+	taskID, err := client.CreateTask(ctx, graphtest.AccessToken, list.ID, "Write tests")
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	// Read
+	task, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, taskID)
+	if err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+	if task.Title != "Write tests" || task.Status != "notStarted" {
+		t.Errorf("unexpected task: %+v", task)
+	}
+
+	// Update status and categories
+	if err := client.UpdateTaskStatus(ctx, graphtest.AccessToken, list.ID, taskID, "", "notStarted", []string{"Doing"}); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+	if stored, _ := server.Task(list.ID, taskID); len(stored.Categories) != 1 || stored.Categories[0] != "Doing" {
+		t.Errorf("categories = %v, want [Doing]", stored.Categories)
+	}
+
+	// Update importance
+	if err := client.UpdateTaskImportance(ctx, graphtest.AccessToken, list.ID, taskID, "", "high"); err != nil {
+		t.Fatalf("UpdateTaskImportance failed: %v", err)
+	}
+	if stored, _ := server.Task(list.ID, taskID); stored.Importance != "high" {
+		t.Errorf("importance = %q, want high", stored.Importance)
+	}
+
+	// Update details
+	notes := "Cover the cache too"
+	reminder := time.Date(2025, 5, 31, 9, 30, 0, 0, time.UTC)
+	err = client.UpdateTaskDetails(ctx, graphtest.AccessToken, list.ID, taskID, "", models.TaskUpdate{
+		Title:      "Write more tests",
+		Status:     "completed",
+		Importance: "normal",
+		DueDate:    "2025-06-01",
+		StartDate:  "2025-05-20",
+		Categories: []string{"Tests"},
+		Body:       &notes,
+		ReminderOn: true,
+		Reminder:   reminder,
+	})
+	if err != nil {
+		t.Fatalf("UpdateTaskDetails failed: %v", err)
+	}
+	stored, _ := server.Task(list.ID, taskID)
+	if stored.Title != "Write more tests" || stored.Status != "completed" || stored.Importance != "normal" {
+		t.Errorf("unexpected task after update: %+v", stored)
+	}
+	if stored.DueDateTime == nil || stored.DueDateTime.DateTime != "2025-06-01T00:00:00Z" {
+		t.Errorf("dueDateTime = %+v, want 2025-06-01", stored.DueDateTime)
+	}
+	if stored.StartDateTime == nil || stored.StartDateTime.DateTime != "2025-05-20T00:00:00Z" {
+		t.Errorf("startDateTime = %+v, want 2025-05-20", stored.StartDateTime)
+	}
+	if stored.Body == nil || stored.Body.Content != notes || stored.Body.ContentType != "text" {
+		t.Errorf("body = %+v, want %q", stored.Body, notes)
+	}
+	if !stored.IsReminderOn || stored.ReminderDateTime == nil {
+		t.Fatalf("reminder not set: %+v", stored)
+	}
+	if got, err := stored.ReminderDateTime.Time(); err != nil || !got.Equal(reminder) {
+		t.Errorf("reminderDateTime = %v (%v), want %v", got, err, reminder)
+	}
+	if stored.CompletedDateTime == nil {
+		t.Errorf("completedDateTime not set on a completed task")
+	}
+
+	// Clearing dates and leaving the notes alone
+	err = client.UpdateTaskDetails(ctx, graphtest.AccessToken, list.ID, taskID, "", models.TaskUpdate{
+		Title:      "Write more tests",
+		Status:     "notStarted",
+		Importance: "normal",
+	})
+	if err != nil {
+		t.Fatalf("UpdateTaskDetails failed: %v", err)
+	}
+	stored, _ = server.Task(list.ID, taskID)
+	if stored.DueDateTime != nil || stored.StartDateTime != nil || stored.CompletedDateTime != nil || stored.IsReminderOn {
+		t.Errorf("dates or reminder not cleared: %+v", stored)
+	}
+	if stored.Body == nil || stored.Body.Content != notes {
+		t.Errorf("body = %+v, want it unchanged", stored.Body)
+	}
+
+	// Delete, but not a version that has changed since
+	err = client.DeleteTask(ctx, graphtest.AccessToken, list.ID, taskID, `W/"stale"`)
+	var graphErr *microsoft.GraphError
+	if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("deleting with a stale etag: error = %v, want a 412 GraphError", err)
+	}
+	stored, _ = server.Task(list.ID, taskID)
+	if err := client.DeleteTask(ctx, graphtest.AccessToken, list.ID, taskID, stored.ETag); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if _, ok := server.Task(list.ID, taskID); ok {
+		t.Errorf("task still exists after DeleteTask")
+	}
+	if err := client.DeleteTask(ctx, graphtest.AccessToken, list.ID, taskID, ""); err == nil {
+		t.Errorf("deleting a missing task succeeded")
+	}
+}
+
+func TestChecklistItems(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Release"})
+	ctx := context.Background()
+
+	// Create
+	for _, name := range []string{"Tag", "Build", "Publish"} {
+		if _, err := client.CreateChecklistItem(ctx, graphtest.AccessToken, list.ID, task.ID, name); err != nil {
+			t.Fatalf("CreateChecklistItem failed: %v", err)
+		}
+	}
+
+	// List, across pages
+	server.MaxPageSize = 2
+	items, err := client.GetChecklistItems(ctx, graphtest.AccessToken, list.ID, task.ID)
+	if err != nil {
+		t.Fatalf("GetChecklistItems failed: %v", err)
+	}
+	if len(items) != 3 || items[0].DisplayName != "Tag" || items[0].IsChecked {
+		t.Fatalf("unexpected checklist: %+v", items)
+	}
+
+	// Rename, check and delete
+	if err := client.UpdateChecklistItem(ctx, graphtest.AccessToken, list.ID, task.ID, items[1].ID, "Build and sign"); err != nil {
+		t.Fatalf("UpdateChecklistItem failed: %v", err)
+	}
+	if err := client.SetChecklistItemChecked(ctx, graphtest.AccessToken, list.ID, task.ID, items[0].ID, true); err != nil {
+		t.Fatalf("SetChecklistItemChecked failed: %v", err)
+	}
+	if err := client.DeleteChecklistItem(ctx, graphtest.AccessToken, list.ID, task.ID, items[2].ID); err != nil {
+		t.Fatalf("DeleteChecklistItem failed: %v", err)
+	}
+	if err := client.DeleteChecklistItem(ctx, graphtest.AccessToken, list.ID, task.ID, items[2].ID); err == nil {
+		t.Errorf("deleting a missing checklist item succeeded")
+	}
+
+	// Tasks come with their checklist
+	tasks, err := client.GetListTasks(ctx, graphtest.AccessToken, list.ID)
+	if err != nil {
+		t.Fatalf("GetListTasks failed: %v", err)
+	}
+	got := tasks.Value[0].ChecklistItems
+	if len(got) != 2 || !got[0].IsChecked || got[0].CheckedDateTime == "" || got[1].DisplayName != "Build and sign" || got[1].IsChecked {
+		t.Errorf("unexpected checklist after updates: %+v", got)
+	}
+	delta, err := client.GetListTasksDelta(ctx, graphtest.AccessToken, list.ID, "")
+	if err != nil {
+		t.Fatalf("GetListTasksDelta failed: %v", err)
+	}
+	if len(delta.Tasks) != 1 || len(delta.Tasks[0].ChecklistItems) != 2 {
+		t.Errorf("delta tasks lack their checklist: %+v", delta.Tasks)
+	}
+	details, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID)
+	if err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+	if len(details.ChecklistItems) != 2 {
+		t.Errorf("task details lack the checklist: %+v", details)
+	}
+}
+
+func TestCopyTask(t *testing.T) {
+	server, client := newTestClient(t)
+	work := server.AddList("Work")
+	home := server.AddList("Home")
+	task := server.AddTask(work.ID, models.Task{
+		Title:          "Fix the fence",
+		Status:         "completed",
+		Body:           &models.ItemBody{Content: "<p>Buy nails</p>", ContentType: "html"},
+		DueDateTime:    models.NewDate("2025-06-02"),
+		ChecklistItems: []models.ChecklistItem{{DisplayName: "Measure", IsChecked: true}, {DisplayName: "Paint"}},
+	})
+	ctx := context.Background()
+
+	copied, err := client.CopyTask(ctx, graphtest.AccessToken, home.ID, task)
+	if err != nil {
+		t.Fatalf("CopyTask failed: %v", err)
+	}
+	if copied.ID == "" || copied.ID == task.ID || copied.Status != "completed" || len(copied.ChecklistItems) != 2 {
+		t.Errorf("unexpected copy: %+v", copied)
+	}
+	stored, ok := server.Task(home.ID, copied.ID)
+	if !ok || stored.Body == nil || stored.Body.ContentType != "html" || stored.DueDateTime == nil {
+		t.Errorf("fields not copied: %+v", stored)
+	}
+	if len(stored.ChecklistItems) != 2 || !stored.ChecklistItems[0].IsChecked || stored.ChecklistItems[1].DisplayName != "Paint" {
+		t.Errorf("checklist not copied: %+v", stored.ChecklistItems)
+	}
+
+	// A copy whose checklist can't be added is removed again
+	server.FailOn(http.MethodPost, "/checklistItems", http.StatusForbidden)
+	if _, err := client.CopyTask(ctx, graphtest.AccessToken, home.ID, task); err == nil {
+		t.Errorf("CopyTask succeeded without its checklist")
+	}
+	if tasks := server.Tasks(home.ID); len(tasks) != 1 {
+		t.Errorf("got %d tasks after a failed copy, want 1", len(tasks))
+	}
+}
+
+func TestLinkedResources(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Review"})
+	ctx := context.Background()
+
+	link, err := client.CreateLinkedResource(ctx, graphtest.AccessToken, list.ID, task.ID, models.LinkedResource{
+		WebURL:          "https://github.com/coseguera/kanban-to-do/pull/1",
+		ApplicationName: "GitHub",
+		DisplayName:     "PR #1",
+	})
+	if err != nil {
+		t.Fatalf("CreateLinkedResource failed: %v", err)
+	}
+	if link.ID == "" || link.DisplayName != "PR #1" {
+		t.Fatalf("unexpected linked resource: %+v", link)
+	}
+
+	links, err := client.GetLinkedResources(ctx, graphtest.AccessToken, list.ID, task.ID)
+	if err != nil {
+		t.Fatalf("GetLinkedResources failed: %v", err)
+	}
+	if len(links) != 1 || links[0].WebURL != "https://github.com/coseguera/kanban-to-do/pull/1" || links[0].ApplicationName != "GitHub" {
+		t.Fatalf("unexpected linked resources: %+v", links)
+	}
+
+	if err := client.DeleteLinkedResource(ctx, graphtest.AccessToken, list.ID, task.ID, link.ID); err != nil {
+		t.Fatalf("DeleteLinkedResource failed: %v", err)
+	}
+	if links, _ := client.GetLinkedResources(ctx, graphtest.AccessToken, list.ID, task.ID); len(links) != 0 {
+		t.Errorf("linked resource was not deleted: %+v", links)
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Spec"})
+	ctx := context.Background()
+
+	// Small files are sent inline
+	small, err := client.AddAttachment(ctx, graphtest.AccessToken, list.ID, task.ID, "notes.txt", "text/plain", []byte("hello"))
+	if err != nil {
+		t.Fatalf("AddAttachment failed: %v", err)
+	}
+	if small.ID == "" || small.Size != 5 || small.ContentBytes != nil {
+		t.Fatalf("unexpected attachment: %+v", small)
+	}
+
+	// Large files go through an upload session, in more than one range
+	large := bytes.Repeat([]byte("0123456789abcdef"), (microsoft.MaxInlineAttachmentSize+1<<20)/16)
+	uploaded, err := client.AddAttachment(ctx, graphtest.AccessToken, list.ID, task.ID, "design.pdf", "application/pdf", large)
+	if err != nil {
+		t.Fatalf("AddAttachment with upload session failed: %v", err)
+	}
+	if uploaded.ID == "" {
+		t.Fatalf("uploaded attachment has no ID: %+v", uploaded)
+	}
+	ranges := 0
+	for _, req := range server.Requests() {
+		if req.Method == "PUT" {
+			ranges++
+			if req.Header.Get("Authorization") != "" {
+				t.Errorf("upload range sent an access token")
+			}
+		}
+	}
+	if ranges < 2 {
+		t.Errorf("expected the upload in several ranges, got %d", ranges)
+	}
+
+	// Listing leaves out the content, reading one includes it
+	attachments, err := client.GetAttachments(ctx, graphtest.AccessToken, list.ID, task.ID)
+	if err != nil {
+		t.Fatalf("GetAttachments failed: %v", err)
+	}
+	if len(attachments) != 2 || attachments[1].Name != "design.pdf" || attachments[1].Size != int64(len(large)) || attachments[1].ContentBytes != nil {
+		t.Fatalf("unexpected attachments: %+v", attachments)
+	}
+	got, err := client.GetAttachment(ctx, graphtest.AccessToken, list.ID, task.ID, uploaded.ID)
+	if err != nil {
+		t.Fatalf("GetAttachment failed: %v", err)
+	}
+	if !bytes.Equal(got.ContentBytes, large) || got.ContentType != "application/pdf" {
+		t.Errorf("uploaded content does not round trip")
+	}
+
+	details, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID)
+	if err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+	if !details.HasAttachments {
+		t.Errorf("task does not report attachments")
+	}
+
+	for _, attachment := range attachments {
+		if err := client.DeleteAttachment(ctx, graphtest.AccessToken, list.ID, task.ID, attachment.ID); err != nil {
+			t.Fatalf("DeleteAttachment failed: %v", err)
+		}
+	}
+	details, _ = client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID)
+	if details.HasAttachments {
+		t.Errorf("task still reports attachments after deleting them")
+	}
+}
+
+func TestRecurringTasks(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Chores")
+	task := server.AddTask(list.ID, models.Task{Title: "Water plants", DueDateTime: models.NewDate("2025-06-02")})
+	ctx := context.Background()
+
+	// Make it repeat weekly
+	weekly := &models.PatternedRecurrence{
+		Pattern: models.RecurrencePattern{Type: models.RecurrenceWeekly, Interval: 1, DaysOfWeek: []string{"monday"}, FirstDayOfWeek: "sunday"},
+		Range:   models.RecurrenceRange{Type: models.RecurrenceNoEnd, StartDate: "2025-06-02"},
+	}
+	update := models.TaskUpdate{Title: task.Title, Status: "notStarted", Importance: "normal", DueDate: "2025-06-02", Recurrence: weekly}
+	if err := client.UpdateTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID, "", update); err != nil {
+		t.Fatalf("UpdateTaskDetails failed: %v", err)
+	}
+	details, err := client.GetTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID)
+	if err != nil {
+		t.Fatalf("GetTaskDetails failed: %v", err)
+	}
+	if details.Recurrence == nil || details.Recurrence.Pattern.Type != models.RecurrenceWeekly {
+		t.Fatalf("recurrence not saved: %+v", details.Recurrence)
+	}
+
+	// Completing it adds the next occurrence
+	if err := client.UpdateTaskStatus(ctx, graphtest.AccessToken, list.ID, task.ID, "", "completed", nil); err != nil {
+		t.Fatalf("UpdateTaskStatus failed: %v", err)
+	}
+	tasks := server.Tasks(list.ID)
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks after completing a recurring task, want 2", len(tasks))
+	}
+	var nextID string
+	for _, stored := range tasks {
+		if stored.ID == task.ID {
+			continue
+		}
+		nextID = stored.ID
+		if stored.Status != "notStarted" || stored.Recurrence == nil || stored.DueDateTime == nil || stored.DueDateTime.DateTime[:10] != "2025-06-09" {
+			t.Errorf("unexpected next occurrence: %+v", stored)
+		}
+	}
+
+	// A recurring task needs a due date
+	update.DueDate = ""
+	if err := client.UpdateTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID, "", update); err == nil {
+		t.Errorf("recurrence without a due date was accepted")
+	}
+
+	// Turn it off
+	update.DueDate = "2025-06-02"
+	update.Recurrence = &models.PatternedRecurrence{}
+	if err := client.UpdateTaskDetails(ctx, graphtest.AccessToken, list.ID, nextID, "", update); err != nil {
+		t.Fatalf("UpdateTaskDetails failed: %v", err)
+	}
+	if stored, _ := server.Task(list.ID, nextID); stored.Recurrence != nil {
+		t.Errorf("recurrence = %+v after turning it off", stored.Recurrence)
+	}
+}
+
+func TestWritesAreRetried(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Retry me"})
+	server.FailNext(1, http.StatusServiceUnavailable, "")
+
+	if err := client.UpdateTaskImportance(context.Background(), graphtest.AccessToken, list.ID, task.ID, task.ETag, "high"); err != nil {
+		t.Fatalf("UpdateTaskImportance failed after retry: %v", err)
+	}
+	if stored, _ := server.Task(list.ID, task.ID); stored.Importance != "high" {
+		t.Errorf("importance = %q, want high; the retried body was not replayed", stored.Importance)
+	}
+}
+
+func TestCreatesAreOnlyRetriedWhenThrottled(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	ctx := context.Background()
+
+	// A throttled create was never applied, so it's sent again
+	server.FailNext(1, http.StatusTooManyRequests, "")
+	if _, err := client.CreateTask(ctx, graphtest.AccessToken, list.ID, "Throttled"); err != nil {
+		t.Fatalf("CreateTask failed after a 429: %v", err)
+	}
+
+	// A create that timed out may have been applied, so sending it again could make a duplicate
+	before := len(server.Requests())
+	server.FailAfter("POST", "/lists/"+list.ID+"/tasks", http.StatusGatewayTimeout)
+	_, err := client.CreateTask(ctx, graphtest.AccessToken, list.ID, "Timed out")
+	var graphErr *microsoft.GraphError
+	if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("error = %v, want a 504 GraphError", err)
+	}
+	if n := len(server.Requests()) - before; n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+	if tasks := server.Tasks(list.ID); len(tasks) != 2 {
+		t.Errorf("list has %d tasks, want 2", len(tasks))
+	}
+
+	// Neither is an update without If-Match
+	task := server.AddTask(list.ID, models.Task{Title: "Unversioned"})
+	before = len(server.Requests())
+	server.FailNext(1, http.StatusServiceUnavailable, "")
+	if err := client.UpdateTaskImportance(ctx, graphtest.AccessToken, list.ID, task.ID, "", "high"); err == nil {
+		t.Errorf("an update without If-Match was retried after a 503")
+	}
+	if n := len(server.Requests()) - before; n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+}
+
+func TestUpdatesUseIfMatch(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	task := server.AddTask(list.ID, models.Task{Title: "Shared task"})
+	ctx := context.Background()
+
+	// An update at the current version succeeds and sends If-Match
+	if err := client.UpdateTaskImportance(ctx, graphtest.AccessToken, list.ID, task.ID, task.ETag, "high"); err != nil {
+		t.Fatalf("UpdateTaskImportance failed: %v", err)
+	}
+	requests := server.Requests()
+	if got := requests[len(requests)-1].Header.Get("If-Match"); got != task.ETag {
+		t.Errorf("If-Match = %q, want %q", got, task.ETag)
+	}
+
+	// The same etag is now stale, so the next update is refused and changes nothing
+	err := client.UpdateTaskDetails(ctx, graphtest.AccessToken, list.ID, task.ID, task.ETag, models.TaskUpdate{Title: "Overwritten", Status: "notStarted", Importance: "normal"})
+	var graphErr *microsoft.GraphError
+	if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("error = %v, want a 412 GraphError", err)
+	}
+	if stored, _ := server.Task(list.ID, task.ID); stored.Title != "Shared task" || stored.Importance != "high" {
+		t.Errorf("stale update was applied: %+v", stored)
+	}
+}
+
+func TestGetListTasksDelta(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	for i := 0; i < 12; i++ {
+		server.AddTask(list.ID, models.Task{Title: fmt.Sprintf("Task %d", i)})
+	}
+	ctx := context.Background()
+
+	// The first round returns every task across pages
+	delta, err := client.GetListTasksDelta(ctx, graphtest.AccessToken, list.ID, "")
+	if err != nil {
+		t.Fatalf("GetListTasksDelta failed: %v", err)
+	}
+	if len(delta.Tasks) != 12 || len(delta.Removed) != 0 || delta.DeltaLink == "" {
+		t.Fatalf("got %d tasks, %d removed and deltaLink %q", len(delta.Tasks), len(delta.Removed), delta.DeltaLink)
+	}
+
+	// Later rounds return only what changed
+	changed := delta.Tasks[3]
+	changed.Title = "Renamed"
+	server.SetTask(list.ID, changed)
+	server.RemoveTask(list.ID, delta.Tasks[5].ID)
+
+	next, err := client.GetListTasksDelta(ctx, graphtest.AccessToken, list.ID, delta.DeltaLink)
+	if err != nil {
+		t.Fatalf("GetListTasksDelta failed: %v", err)
+	}
+	if len(next.Tasks) != 1 || next.Tasks[0].Title != "Renamed" {
+		t.Errorf("changed tasks = %+v, want only the renamed task", next.Tasks)
+	}
+	if len(next.Removed) != 1 || next.Removed[0] != delta.Tasks[5].ID {
+		t.Errorf("removed = %v, want [%s]", next.Removed, delta.Tasks[5].ID)
+	}
+	if next.DeltaLink == "" || next.DeltaLink == delta.DeltaLink {
+		t.Errorf("deltaLink was not advanced: %q", next.DeltaLink)
+	}
+}
+
+func TestGetFilteredTasks(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	for i := 0; i < 12; i++ {
+		server.AddTask(list.ID, models.Task{Title: fmt.Sprintf("Task %d", i), Importance: "high", Categories: []string{"Bob's"}, DueDateTime: models.NewDate("2025-06-02")})
+	}
+	server.AddTask(list.ID, models.Task{Title: "Low", Importance: "low", Categories: []string{"Bob's"}, DueDateTime: models.NewDate("2025-06-02")})
+	server.AddTask(list.ID, models.Task{Title: "Late", Importance: "high", Categories: []string{"Bob's"}, DueDateTime: models.NewDate("2025-06-09")})
+	server.AddTask(list.ID, models.Task{Title: "Undated", Importance: "high", Categories: []string{"Bob's"}})
+	ctx := context.Background()
+
+	// Graph applies the filter, across pages; the search is left to the caller
+	filter := models.TaskFilter{Search: "task", Category: "Bob's", Importance: "high", DueAfter: "2025-06-01", DueBefore: "2025-06-03"}
+	if !client.CanFilter(filter) || client.CanFilter(models.TaskFilter{Search: "task"}) {
+		t.Errorf("CanFilter should be true only for filters with parts Graph can apply")
+	}
+	tasks, err := client.GetFilteredTasks(ctx, graphtest.AccessToken, list.ID, filter)
+	if err != nil {
+		t.Fatalf("GetFilteredTasks failed: %v", err)
+	}
+	if len(tasks.Value) != 12 {
+		t.Errorf("got %d tasks, want 12", len(tasks.Value))
+	}
+	requests := server.Requests()
+	want := "categories/any(c:c eq 'Bob''s') and importance eq 'high' and dueDateTime/dateTime lt '2025-06-03T00:00:00' and dueDateTime/dateTime gt '2025-06-01T23:59:59'"
+	if got := requests[len(requests)-1].Query.Get("$filter"); got != want {
+		t.Errorf("$filter = %q, want %q", got, want)
+	}
+
+	// Overdue tasks are the incomplete ones due before today
+	tasks, err = client.GetFilteredTasks(ctx, graphtest.AccessToken, list.ID, models.TaskFilter{Overdue: true, Today: "2025-06-05"})
+	if err != nil {
+		t.Fatalf("GetFilteredTasks failed: %v", err)
+	}
+	if len(tasks.Value) != 13 {
+		t.Errorf("got %d overdue tasks, want 13", len(tasks.Value))
+	}
+
+	// A filter Graph can't apply reads the whole list
+	tasks, err = client.GetFilteredTasks(ctx, graphtest.AccessToken, list.ID, models.TaskFilter{Search: "late"})
+	if err != nil {
+		t.Fatalf("GetFilteredTasks failed: %v", err)
+	}
+	requests = server.Requests()
+	if len(tasks.Value) != 15 || requests[len(requests)-1].Query.Has("$filter") {
+		t.Errorf("got %d tasks with $filter %q, want every task unfiltered", len(tasks.Value), requests[len(requests)-1].Query.Get("$filter"))
+	}
+}
+
+func TestSubscriptionLifecycle(t *testing.T) {
+	server, client := newTestClient(t)
+	list := server.AddList("Work")
+	ctx := context.Background()
+
+	// Graph validates the notification URL by having it echo a token
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.Query().Get("validationToken"))
+	}))
+	defer endpoint.Close()
+
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	sub, err := client.CreateSubscription(ctx, graphtest.AccessToken, models.Subscription{
+		Resource:           "/me/todo/lists/" + list.ID + "/tasks",
+		ChangeType:         "created,updated,deleted",
+		NotificationURL:    endpoint.URL,
+		ClientState:        "secret",
+		ExpirationDateTime: expiration,
+	})
+	if err != nil {
+		t.Fatalf("CreateSubscription failed: %v", err)
+	}
+	if sub.ID == "" || !sub.ExpirationDateTime.Equal(expiration) {
+		t.Errorf("unexpected subscription: %+v", sub)
+	}
+
+	renewed, err := client.RenewSubscription(ctx, graphtest.AccessToken, sub.ID, expiration.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("RenewSubscription failed: %v", err)
+	}
+	if !renewed.ExpirationDateTime.Equal(expiration.Add(time.Hour)) {
+		t.Errorf("expiration = %v, want %v", renewed.ExpirationDateTime, expiration.Add(time.Hour))
+	}
+
+	if err := client.DeleteSubscription(ctx, graphtest.AccessToken, sub.ID); err != nil {
+		t.Fatalf("DeleteSubscription failed: %v", err)
+	}
+	if n := len(server.Subscriptions()); n != 0 {
+		t.Errorf("got %d subscriptions after delete, want 0", n)
+	}
+
+	// A notification URL that doesn't echo the token is rejected
+	silent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer silent.Close()
+
+	_, err = client.CreateSubscription(ctx, graphtest.AccessToken, models.Subscription{
+		Resource:        "/me/todo/lists/" + list.ID + "/tasks",
+		ChangeType:      "updated",
+		NotificationURL: silent.URL,
+	})
+	var graphErr *microsoft.GraphError
+	if !errors.As(err, &graphErr) || graphErr.Code != "ValidationError" {
+		t.Errorf("error = %v, want a ValidationError", err)
+	}
+}
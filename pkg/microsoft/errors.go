@@ -0,0 +1,109 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package microsoft
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GraphError is returned when Microsoft Graph or the identity platform responds with a non-success status
+type GraphError struct {
+	StatusCode int           // HTTP status code
+	Code       string        // Graph error code, e.g. "ErrorItemNotFound" or "invalid_grant"
+	Message    string        // Human-readable error message
+	RequestID  string        // Graph request-id, useful when reporting issues to Microsoft
+	RetryAfter time.Duration // How long the server asked us to wait, if it said
+}
+
+// Error implements the error interface
+func (e *GraphError) Error() string {
+	msg := fmt.Sprintf("Microsoft Graph API returned error: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Code != "" {
+		msg += " - " + e.Code
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += " (request-id " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// HTTPStatus returns the HTTP status Graph responded with
+func (e *GraphError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// RetryAfterDelay returns how long Graph asked us to wait before retrying
+func (e *GraphError) RetryAfterDelay() time.Duration {
+	return e.RetryAfter
+}
+
+// graphErrorBody is the error payload returned by Microsoft Graph
+type graphErrorBody struct {
+	Error struct {
+		Code       string `json:"code"`
+		Message    string `json:"message"`
+		InnerError struct {
+			RequestID string `json:"request-id"`
+		} `json:"innerError"`
+	} `json:"error"`
+}
+
+// tokenErrorBody is the error payload returned by the identity platform token endpoint
+type tokenErrorBody struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// newGraphError builds a GraphError from a non-success response, consuming its body
+func newGraphError(resp *http.Response) *GraphError {
+	body, _ := io.ReadAll(resp.Body)
+
+	graphErr := &GraphError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("request-id"),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var graphBody graphErrorBody
+	var tokenBody tokenErrorBody
+	if err := json.Unmarshal(body, &graphBody); err == nil && graphBody.Error.Code != "" {
+		graphErr.Code = graphBody.Error.Code
+		graphErr.Message = graphBody.Error.Message
+		if graphBody.Error.InnerError.RequestID != "" {
+			graphErr.RequestID = graphBody.Error.InnerError.RequestID
+		}
+	} else if err := json.Unmarshal(body, &tokenBody); err == nil && tokenBody.Error != "" {
+		graphErr.Code = tokenBody.Error
+		graphErr.Message = tokenBody.ErrorDescription
+	} else {
+		graphErr.Message = string(body)
+	}
+
+	return graphErr
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
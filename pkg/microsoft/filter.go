@@ -0,0 +1,77 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package microsoft
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// GetFilteredTasks gets the tasks in a list that pass the parts of filter Graph's $filter can express,
+// following pagination. Text search isn't among them, since Graph can't filter on the notes.
+func (c *Client) GetFilteredTasks(ctx context.Context, accessToken string, listID string, filter models.TaskFilter) (*models.TaskResponse, error) {
+	expression := graphFilter(filter)
+	if expression == "" {
+		return c.GetListTasks(ctx, accessToken, listID)
+	}
+
+	// Graph reads + as a plus sign rather than a space, so spaces are sent as %20
+	query := expandChecklist + "&$filter=" + strings.ReplaceAll(url.QueryEscape(expression), "+", "%20")
+
+	var taskResp models.TaskResponse
+	err := c.walkPages(c.pagedURL(c.tasksURL(listID)+"?"+query), func(pageURL string) (string, error) {
+		page, err := c.GetListTasksPage(ctx, accessToken, listID, pageURL)
+		if err != nil {
+			return "", err
+		}
+		taskResp.Value = append(taskResp.Value, page.Value...)
+		return page.NextLink, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &taskResp, nil
+}
+
+// CanFilter reports whether Graph's $filter can express any part of filter
+func (c *Client) CanFilter(filter models.TaskFilter) bool {
+	return graphFilter(filter) != ""
+}
+
+// graphFilter translates the parts of a task filter Graph can apply into a $filter expression,
+// or returns "" if there are none
+func graphFilter(filter models.TaskFilter) string {
+	var clauses []string
+	if filter.Category != "" {
+		clauses = append(clauses, fmt.Sprintf("categories/any(c:c eq %s)", odataString(filter.Category)))
+	}
+	if filter.Importance != "" {
+		clauses = append(clauses, fmt.Sprintf("importance eq %s", odataString(filter.Importance)))
+	}
+
+	// Due dates are compared as Graph stores them, from midnight of each date
+	if filter.DueBefore != "" {
+		clauses = append(clauses, fmt.Sprintf("dueDateTime/dateTime lt %s", odataString(filter.DueBefore+"T00:00:00")))
+	}
+	if filter.DueAfter != "" {
+		clauses = append(clauses, fmt.Sprintf("dueDateTime/dateTime gt %s", odataString(filter.DueAfter+"T23:59:59")))
+	}
+	if filter.Overdue {
+		clauses = append(clauses, "status ne 'completed'")
+		clauses = append(clauses, fmt.Sprintf("dueDateTime/dateTime lt %s", odataString(filter.Today+"T00:00:00")))
+	}
+
+	return strings.Join(clauses, " and ")
+}
+
+// odataString quotes a value as an OData string literal
+func odataString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package graphtest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// The $filter clauses the fake server understands, which are those the client sends
+var (
+	categoryClause = regexp.MustCompile(`^categories/any\((\w+):(\w+) eq '((?:[^']|'')*)'\)$`)
+	fieldClause    = regexp.MustCompile(`^(importance|status) (eq|ne) '((?:[^']|'')*)'$`)
+	dueClause      = regexp.MustCompile(`^dueDateTime/dateTime (lt|le|gt|ge) '((?:[^']|'')*)'$`)
+)
+
+// parseFilter turns a $filter expression of clauses joined by "and" into a test on tasks
+func parseFilter(expression string) (func(models.Task) bool, error) {
+	var tests []func(models.Task) bool
+	for _, clause := range splitAnd(expression) {
+		test, err := parseClause(strings.TrimSpace(clause))
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, test)
+	}
+
+	return func(task models.Task) bool {
+		for _, test := range tests {
+			if !test(task) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// parseClause turns a single $filter clause into a test on tasks
+func parseClause(clause string) (func(models.Task) bool, error) {
+	if m := categoryClause.FindStringSubmatch(clause); m != nil && m[1] == m[2] {
+		category := unquote(m[3])
+		return func(task models.Task) bool {
+			for _, c := range task.Categories {
+				if c == category {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	if m := fieldClause.FindStringSubmatch(clause); m != nil {
+		value := unquote(m[3])
+		return func(task models.Task) bool {
+			field := task.Importance
+			if m[1] == "status" {
+				field = task.Status
+			}
+			return (field == value) == (m[2] == "eq")
+		}, nil
+	}
+
+	if m := dueClause.FindStringSubmatch(clause); m != nil {
+		value := unquote(m[2])
+		return func(task models.Task) bool {
+			if task.DueDateTime == nil {
+				return false
+			}
+			due := task.DueDateTime.DateTime
+			switch m[1] {
+			case "lt":
+				return due < value
+			case "le":
+				return due <= value
+			case "gt":
+				return due > value
+			default:
+				return due >= value
+			}
+		}, nil
+	}
+
+	return nil, fmt.Errorf("invalid filter clause: %s", clause)
+}
+
+// splitAnd splits an expression on the "and" operators outside string literals
+func splitAnd(expression string) []string {
+	var clauses []string
+	inString := false
+	start := 0
+	for i := 0; i < len(expression); i++ {
+		if expression[i] == '\'' {
+			inString = !inString
+			continue
+		}
+		if !inString && strings.HasPrefix(expression[i:], " and ") {
+			clauses = append(clauses, expression[start:i])
+			start = i + len(" and ")
+			i = start - 1
+		}
+	}
+	return append(clauses, expression[start:])
+}
+
+// unquote undoes the doubling of quotes in an OData string literal
+func unquote(value string) string {
+	return strings.ReplaceAll(value, "''", "'")
+}
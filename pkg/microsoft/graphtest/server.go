@@ -0,0 +1,1425 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+// Package graphtest provides a fake Microsoft Graph To Do API and identity platform
+// for testing code that uses microsoft.Client
+package graphtest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+	"github.com/coseguera/kanban-to-do/pkg/microsoft"
+)
+
+// Credentials issued and accepted by the fake server
+const (
+	ClientID     = "graphtest-client-id"
+	ClientSecret = "graphtest-client-secret"
+	AuthCode     = "graphtest-code"
+	AccessToken  = "graphtest-access-token"
+	RefreshToken = "graphtest-refresh-token"
+)
+
+// graphPath is the path of the To Do lists collection
+const graphPath = "/v1.0/me/todo/lists"
+
+// subscriptionsPath is the path of the subscriptions collection
+const subscriptionsPath = "/v1.0/subscriptions"
+
+// uploadPath is where upload sessions for large attachments receive their content
+const uploadPath = "/upload"
+
+// Request is a request received by the fake server
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   string
+}
+
+// failure is a canned error response queued with FailNext
+type failure struct {
+	status     int
+	retryAfter string
+	method     string // if set, only requests with this method and path suffix fail
+	pathSuffix string
+	applied    bool // the request takes effect before the failure is returned
+}
+
+// matches reports whether the failure applies to a request
+func (f failure) matches(r *http.Request) bool {
+	return f.method == "" || (r.Method == f.method && strings.HasSuffix(r.URL.Path, f.pathSuffix))
+}
+
+// change records a task that was modified or deleted, for delta queries
+type change struct {
+	taskID  string
+	version int
+	deleted bool
+}
+
+// upload is an unfinished upload session for an attachment
+type upload struct {
+	listID      string
+	taskID      string
+	name        string
+	contentType string
+	size        int64
+	content     []byte
+}
+
+// Server is a fake Microsoft Graph server backed by httptest.Server
+type Server struct {
+	*httptest.Server
+
+	// MaxPageSize caps the page size the server returns, regardless of $top
+	MaxPageSize int
+	// RequireSecret makes the token endpoint reject requests without ClientSecret
+	RequireSecret bool
+	// NotificationClient posts validation requests and change notifications to
+	// subscribers (http.DefaultClient if nil)
+	NotificationClient *http.Client
+
+	mu         sync.Mutex
+	lists      []models.TodoList
+	tasks      map[string][]models.Task
+	links      map[string][]models.LinkedResource // task ID -> linked resources
+	files      map[string][]models.Attachment     // task ID -> attachments with their content
+	uploads    map[string]*upload
+	changes    map[string][]change
+	version    int
+	nextID     int
+	challenges map[string]string // state -> PKCE code challenge
+	subs       []models.Subscription
+	failures   []failure
+	requests   []Request
+}
+
+// NewServer starts a fake Graph server; call Close when done
+func NewServer() *Server {
+	s := &Server{
+		MaxPageSize: 10,
+		tasks:       make(map[string][]models.Task),
+		links:       make(map[string][]models.LinkedResource),
+		files:       make(map[string][]models.Attachment),
+		uploads:     make(map[string]*upload),
+		changes:     make(map[string][]change),
+		challenges:  make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /authorize", s.handleAuthorize)
+	mux.HandleFunc("POST /token", s.handleToken)
+	mux.HandleFunc("GET "+graphPath, s.graph(s.handleGetLists))
+	mux.HandleFunc("POST "+graphPath, s.graph(s.handleCreateList))
+	mux.HandleFunc("GET "+graphPath+"/{listID}", s.graph(s.handleGetList))
+	mux.HandleFunc("PATCH "+graphPath+"/{listID}", s.graph(s.handleRenameList))
+	mux.HandleFunc("DELETE "+graphPath+"/{listID}", s.graph(s.handleDeleteList))
+	mux.HandleFunc("GET "+graphPath+"/{listID}/tasks", s.graph(s.handleGetTasks))
+	mux.HandleFunc("POST "+graphPath+"/{listID}/tasks", s.graph(s.handleCreateTask))
+	mux.HandleFunc("GET "+graphPath+"/{listID}/tasks/delta", s.graph(s.handleDelta))
+	mux.HandleFunc("GET "+graphPath+"/{listID}/tasks/{taskID}", s.graph(s.handleGetTask))
+	mux.HandleFunc("PATCH "+graphPath+"/{listID}/tasks/{taskID}", s.graph(s.handleUpdateTask))
+	mux.HandleFunc("DELETE "+graphPath+"/{listID}/tasks/{taskID}", s.graph(s.handleDeleteTask))
+	mux.HandleFunc("GET "+graphPath+"/{listID}/tasks/{taskID}/checklistItems", s.graph(s.handleGetChecklistItems))
+	mux.HandleFunc("POST "+graphPath+"/{listID}/tasks/{taskID}/checklistItems", s.graph(s.handleCreateChecklistItem))
+	mux.HandleFunc("PATCH "+graphPath+"/{listID}/tasks/{taskID}/checklistItems/{itemID}", s.graph(s.handleUpdateChecklistItem))
+	mux.HandleFunc("DELETE "+graphPath+"/{listID}/tasks/{taskID}/checklistItems/{itemID}", s.graph(s.handleDeleteChecklistItem))
+	mux.HandleFunc("GET "+graphPath+"/{listID}/tasks/{taskID}/linkedResources", s.graph(s.handleGetLinkedResources))
+	mux.HandleFunc("POST "+graphPath+"/{listID}/tasks/{taskID}/linkedResources", s.graph(s.handleCreateLinkedResource))
+	mux.HandleFunc("DELETE "+graphPath+"/{listID}/tasks/{taskID}/linkedResources/{resourceID}", s.graph(s.handleDeleteLinkedResource))
+	mux.HandleFunc("GET "+graphPath+"/{listID}/tasks/{taskID}/attachments", s.graph(s.handleGetAttachments))
+	mux.HandleFunc("POST "+graphPath+"/{listID}/tasks/{taskID}/attachments", s.graph(s.handleCreateAttachment))
+	mux.HandleFunc("POST "+graphPath+"/{listID}/tasks/{taskID}/attachments/createUploadSession", s.graph(s.handleCreateUploadSession))
+	mux.HandleFunc("GET "+graphPath+"/{listID}/tasks/{taskID}/attachments/{attachmentID}", s.graph(s.handleGetAttachment))
+	mux.HandleFunc("DELETE "+graphPath+"/{listID}/tasks/{taskID}/attachments/{attachmentID}", s.graph(s.handleDeleteAttachment))
+	mux.HandleFunc("PUT "+uploadPath+"/{uploadID}", s.handleUploadRange)
+	mux.HandleFunc("DELETE "+uploadPath+"/{uploadID}", s.handleCancelUpload)
+	mux.HandleFunc("POST "+subscriptionsPath, s.graph(s.handleCreateSubscription))
+	mux.HandleFunc("PATCH "+subscriptionsPath+"/{id}", s.graph(s.handleRenewSubscription))
+	mux.HandleFunc("DELETE "+subscriptionsPath+"/{id}", s.graph(s.handleDeleteSubscription))
+
+	s.Server = httptest.NewServer(s.record(mux))
+	return s
+}
+
+// Config returns a microsoft.Config pointing at the fake server.
+// Retries use a 1ms base delay so tests don't wait on backoff.
+func (s *Server) Config(redirectURI string) microsoft.Config {
+	return microsoft.Config{
+		ClientID:       ClientID,
+		ClientSecret:   ClientSecret,
+		RedirectURI:    redirectURI,
+		AuthURL:        s.URL + "/authorize",
+		TokenURL:       s.URL + "/token",
+		Scope:          "offline_access User.Read Tasks.ReadWrite",
+		GraphURL:       s.URL + graphPath,
+		RetryBaseDelay: time.Millisecond,
+	}
+}
+
+// AddList adds a list
+func (s *Server) AddList(displayName string) models.TodoList {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := models.TodoList{ID: s.newID("list"), DisplayName: displayName, IsOwner: true, WellknownListName: models.WellknownListNone}
+	s.lists = append(s.lists, list)
+	s.tasks[list.ID] = []models.Task{}
+	return list
+}
+
+// SetList replaces a stored list, for example to make it shared or a built-in list
+func (s *Server) SetList(list models.TodoList) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.listIndex(list.ID)
+	if i < 0 {
+		return false
+	}
+	s.lists[i] = list
+	return true
+}
+
+// AddTask adds a task to a list and returns it with its ID, and the IDs of its checklist items, set
+func (s *Server) AddTask(listID string, task models.Task) models.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task.ID = s.newID("task")
+	task.ChecklistItems = append([]models.ChecklistItem(nil), task.ChecklistItems...)
+	for i := range task.ChecklistItems {
+		task.ChecklistItems[i].ID = s.newID("item")
+	}
+	if task.Status == "" {
+		task.Status = "notStarted"
+	}
+	if task.Importance == "" {
+		task.Importance = "normal"
+	}
+	task.CreatedDateTime = time.Now().UTC().Format(time.RFC3339)
+	s.tasks[listID] = append(s.tasks[listID], task)
+	s.recordChange(listID, task.ID, false)
+	return s.tasks[listID][len(s.tasks[listID])-1]
+}
+
+// SetTask replaces a stored task, as if it was edited in another app
+func (s *Server) SetTask(listID string, task models.Task) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(listID, task.ID)
+	if i < 0 {
+		return false
+	}
+	s.tasks[listID][i] = task
+	s.recordChange(listID, task.ID, false)
+	return true
+}
+
+// RemoveTask deletes a stored task, as if it was deleted in another app
+func (s *Server) RemoveTask(listID string, taskID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(listID, taskID)
+	if i < 0 {
+		return false
+	}
+	s.tasks[listID] = append(s.tasks[listID][:i], s.tasks[listID][i+1:]...)
+	delete(s.links, taskID)
+	delete(s.files, taskID)
+	s.recordChange(listID, taskID, true)
+	return true
+}
+
+// Task returns a task as currently stored
+func (s *Server) Task(listID string, taskID string) (models.Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(listID, taskID)
+	if i < 0 {
+		return models.Task{}, false
+	}
+	return s.tasks[listID][i], true
+}
+
+// Tasks returns the tasks of a list as currently stored
+func (s *Server) Tasks(listID string) []models.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.Task(nil), s.tasks[listID]...)
+}
+
+// Subscriptions returns the active subscriptions
+func (s *Server) Subscriptions() []models.Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.Subscription(nil), s.subs...)
+}
+
+// Notify posts a change notification about a task to every subscription on its list,
+// as Graph would after the task changed. changeType is created, updated or deleted.
+func (s *Server) Notify(listID string, taskID string, changeType string) error {
+	resource := "/me/todo/lists/" + listID + "/tasks"
+
+	for _, sub := range s.Subscriptions() {
+		if sub.Resource != resource || !strings.Contains(sub.ChangeType, changeType) {
+			continue
+		}
+		notification := models.ChangeNotification{
+			SubscriptionID: sub.ID,
+			ClientState:    sub.ClientState,
+			ChangeType:     changeType,
+			Resource:       "Users/graphtest-user/todo/lists/" + listID + "/tasks/" + taskID,
+			ResourceData:   &models.ResourceData{ID: taskID},
+		}
+		if err := s.post(sub.NotificationURL, notification); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifyLifecycle posts a lifecycle event, such as subscriptionRemoved or
+// reauthorizationRequired, to a subscription's lifecycle notification URL
+func (s *Server) NotifyLifecycle(subscriptionID string, event string) error {
+	for _, sub := range s.Subscriptions() {
+		if sub.ID != subscriptionID {
+			continue
+		}
+		notificationURL := sub.LifecycleNotificationURL
+		if notificationURL == "" {
+			notificationURL = sub.NotificationURL
+		}
+		return s.post(notificationURL, models.ChangeNotification{
+			SubscriptionID: sub.ID,
+			ClientState:    sub.ClientState,
+			LifecycleEvent: event,
+		})
+	}
+	return fmt.Errorf("subscription %s not found", subscriptionID)
+}
+
+// post sends a notification to a subscriber
+func (s *Server) post(notificationURL string, notification models.ChangeNotification) error {
+	body, err := json.Marshal(models.ChangeNotificationCollection{Value: []models.ChangeNotification{notification}})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.notificationClient().Post(notificationURL, "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("notification URL returned %s", resp.Status)
+	}
+	return nil
+}
+
+// notificationClient returns the client used to call subscribers
+func (s *Server) notificationClient() *http.Client {
+	if s.NotificationClient != nil {
+		return s.NotificationClient
+	}
+	return http.DefaultClient
+}
+
+// FailNext makes the next n Graph requests fail with status, sending a
+// Retry-After header if retryAfter is not empty
+func (s *Server) FailNext(n int, status int, retryAfter string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, failure{status: status, retryAfter: retryAfter})
+	}
+}
+
+// FailOn makes the next Graph request with the given method and a path ending in
+// pathSuffix, such as "/tasks/"+taskID, fail with status
+func (s *Server) FailOn(method string, pathSuffix string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, failure{status: status, method: method, pathSuffix: pathSuffix})
+}
+
+// FailAfter is like FailOn, but the request takes effect before the failure is returned,
+// as when a response is lost after Graph has made a change
+func (s *Server) FailAfter(method string, pathSuffix string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, failure{status: status, method: method, pathSuffix: pathSuffix, applied: true})
+}
+
+// Requests returns every request received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// record logs each request before passing it on
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Query:  r.URL.Query(),
+			Header: r.Header.Clone(),
+			Body:   string(body),
+		})
+		s.mu.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// graph wraps a Graph handler with queued failures and bearer token checks
+func (s *Server) graph(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		var fail *failure
+		for i, f := range s.failures {
+			if f.matches(r) {
+				fail = &f
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+
+		if fail != nil && fail.applied {
+			next(httptest.NewRecorder(), r)
+		}
+		if fail != nil {
+			if fail.retryAfter != "" {
+				w.Header().Set("Retry-After", fail.retryAfter)
+			}
+			writeError(w, fail.status, "InjectedFailure", "Failure injected by graphtest")
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+AccessToken {
+			writeError(w, http.StatusUnauthorized, "InvalidAuthenticationToken", "Access token is empty or invalid")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// handleAuthorize signs in immediately and redirects back with an authorization code
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != ClientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.challenges[AuthCode] = query.Get("code_challenge")
+	s.mu.Unlock()
+
+	redirect := query.Get("redirect_uri") + "?code=" + AuthCode + "&state=" + url.QueryEscape(query.Get("state"))
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+// handleToken implements the authorization_code and refresh_token grants
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, "invalid_request", err.Error())
+		return
+	}
+
+	if r.FormValue("client_id") != ClientID {
+		writeTokenError(w, "invalid_client", "unknown client")
+		return
+	}
+	if s.RequireSecret && r.FormValue("client_secret") != ClientSecret {
+		writeTokenError(w, "invalid_client", "client secret is required")
+		return
+	}
+
+	switch r.FormValue("grant_type") {
+	case "authorization_code":
+		if r.FormValue("code") != AuthCode {
+			writeTokenError(w, "invalid_grant", "unknown authorization code")
+			return
+		}
+
+		// Verify PKCE if the code was issued through /authorize
+		s.mu.Lock()
+		challenge, issued := s.challenges[AuthCode]
+		s.mu.Unlock()
+		if issued {
+			sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+			if base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				writeTokenError(w, "invalid_grant", "code_verifier does not match code_challenge")
+				return
+			}
+		} else if r.FormValue("code_verifier") == "" {
+			writeTokenError(w, "invalid_grant", "code_verifier is required")
+			return
+		}
+	case "refresh_token":
+		if r.FormValue("refresh_token") != RefreshToken {
+			writeTokenError(w, "invalid_grant", "unknown refresh token")
+			return
+		}
+	default:
+		writeTokenError(w, "unsupported_grant_type", r.FormValue("grant_type"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.TokenResponse{
+		AccessToken:  AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    3600,
+		RefreshToken: RefreshToken,
+	})
+}
+
+// handleGetLists returns a page of lists
+func (s *Server) handleGetLists(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	lists := append([]models.TodoList(nil), s.lists...)
+	s.mu.Unlock()
+
+	start, end, nextLink := s.page(r, len(lists))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"value":           lists[start:end],
+		"@odata.nextLink": nextLink,
+	})
+}
+
+// handleGetList returns a single list
+func (s *Server) handleGetList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, list := range s.lists {
+		if list.ID == r.PathValue("listID") {
+			writeJSON(w, http.StatusOK, list)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+}
+
+// handleCreateList creates a list owned by the user
+func (s *Server) handleCreateList(w http.ResponseWriter, r *http.Request) {
+	var list models.TodoList
+	if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+	if list.DisplayName == "" {
+		writeError(w, http.StatusBadRequest, "invalidRequest", "displayName is required.")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, s.AddList(list.DisplayName))
+}
+
+// handleRenameList changes a list's name; built-in lists are refused
+func (s *Server) handleRenameList(w http.ResponseWriter, r *http.Request) {
+	var patch struct {
+		DisplayName string `json:"displayName"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.editableList(w, r.PathValue("listID"))
+	if i < 0 {
+		return
+	}
+	if patch.DisplayName != "" {
+		s.lists[i].DisplayName = patch.DisplayName
+	}
+	writeJSON(w, http.StatusOK, s.lists[i])
+}
+
+// handleDeleteList deletes a list with its tasks; built-in lists are refused
+func (s *Server) handleDeleteList(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listID")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.editableList(w, listID)
+	if i < 0 {
+		return
+	}
+	s.lists = append(s.lists[:i], s.lists[i+1:]...)
+	for _, task := range s.tasks[listID] {
+		delete(s.links, task.ID)
+		delete(s.files, task.ID)
+	}
+	delete(s.tasks, listID)
+	delete(s.changes, listID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetTasks returns a page of tasks
+func (s *Server) handleGetTasks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	tasks, ok := s.tasks[r.PathValue("listID")]
+	tasks = append([]models.Task(nil), tasks...)
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+
+	// Apply $filter before paging, as Graph does
+	if expression := r.URL.Query().Get("$filter"); expression != "" {
+		matches, err := parseFilter(expression)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+			return
+		}
+		filtered := tasks[:0]
+		for _, task := range tasks {
+			if matches(task) {
+				filtered = append(filtered, task)
+			}
+		}
+		tasks = filtered
+	}
+
+	start, end, nextLink := s.page(r, len(tasks))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"value":           expand(r, tasks[start:end]),
+		"@odata.nextLink": nextLink,
+	})
+}
+
+// handleDelta returns tasks changed since the delta token, or all tasks without one.
+// The last page carries an @odata.deltaLink for the next round.
+func (s *Server) handleDelta(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listID")
+
+	s.mu.Lock()
+	tasks, ok := s.tasks[listID]
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+
+	var items []interface{}
+	if token := r.URL.Query().Get("$deltatoken"); token != "" {
+		since, err := strconv.Atoi(token)
+		if err != nil {
+			s.mu.Unlock()
+			writeError(w, http.StatusGone, "SyncStateNotFound", "The sync state is invalid.")
+			return
+		}
+
+		// Report each changed task once, in its latest state
+		seen := make(map[string]bool)
+		for i := len(s.changes[listID]) - 1; i >= 0; i-- {
+			c := s.changes[listID][i]
+			if c.version <= since || seen[c.taskID] {
+				continue
+			}
+			seen[c.taskID] = true
+			if idx := s.indexOf(listID, c.taskID); idx >= 0 && !c.deleted {
+				items = append(items, expand(r, tasks[idx:idx+1])[0])
+			} else {
+				items = append(items, map[string]interface{}{
+					"id":       c.taskID,
+					"@removed": map[string]string{"reason": "deleted"},
+				})
+			}
+		}
+	} else {
+		for _, task := range expand(r, tasks) {
+			items = append(items, task)
+		}
+	}
+	version := s.version
+	s.mu.Unlock()
+
+	start, end, nextLink := s.page(r, len(items))
+	resp := map[string]interface{}{
+		"value":           append([]interface{}{}, items[start:end]...),
+		"@odata.nextLink": nextLink,
+	}
+	if nextLink == "" {
+		// Like Graph, the delta link keeps the query options of the first request
+		query := url.Values{"$deltatoken": {strconv.Itoa(version)}}
+		if expandParam := r.URL.Query().Get("$expand"); expandParam != "" {
+			query.Set("$expand", expandParam)
+		}
+		resp["@odata.deltaLink"] = fmt.Sprintf("%s%s/%s/tasks/delta?%s", s.URL, graphPath, listID, query.Encode())
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleGetTask returns a single task, or 304 if it still matches If-None-Match
+func (s *Server) handleGetTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.Task(r.PathValue("listID"), r.PathValue("taskID"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+	if match := r.Header.Get("If-None-Match"); match != "" && match == task.ETag {
+		w.Header().Set("ETag", task.ETag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, expand(r, []models.Task{task})[0])
+}
+
+// handleCreateTask creates a task from the posted fields
+func (s *Server) handleCreateTask(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listID")
+
+	s.mu.Lock()
+	_, ok := s.tasks[listID]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+
+	var task models.Task
+	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	task.ChecklistItems = nil
+	task.HasAttachments = false
+	writeJSON(w, http.StatusCreated, s.AddTask(listID, task))
+}
+
+// handleUpdateTask merges the posted fields into a task, or returns 412 if it no longer matches If-Match
+func (s *Server) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
+	listID, taskID := r.PathValue("listID"), r.PathValue("taskID")
+
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(listID, taskID)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+	if match := r.Header.Get("If-Match"); match != "" && match != "*" && match != s.tasks[listID][i].ETag {
+		writeError(w, http.StatusPreconditionFailed, "PreconditionFailed", "The entity has been modified since it was last read.")
+		return
+	}
+
+	// Overlay the patch on the stored task's JSON
+	current, _ := json.Marshal(s.tasks[listID][i])
+	var merged map[string]json.RawMessage
+	json.Unmarshal(current, &merged)
+	for key, value := range patch {
+		merged[key] = value
+	}
+	data, _ := json.Marshal(merged)
+
+	var task models.Task
+	if err := json.Unmarshal(data, &task); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+	if task.Recurrence != nil && task.DueDateTime == nil {
+		writeError(w, http.StatusBadRequest, "invalidRequest", "A recurring task must have a due date.")
+		return
+	}
+	// Graph records when a task is completed and forgets it when the task is reopened
+	completed := task.Status == "completed" && s.tasks[listID][i].Status != "completed"
+	if task.Status != "completed" {
+		task.CompletedDateTime = nil
+	} else if task.CompletedDateTime == nil {
+		task.CompletedDateTime = models.NewDateTime(time.Now())
+	}
+
+	// Completing a recurring task creates its next occurrence, which takes over the recurrence
+	next, recurs := models.NextOccurrence(task)
+	recurs = recurs && completed
+	if recurs {
+		task.Recurrence = nil
+	}
+
+	s.tasks[listID][i] = task
+	s.recordChange(listID, taskID, false)
+	updated := s.tasks[listID][i]
+
+	if recurs {
+		s.addOccurrence(listID, next)
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// handleGetChecklistItems returns a page of a task's checklist items
+func (s *Server) handleGetChecklistItems(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.Task(r.PathValue("listID"), r.PathValue("taskID"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+
+	items := append([]models.ChecklistItem{}, task.ChecklistItems...)
+	start, end, nextLink := s.page(r, len(items))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"value":           items[start:end],
+		"@odata.nextLink": nextLink,
+	})
+}
+
+// handleCreateChecklistItem adds an item to a task's checklist
+func (s *Server) handleCreateChecklistItem(w http.ResponseWriter, r *http.Request) {
+	listID, taskID := r.PathValue("listID"), r.PathValue("taskID")
+
+	var item models.ChecklistItem
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(listID, taskID)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+	item.ID = s.newID("item")
+	item.CreatedDateTime = time.Now().UTC().Format(time.RFC3339)
+	if item.IsChecked {
+		item.CheckedDateTime = item.CreatedDateTime
+	}
+	s.tasks[listID][i].ChecklistItems = append(s.tasks[listID][i].ChecklistItems, item)
+	s.recordChange(listID, taskID, false)
+
+	writeJSON(w, http.StatusCreated, item)
+}
+
+// handleUpdateChecklistItem merges the posted fields into a checklist item
+func (s *Server) handleUpdateChecklistItem(w http.ResponseWriter, r *http.Request) {
+	listID, taskID, itemID := r.PathValue("listID"), r.PathValue("taskID"), r.PathValue("itemID")
+
+	var patch struct {
+		DisplayName *string `json:"displayName"`
+		IsChecked   *bool   `json:"isChecked"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := s.checklistItem(listID, taskID, itemID)
+	if item == nil {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+	if patch.DisplayName != nil {
+		item.DisplayName = *patch.DisplayName
+	}
+	if patch.IsChecked != nil {
+		item.IsChecked = *patch.IsChecked
+		item.CheckedDateTime = ""
+		if item.IsChecked {
+			item.CheckedDateTime = time.Now().UTC().Format(time.RFC3339)
+		}
+	}
+	updated := *item
+	s.recordChange(listID, taskID, false)
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// handleDeleteChecklistItem deletes a checklist item
+func (s *Server) handleDeleteChecklistItem(w http.ResponseWriter, r *http.Request) {
+	listID, taskID, itemID := r.PathValue("listID"), r.PathValue("taskID"), r.PathValue("itemID")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.indexOf(listID, taskID); i >= 0 {
+		items := s.tasks[listID][i].ChecklistItems
+		for j := range items {
+			if items[j].ID == itemID {
+				s.tasks[listID][i].ChecklistItems = append(items[:j:j], items[j+1:]...)
+				s.recordChange(listID, taskID, false)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+}
+
+// handleGetLinkedResources returns a page of a task's linked resources
+func (s *Server) handleGetLinkedResources(w http.ResponseWriter, r *http.Request) {
+	listID, taskID := r.PathValue("listID"), r.PathValue("taskID")
+
+	s.mu.Lock()
+	found := s.indexOf(listID, taskID) >= 0
+	links := append([]models.LinkedResource{}, s.links[taskID]...)
+	s.mu.Unlock()
+	if !found {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+
+	start, end, nextLink := s.page(r, len(links))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"value":           links[start:end],
+		"@odata.nextLink": nextLink,
+	})
+}
+
+// handleCreateLinkedResource links a task to an item in another app
+func (s *Server) handleCreateLinkedResource(w http.ResponseWriter, r *http.Request) {
+	listID, taskID := r.PathValue("listID"), r.PathValue("taskID")
+
+	var link models.LinkedResource
+	if err := json.NewDecoder(r.Body).Decode(&link); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+	if link.WebURL == "" {
+		writeError(w, http.StatusBadRequest, "BadRequest", "webUrl is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.indexOf(listID, taskID) < 0 {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+	link.ID = s.newID("link")
+	s.links[taskID] = append(s.links[taskID], link)
+	s.recordChange(listID, taskID, false)
+
+	writeJSON(w, http.StatusCreated, link)
+}
+
+// handleDeleteLinkedResource deletes a linked resource
+func (s *Server) handleDeleteLinkedResource(w http.ResponseWriter, r *http.Request) {
+	listID, taskID, resourceID := r.PathValue("listID"), r.PathValue("taskID"), r.PathValue("resourceID")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.indexOf(listID, taskID) >= 0 {
+		links := s.links[taskID]
+		for i := range links {
+			if links[i].ID == resourceID {
+				s.links[taskID] = append(links[:i:i], links[i+1:]...)
+				s.recordChange(listID, taskID, false)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+}
+
+// handleGetAttachments returns a page of a task's attachments, without their content
+func (s *Server) handleGetAttachments(w http.ResponseWriter, r *http.Request) {
+	listID, taskID := r.PathValue("listID"), r.PathValue("taskID")
+
+	s.mu.Lock()
+	found := s.indexOf(listID, taskID) >= 0
+	attachments := []models.Attachment{}
+	for _, attachment := range s.files[taskID] {
+		attachment.ContentBytes = nil
+		attachments = append(attachments, attachment)
+	}
+	s.mu.Unlock()
+	if !found {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+
+	start, end, nextLink := s.page(r, len(attachments))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"value":           attachments[start:end],
+		"@odata.nextLink": nextLink,
+	})
+}
+
+// handleGetAttachment returns an attachment with its content
+func (s *Server) handleGetAttachment(w http.ResponseWriter, r *http.Request) {
+	listID, taskID, attachmentID := r.PathValue("listID"), r.PathValue("taskID"), r.PathValue("attachmentID")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.indexOf(listID, taskID) >= 0 {
+		for _, attachment := range s.files[taskID] {
+			if attachment.ID == attachmentID {
+				writeJSON(w, http.StatusOK, attachment)
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+}
+
+// handleCreateAttachment attaches a file sent inline, rejecting files too large for one request as Graph does
+func (s *Server) handleCreateAttachment(w http.ResponseWriter, r *http.Request) {
+	listID, taskID := r.PathValue("listID"), r.PathValue("taskID")
+
+	var attachment models.Attachment
+	if err := json.NewDecoder(r.Body).Decode(&attachment); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+	if len(attachment.ContentBytes) > microsoft.MaxInlineAttachmentSize {
+		writeError(w, http.StatusRequestEntityTooLarge, "RequestBodyTooLarge", "Attachments larger than 3 MB must be uploaded with an upload session.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.indexOf(listID, taskID) < 0 {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+	created := s.addAttachment(listID, taskID, attachment.Name, attachment.ContentType, attachment.ContentBytes)
+	created.ContentBytes = nil
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// handleCreateUploadSession starts an upload session for a large attachment
+func (s *Server) handleCreateUploadSession(w http.ResponseWriter, r *http.Request) {
+	listID, taskID := r.PathValue("listID"), r.PathValue("taskID")
+
+	var request struct {
+		AttachmentInfo struct {
+			AttachmentType string `json:"attachmentType"`
+			Name           string `json:"name"`
+			ContentType    string `json:"contentType"`
+			Size           int64  `json:"size"`
+		} `json:"attachmentInfo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+	info := request.AttachmentInfo
+	if info.AttachmentType != "file" || info.Name == "" || info.Size <= 0 {
+		writeError(w, http.StatusBadRequest, "BadRequest", "attachmentInfo must describe a file")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.indexOf(listID, taskID) < 0 {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+	uploadID := s.newID("upload")
+	s.uploads[uploadID] = &upload{
+		listID:      listID,
+		taskID:      taskID,
+		name:        info.Name,
+		contentType: info.ContentType,
+		size:        info.Size,
+	}
+
+	writeJSON(w, http.StatusOK, models.UploadSession{
+		UploadURL:          "http://" + r.Host + uploadPath + "/" + uploadID,
+		ExpirationDateTime: time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		NextExpectedRanges: []string{"0-"},
+	})
+}
+
+// handleUploadRange receives one range of an upload session. The upload URL is
+// pre-authenticated, so like Graph it refuses requests that carry an access token.
+func (s *Server) handleUploadRange(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "" {
+		writeError(w, http.StatusUnauthorized, "InvalidAuthenticationToken", "Upload URLs must be called without an Authorization header")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uploadID := r.PathValue("uploadID")
+	u, ok := s.uploads[uploadID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The upload session was not found.")
+		return
+	}
+
+	var start, end, total int64
+	if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total); err != nil ||
+		start != int64(len(u.content)) || end-start+1 != int64(len(body)) || total != u.size || end >= total {
+		writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The Content-Range does not match the next expected range.")
+		return
+	}
+	u.content = append(u.content, body...)
+
+	if int64(len(u.content)) < u.size {
+		writeJSON(w, http.StatusOK, models.UploadSession{
+			NextExpectedRanges: []string{fmt.Sprintf("%d-", len(u.content))},
+		})
+		return
+	}
+
+	delete(s.uploads, uploadID)
+	if s.indexOf(u.listID, u.taskID) < 0 {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+	created := s.addAttachment(u.listID, u.taskID, u.name, u.contentType, u.content)
+
+	// Like Graph, the completed upload only says where the attachment is
+	w.Header().Set("Location", fmt.Sprintf("http://%s%s/%s/tasks/%s/attachments('%s')", r.Host, graphPath, u.listID, u.taskID, created.ID))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleCancelUpload deletes an unfinished upload session
+func (s *Server) handleCancelUpload(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.uploads, r.PathValue("uploadID"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeleteAttachment deletes an attachment
+func (s *Server) handleDeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	listID, taskID, attachmentID := r.PathValue("listID"), r.PathValue("taskID"), r.PathValue("attachmentID")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.indexOf(listID, taskID); i >= 0 {
+		files := s.files[taskID]
+		for j := range files {
+			if files[j].ID == attachmentID {
+				s.files[taskID] = append(files[:j:j], files[j+1:]...)
+				s.tasks[listID][i].HasAttachments = len(s.files[taskID]) > 0
+				s.recordChange(listID, taskID, false)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+}
+
+// handleDeleteTask deletes a task
+func (s *Server) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
+	listID, taskID := r.PathValue("listID"), r.PathValue("taskID")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(listID, taskID)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+	if match := r.Header.Get("If-Match"); match != "" && match != "*" && match != s.tasks[listID][i].ETag {
+		writeError(w, http.StatusPreconditionFailed, "PreconditionFailed", "The entity has been modified since it was last read.")
+		return
+	}
+	s.tasks[listID] = append(s.tasks[listID][:i], s.tasks[listID][i+1:]...)
+	delete(s.links, taskID)
+	delete(s.files, taskID)
+	s.recordChange(listID, taskID, true)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCreateSubscription validates the notification URL like Graph does, then stores the subscription
+func (s *Server) handleCreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var sub models.Subscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	// The subscriber must echo a validation token back as plain text
+	token := fmt.Sprintf("graphtest-validation-%d", time.Now().UnixNano())
+	validationURL := sub.NotificationURL + "?validationToken=" + url.QueryEscape(token)
+	resp, err := s.notificationClient().Post(validationURL, "text/plain", nil)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationError", "Subscription validation request failed: "+err.Error())
+		return
+	}
+	echoed, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(echoed) != token {
+		writeError(w, http.StatusBadRequest, "ValidationError", "Subscription validation request failed. Response must exactly match validationToken query parameter.")
+		return
+	}
+
+	s.mu.Lock()
+	sub.ID = s.newID("subscription")
+	s.subs = append(s.subs, sub)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+// handleRenewSubscription updates a subscription's expiration
+func (s *Server) handleRenewSubscription(w http.ResponseWriter, r *http.Request) {
+	var patch struct {
+		ExpirationDateTime time.Time `json:"expirationDateTime"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.subs {
+		if s.subs[i].ID == r.PathValue("id") {
+			s.subs[i].ExpirationDateTime = patch.ExpirationDateTime
+			writeJSON(w, http.StatusOK, s.subs[i])
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "ResourceNotFound", "The object was not found.")
+}
+
+// handleDeleteSubscription deletes a subscription
+func (s *Server) handleDeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.subs {
+		if s.subs[i].ID == r.PathValue("id") {
+			s.subs = append(s.subs[:i], s.subs[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "ResourceNotFound", "The object was not found.")
+}
+
+// page applies $top and $skip to a collection of n items, returning the
+// slice bounds and the nextLink for the following page
+func (s *Server) page(r *http.Request, n int) (start, end int, nextLink string) {
+	query := r.URL.Query()
+	size := s.MaxPageSize
+	if top, err := strconv.Atoi(query.Get("$top")); err == nil && top > 0 && top < size {
+		size = top
+	}
+	start, _ = strconv.Atoi(query.Get("$skip"))
+	if start > n {
+		start = n
+	}
+	end = start + size
+	if end >= n {
+		return start, n, ""
+	}
+
+	query.Set("$skip", strconv.Itoa(end))
+	next := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path, RawQuery: query.Encode()}
+	return start, end, next.String()
+}
+
+// indexOf returns the position of a task in its list, or -1; callers must hold the lock
+func (s *Server) indexOf(listID string, taskID string) int {
+	for i, task := range s.tasks[listID] {
+		if task.ID == taskID {
+			return i
+		}
+	}
+	return -1
+}
+
+// listIndex returns the position of a list, or -1; callers must hold the lock
+func (s *Server) listIndex(listID string) int {
+	for i, list := range s.lists {
+		if list.ID == listID {
+			return i
+		}
+	}
+	return -1
+}
+
+// editableList returns the position of a list that can be renamed or deleted, or writes
+// an error and returns -1 if it doesn't exist or is built in; callers must hold the lock
+func (s *Server) editableList(w http.ResponseWriter, listID string) int {
+	i := s.listIndex(listID)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return -1
+	}
+	if s.lists[i].IsWellknown() {
+		writeError(w, http.StatusBadRequest, "invalidRequest", "Well-known lists can't be renamed or deleted.")
+		return -1
+	}
+	return i
+}
+
+// checklistItem returns a pointer to a stored checklist item, or nil; callers must hold the lock
+func (s *Server) checklistItem(listID string, taskID string, itemID string) *models.ChecklistItem {
+	i := s.indexOf(listID, taskID)
+	if i < 0 {
+		return nil
+	}
+	for j := range s.tasks[listID][i].ChecklistItems {
+		if s.tasks[listID][i].ChecklistItems[j].ID == itemID {
+			return &s.tasks[listID][i].ChecklistItems[j]
+		}
+	}
+	return nil
+}
+
+// addOccurrence stores the next occurrence of a recurring task; callers must hold the lock
+func (s *Server) addOccurrence(listID string, task models.Task) {
+	task.ID = s.newID("task")
+	for i := range task.ChecklistItems {
+		task.ChecklistItems[i].ID = s.newID("item")
+	}
+	task.CreatedDateTime = time.Now().UTC().Format(time.RFC3339)
+	s.tasks[listID] = append(s.tasks[listID], task)
+	s.recordChange(listID, task.ID, false)
+}
+
+// addAttachment stores an attachment on an existing task and returns it; callers must hold the lock
+func (s *Server) addAttachment(listID string, taskID string, name string, contentType string, content []byte) models.Attachment {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	attachment := models.Attachment{
+		ID:                   s.newID("attachment"),
+		Name:                 name,
+		ContentType:          contentType,
+		Size:                 int64(len(content)),
+		LastModifiedDateTime: time.Now().UTC().Format(time.RFC3339),
+		ContentBytes:         append([]byte(nil), content...),
+	}
+	s.files[taskID] = append(s.files[taskID], attachment)
+	s.tasks[listID][s.indexOf(listID, taskID)].HasAttachments = true
+	s.recordChange(listID, taskID, false)
+	return attachment
+}
+
+// expand returns copies of tasks that include their checklist items only if the request asked for
+// them with $expand=checklistItems, as Graph does
+func expand(r *http.Request, tasks []models.Task) []models.Task {
+	withChecklist := strings.Contains(r.URL.Query().Get("$expand"), "checklistItems")
+
+	expanded := make([]models.Task, len(tasks))
+	for i, task := range tasks {
+		if withChecklist {
+			task.ChecklistItems = append([]models.ChecklistItem{}, task.ChecklistItems...)
+		} else {
+			task.ChecklistItems = nil
+		}
+		expanded[i] = task
+	}
+	return expanded
+}
+
+// recordChange notes a change for delta queries and gives the task a new etag;
+// callers must hold the lock
+func (s *Server) recordChange(listID string, taskID string, deleted bool) {
+	s.version++
+	s.changes[listID] = append(s.changes[listID], change{taskID: taskID, version: s.version, deleted: deleted})
+	if i := s.indexOf(listID, taskID); i >= 0 {
+		s.tasks[listID][i].ETag = fmt.Sprintf(`W/"%d"`, s.version)
+	}
+}
+
+// newID returns a unique ID; callers must hold the lock
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s-%d", prefix, s.nextID)
+}
+
+// writeJSON writes a JSON response, omitting an empty @odata.nextLink
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	if m, ok := v.(map[string]interface{}); ok && m["@odata.nextLink"] == "" {
+		delete(m, "@odata.nextLink")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a Graph-style error response
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	requestID := fmt.Sprintf("graphtest-%d", time.Now().UnixNano())
+	w.Header().Set("request-id", requestID)
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":       code,
+			"message":    message,
+			"innerError": map[string]string{"request-id": requestID},
+		},
+	})
+}
+
+// writeTokenError writes an identity platform error response
+func writeTokenError(w http.ResponseWriter, code string, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package microsoft
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// CreateList creates a to-do list owned by the user
+func (c *Client) CreateList(ctx context.Context, accessToken string, displayName string) (*models.TodoList, error) {
+	var list models.TodoList
+	if err := c.postJSON(ctx, accessToken, c.config.GraphURL, map[string]interface{}{"displayName": displayName}, &list); err != nil {
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+// RenameList changes a to-do list's name
+func (c *Client) RenameList(ctx context.Context, accessToken string, listID string, displayName string) error {
+	return c.patchItem(ctx, accessToken, fmt.Sprintf("%s/%s", c.config.GraphURL, listID), map[string]interface{}{
+		"displayName": displayName,
+	})
+}
+
+// DeleteList deletes a to-do list and its tasks
+func (c *Client) DeleteList(ctx context.Context, accessToken string, listID string) error {
+	return c.deleteItem(ctx, accessToken, fmt.Sprintf("%s/%s", c.config.GraphURL, listID))
+}
+
+// patchItem sends a partial update of an item
+func (c *Client) patchItem(ctx context.Context, accessToken string, itemURL string, payload map[string]interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PATCH", itemURL, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("error creating API request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("error calling Microsoft Graph API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return newGraphError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2025 Carlos Oseguera (@coseguera)
+// This code is licensed under a dual-license model.
+// See LICENSE.md for more information.
+
+package microsoft
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coseguera/kanban-to-do/internal/models"
+)
+
+// subscriptionsURL returns the Graph subscriptions collection
+func (c *Client) subscriptionsURL() string {
+	if c.config.SubscriptionsURL != "" {
+		return c.config.SubscriptionsURL
+	}
+	return strings.TrimSuffix(c.config.GraphURL, "/me/todo/lists") + "/subscriptions"
+}
+
+// CreateSubscription subscribes to change notifications.
+// Graph validates the notification URL before creating the subscription.
+func (c *Client) CreateSubscription(ctx context.Context, accessToken string, subscription models.Subscription) (*models.Subscription, error) {
+	jsonData, err := json.Marshal(subscription)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling subscription: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.subscriptionsURL(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("error creating API request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+	req.Header.Add("Content-Type", "application/json")
+
+	return c.sendSubscription(req, http.StatusCreated)
+}
+
+// RenewSubscription extends a subscription's expiration
+func (c *Client) RenewSubscription(ctx context.Context, accessToken string, subscriptionID string, expiration time.Time) (*models.Subscription, error) {
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"expirationDateTime": expiration.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling subscription renewal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PATCH", c.subscriptionsURL()+"/"+subscriptionID, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("error creating API request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+	req.Header.Add("Content-Type", "application/json")
+
+	return c.sendSubscription(req, http.StatusOK)
+}
+
+// DeleteSubscription deletes a subscription
+func (c *Client) DeleteSubscription(ctx context.Context, accessToken string, subscriptionID string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.subscriptionsURL()+"/"+subscriptionID, nil)
+	if err != nil {
+		return fmt.Errorf("error creating API request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("error calling Microsoft Graph API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return newGraphError(resp)
+	}
+
+	return nil
+}
+
+// sendSubscription sends a subscription request and decodes the subscription in the response
+func (c *Client) sendSubscription(req *http.Request, wantStatus int) (*models.Subscription, error) {
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling Microsoft Graph API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != wantStatus {
+		return nil, newGraphError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading API response: %w", err)
+	}
+
+	var subscription models.Subscription
+	if err := json.Unmarshal(body, &subscription); err != nil {
+		return nil, fmt.Errorf("error parsing API response: %w", err)
+	}
+
+	return &subscription, nil
+}
@@ -4,9 +4,28 @@
  * See LICENSE.md for more information.
  */
 
-// Store the list ID for API calls
+// Store the list ID for API calls; empty on an aggregate board, where each card names its list
 const listId = document.getElementById('listIdField').value;
 
+// Lists shown on an aggregate board, or none on a single list's board
+const boardLists = [...document.querySelectorAll('.board-list')].map(input => ({
+    id: input.value,
+    name: input.getAttribute('data-name')
+}));
+
+// A filtered board only shows matching tasks, so it doesn't add cards for tasks created elsewhere
+const filterForm = document.getElementById('filterForm');
+const boardFiltered = filterForm !== null && filterForm.getAttribute('data-active') === 'true';
+
+// Find the list a task belongs to from its card, falling back to the board's list
+function taskListId(taskId) {
+    const taskCard = document.querySelector(`[data-task-id="${taskId}"]`);
+    return (taskCard && taskCard.getAttribute('data-list-id')) || listId;
+}
+
+// Identify this browser tab so it can ignore live events for its own changes
+const clientId = Math.random().toString(36).slice(2) + Date.now().toString(36);
+
 // Debug on page load
 console.log("List ID at page load:", listId);
 
@@ -41,81 +60,64 @@ function drop(event) {
     // Get the column name
     const columnName = targetColumn.parentElement.getAttribute("data-column");
     
+    // Work out where in the column the card was dropped
+    const nextCard = getCardAfterPointer(targetColumn, event.clientY, draggedElement);
+    
+    // Dropping within the same column only changes the card's position
+    if (draggedElement.closest(".column-content") === targetColumn) {
+        targetColumn.insertBefore(draggedElement, nextCard);
+        saveCardOrder(taskId, targetColumn).then((success) => {
+            if (!success) {
+                showToast("Failed to save card order. Please try again.", "error");
+            }
+        });
+        return;
+    }
+    
     // Show loading overlay
     document.getElementById("loadingOverlay").style.display = "flex";
     
     // Debug the values
     console.log("About to send update request:");
-    console.log("- List ID:", listId);
+    console.log("- List ID:", taskListId(taskId));
     console.log("- Task ID:", taskId);
     console.log("- Column Name:", columnName);
     
     // Send the update to the server
     updateTaskStatus(taskId, columnName)
-        .then((success) => {
-            if (success) {
+        .then((result) => {
+            if (result && result.cancelled) {
+                showToast("Move cancelled: column is at its WIP limit", "error");
+            } else if (result && result.conflict) {
+                // Someone else changed the task since the board loaded; show their version
+                updateTaskCardInUI(taskId, result.conflict);
+                showToast("This task was changed by someone else. The card has been refreshed; try the move again.", "error");
+            } else if (result) {
                 // Check for and remove the "no-tasks" message if it exists
                 const noTasksMessage = targetColumn.querySelector(".no-tasks");
                 if (noTasksMessage) {
                     noTasksMessage.remove();
                 }
                 
-                // If successful, move the task to the new column
-                targetColumn.appendChild(draggedElement);
+                // If successful, move the task to the new column and remember its position
+                targetColumn.insertBefore(draggedElement, nextCard);
+                saveCardOrder(taskId, targetColumn);
                 
-                // Update the UI based on the new column
-                if (columnName === "Done") {
+                // Update the card with the status and categories the server applied
+                if (result.status === "completed") {
                     draggedElement.classList.add("completed");
-                    
-                    // Remove "Doing" category tag
-                    const doingTags = draggedElement.querySelectorAll(".category-tag");
-                    doingTags.forEach(tag => {
-                        if (tag.textContent.toLowerCase() === "doing") {
-                            tag.remove();
-                        }
-                    });
-                } else if (columnName === "Doing") {
-                    draggedElement.classList.remove("completed");
-                    
-                    // Add "Doing" category tag if it doesn't exist
-                    let hasDoingTag = false;
-                    const categories = draggedElement.querySelector(".task-categories");
-                    const tags = categories ? categories.querySelectorAll(".category-tag") : [];
-                    
-                    tags.forEach(tag => {
-                        if (tag.textContent.toLowerCase() === "doing") {
-                            hasDoingTag = true;
-                        }
-                    });
-                    
-                    if (!hasDoingTag) {
-                        if (!categories) {
-                            // Create categories div if it doesn't exist
-                            const categoriesDiv = document.createElement("div");
-                            categoriesDiv.className = "task-categories";
-                            draggedElement.appendChild(categoriesDiv);
-                            
-                            const doingTag = document.createElement("span");
-                            doingTag.className = "category-tag";
-                            doingTag.textContent = "Doing";
-                            categoriesDiv.appendChild(doingTag);
-                        } else {
-                            const doingTag = document.createElement("span");
-                            doingTag.className = "category-tag";
-                            doingTag.textContent = "Doing";
-                            categories.appendChild(doingTag);
-                        }
-                    }
-                } else if (columnName === "Not Started") {
+                } else {
                     draggedElement.classList.remove("completed");
-                    
-                    // Remove "Doing" category tag
-                    const doingTags = draggedElement.querySelectorAll(".category-tag");
-                    doingTags.forEach(tag => {
-                        if (tag.textContent.toLowerCase() === "doing") {
-                            tag.remove();
-                        }
-                    });
+                }
+                renderCardCategories(draggedElement, result.categories);
+                updateColumnCounts();
+                
+                // Completing a recurring task adds its next occurrence
+                if (result.nextOccurrences && result.nextOccurrences.length > 0) {
+                    refreshTaskCard(taskId);
+                    showNextOccurrences(result.nextOccurrences, taskListId(taskId));
+                    showToast("Task completed. The next occurrence was added to the board", "success");
+                    return;
                 }
                 
                 // Show success message
@@ -134,18 +136,25 @@ function drop(event) {
         });
 }
 
-// Function to update task status on the server
-async function updateTaskStatus(taskId, columnName) {
+// Function to update task status on the server.
+// Returns the task's new status and categories, { cancelled: true } if the user
+// declined to exceed a WIP limit, { conflict: task } with the current task if it
+// was changed elsewhere since the board loaded, or null on failure.
+async function updateTaskStatus(taskId, columnName, override = false) {
     try {
         // Use URL encoded form data instead of FormData
         const params = new URLSearchParams();
-        params.append("listId", listId);
+        params.append("listId", taskListId(taskId));
         params.append("taskId", taskId);
         params.append("column", columnName);
+        params.append("clientId", clientId);
+        if (override) {
+            params.append("override", "true");
+        }
         
         // Debug logs
         console.log("Sending request with:");
-        console.log("listId:", listId);
+        console.log("listId:", params.get("listId"));
         console.log("taskId:", taskId);
         console.log("column:", columnName);
         
@@ -158,6 +167,21 @@ async function updateTaskStatus(taskId, columnName) {
             credentials: "same-origin"
         });
         
+        // The target column is at its WIP limit; let the user decide whether to exceed it
+        if (response.status === 409) {
+            const conflict = await response.json();
+            if (confirm(`${conflict.error}. Move the task anyway?`)) {
+                return await updateTaskStatus(taskId, columnName, true);
+            }
+            return { cancelled: true };
+        }
+        
+        // The task changed elsewhere, so the move was refused
+        if (response.status === 412) {
+            const conflict = await response.json();
+            return { conflict: conflict.current };
+        }
+        
         if (!response.ok) {
             // Get the error text from the response
             const errorText = await response.text();
@@ -165,9 +189,100 @@ async function updateTaskStatus(taskId, columnName) {
             throw new Error(errorText || "Server error");
         }
         
-        return response.ok;
+        return await response.json();
     } catch (error) {
         console.error("Error updating task:", error);
+        return null;
+    }
+}
+
+// Refresh the task count and over-limit highlight of columns with a WIP limit.
+// Tasks a filter hides still count against the limit.
+function updateColumnCounts() {
+    document.querySelectorAll('.kanban-column').forEach(column => {
+        const limit = parseInt(column.getAttribute('data-wip-limit'), 10) || 0;
+        if (limit === 0) return;
+        
+        const hidden = parseInt(column.getAttribute('data-hidden'), 10) || 0;
+        const count = column.querySelectorAll('.task-card').length + hidden;
+        const countElement = column.querySelector('.column-count');
+        if (countElement) {
+            countElement.textContent = `${count}/${limit}`;
+        }
+        column.classList.toggle('over-limit', count > limit);
+    });
+}
+
+// Replace the category tags shown on a task card
+function renderCardCategories(taskCard, categories) {
+    let categoriesElement = taskCard.querySelector('.task-categories');
+    
+    if (!categories || categories.length === 0) {
+        if (categoriesElement) {
+            categoriesElement.remove();
+        }
+        return;
+    }
+    
+    if (!categoriesElement) {
+        // Create categories container if it doesn't exist
+        categoriesElement = document.createElement('div');
+        categoriesElement.className = 'task-categories';
+        taskCard.appendChild(categoriesElement);
+    }
+    
+    // Clear existing categories and add the new ones
+    categoriesElement.innerHTML = '';
+    categories.forEach(category => {
+        const categoryTag = document.createElement('span');
+        categoryTag.className = 'category-tag';
+        categoryTag.textContent = category;
+        categoriesElement.appendChild(categoryTag);
+    });
+}
+
+// Find the card a dropped card should be inserted before, based on the pointer position
+function getCardAfterPointer(columnContent, y, draggedElement) {
+    const cards = [...columnContent.querySelectorAll(".task-card")].filter(card => card !== draggedElement);
+    return cards.find(card => {
+        const box = card.getBoundingClientRect();
+        return y < box.top + box.height / 2;
+    }) || null;
+}
+
+// Save the position of a card within its column on the server
+async function saveCardOrder(taskId, columnContent) {
+    try {
+        // Only the order of the task's own list is saved, so skip other lists' cards on an aggregate board
+        const taskList = taskListId(taskId);
+        const order = [...columnContent.querySelectorAll(".task-card")]
+            .filter(card => (card.getAttribute("data-list-id") || listId) === taskList)
+            .map(card => card.getAttribute("data-task-id"));
+        
+        const params = new URLSearchParams();
+        params.append("listId", taskList);
+        params.append("taskId", taskId);
+        params.append("order", JSON.stringify(order));
+        params.append("clientId", clientId);
+        
+        const response = await fetch("/api/reorderTask", {
+            method: "POST",
+            headers: {
+                'Content-Type': 'application/x-www-form-urlencoded',
+            },
+            body: params.toString(),
+            credentials: "same-origin"
+        });
+        
+        if (!response.ok) {
+            const errorText = await response.text();
+            console.error("Server error:", errorText);
+            throw new Error(errorText || "Server error");
+        }
+        
+        return true;
+    } catch (error) {
+        console.error("Error saving card order:", error);
         return false;
     }
 }
@@ -209,8 +324,12 @@ function toggleImportance(event, taskCard) {
     
     // Send the update to the server
     updateTaskImportance(taskId, newImportance)
-        .then((success) => {
-            if (success) {
+        .then((result) => {
+            if (result && result.conflict) {
+                // Someone else changed the task since the board loaded; show their version
+                updateTaskCardInUI(taskId, result.conflict);
+                showToast("This task was changed by someone else. The card has been refreshed; try again.", "error");
+            } else if (result) {
                 // Update the UI to reflect the new importance state
                 if (newImportance) {
                     taskCard.classList.add("important");
@@ -246,13 +365,14 @@ async function updateTaskImportance(taskId, isImportant) {
     try {
         // Use URL encoded form data
         const params = new URLSearchParams();
-        params.append("listId", listId);
+        params.append("listId", taskListId(taskId));
         params.append("taskId", taskId);
         params.append("isImportant", isImportant.toString());
+        params.append("clientId", clientId);
         
         // Debug logs
         console.log("Sending importance update request with:");
-        console.log("listId:", listId);
+        console.log("listId:", params.get("listId"));
         console.log("taskId:", taskId);
         console.log("isImportant:", isImportant.toString());
         
@@ -265,6 +385,12 @@ async function updateTaskImportance(taskId, isImportant) {
             credentials: "same-origin"
         });
         
+        // The task changed elsewhere, so the change was refused
+        if (response.status === 412) {
+            const conflict = await response.json();
+            return { conflict: conflict.current };
+        }
+        
         if (!response.ok) {
             // Get the error text from the response
             const errorText = await response.text();
@@ -289,10 +415,26 @@ const editModeContainer = document.getElementById('editModeContainer');
 const editTaskButton = document.getElementById('editTaskButton');
 const saveTaskButton = document.getElementById('saveTaskButton');
 const cancelEditButton = document.getElementById('cancelEditButton');
+const conflictContainer = document.getElementById('conflictContainer');
+const overwriteTaskButton = document.getElementById('overwriteTaskButton');
+const useCurrentTaskButton = document.getElementById('useCurrentTaskButton');
 
 // Current task being viewed/edited
 let currentTaskId = null;
 
+// Version of the task shown in the modal, sent back with edits so they can't overwrite newer changes
+let currentTaskEtag = '';
+
+// Notes as shown in the modal, so they are only saved when edited
+let currentTaskNotes = '';
+
+// Recurrence as shown in the edit form, so it is only saved when edited
+let currentRecurrenceForm = '';
+
+// Edits refused because the task changed, and the task as it is now
+let conflictedTaskData = null;
+let conflictCurrentTask = null;
+
 // Open task details modal
 function openTaskDetails(event, taskElement) {
     // Prevent event from propagating (avoid starting drag)
@@ -311,6 +453,7 @@ function openTaskDetails(event, taskElement) {
             if (task) {
                 // Update modal with task details
                 updateModalWithTaskDetails(task);
+                loadTaskResources(taskId);
                 
                 // Show the modal
                 modal.style.display = "block";
@@ -328,11 +471,11 @@ function openTaskDetails(event, taskElement) {
         });
 }
 
-// Fetch task details from the server
-async function fetchTaskDetails(taskId) {
+// Fetch task details from the server; taskList is needed for tasks without a card yet
+async function fetchTaskDetails(taskId, taskList = taskListId(taskId)) {
     try {
         const params = new URLSearchParams();
-        params.append("listId", listId);
+        params.append("listId", taskList);
         params.append("taskId", taskId);
         
         const response = await fetch(`/api/getTaskDetails?${params.toString()}`, {
@@ -358,9 +501,19 @@ function updateModalWithTaskDetails(task) {
     // Update view mode
     document.getElementById('modalTaskTitle').textContent = task.title;
     document.getElementById('viewTaskTitle').textContent = task.title;
-    document.getElementById('viewTaskStatus').textContent = task.status || 'Not Started';
+    document.getElementById('viewTaskStatus').textContent = statusLabel(task.status || 'notStarted');
     document.getElementById('viewTaskImportance').textContent = task.importance === 'high' ? 'High' : 'Normal';
     document.getElementById('viewTaskDueDate').textContent = task.dueDateTime || 'None';
+    document.getElementById('viewTaskStartDate').textContent = task.startDateTime || 'None';
+    document.getElementById('viewTaskReminder').textContent = task.isReminderOn && task.reminderDateTime
+        ? new Date(task.reminderDateTime).toLocaleString()
+        : 'None';
+    document.getElementById('viewTaskRecurrence').textContent = task.recurrenceText || 'Never';
+    document.getElementById('viewTaskCompleted').textContent = task.completedDateTime || '';
+    document.getElementById('viewTaskCompletedRow').style.display = task.completedDateTime ? 'block' : 'none';
+    currentTaskNotes = notesText(task);
+    document.getElementById('viewTaskNotes').textContent = currentTaskNotes || 'None';
+    renderChecklist(task.checklistItems || []);
     document.getElementById('viewTaskCategories').textContent = task.categories && task.categories.length > 0 
         ? task.categories.join(', ') 
         : 'None';
@@ -368,14 +521,7 @@ function updateModalWithTaskDetails(task) {
     // Update edit mode form
     document.getElementById('editTaskTitle').value = task.title;
     
-    const statusSelect = document.getElementById('editTaskStatus');
-    if (task.status === 'completed') {
-        statusSelect.value = 'completed';
-    } else if (task.categories && task.categories.includes('Doing')) {
-        statusSelect.value = 'inProgress';
-    } else {
-        statusSelect.value = 'notStarted';
-    }
+    document.getElementById('editTaskStatus').value = editStatusOf(task);
     
     document.getElementById('editTaskImportance').value = task.importance === 'high' ? 'high' : 'normal';
     
@@ -389,26 +535,68 @@ function updateModalWithTaskDetails(task) {
         dueDateInput.value = '';
     }
     
+    document.getElementById('editTaskStartDate').value = task.startDateTimeRaw ? task.startDateTimeRaw.split('T')[0] : '';
+    document.getElementById('editTaskReminderOn').checked = !!task.isReminderOn;
+    document.getElementById('editTaskReminder').value = task.reminderDateTime ? toLocalInputValue(new Date(task.reminderDateTime)) : '';
     document.getElementById('editTaskCategories').value = task.categories ? task.categories.join(', ') : '';
+    document.getElementById('editTaskNotes').value = currentTaskNotes;
+    populateRecurrenceForm(task.recurrence);
     
-    // Reset delete confirmation if it was previously shown
+    // Remember the version being shown
+    currentTaskEtag = task.etag || '';
+    
+    // Reset delete confirmation and the move picker if they were previously shown
     resetDeleteConfirmation();
+    resetMoveTask();
     
     // Show view mode container, hide edit mode container
-    viewModeContainer.style.display = 'block';
-    editModeContainer.style.display = 'none';
+    switchToViewMode();
+}
+
+// Plain text of a task's notes, which may have been written as HTML in another app
+function notesText(task) {
+    if (!task.body) return '';
+    if (task.bodyType === 'html') {
+        return new DOMParser().parseFromString(task.body, 'text/html').body.textContent.trim();
+    }
+    return task.body;
+}
+
+// Format a date as the value of a datetime-local input, in the user's time zone
+function toLocalInputValue(date) {
+    const pad = n => String(n).padStart(2, '0');
+    return `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(date.getDate())}T${pad(date.getHours())}:${pad(date.getMinutes())}`;
+}
+
+// Status as chosen in the edit form's Status select. The select offers the statuses of the board's
+// columns; a status set in another app is added to it, so saving the task doesn't change it.
+function editStatusOf(task) {
+    const status = task.status || 'notStarted';
+    const select = document.getElementById('editTaskStatus');
+    if (![...select.options].some(option => option.value === status)) {
+        select.add(new Option(status, status));
+    }
+    return status;
+}
+
+// Name of a status as the edit form's Status select shows it
+function statusLabel(status) {
+    const option = [...document.getElementById('editTaskStatus').options].find(option => option.value === status);
+    return option ? option.text : status;
 }
 
 // Switch to edit mode
 function switchToEditMode() {
     viewModeContainer.style.display = 'none';
     editModeContainer.style.display = 'block';
+    conflictContainer.style.display = 'none';
 }
 
 // Switch to view mode
 function switchToViewMode() {
     viewModeContainer.style.display = 'block';
     editModeContainer.style.display = 'none';
+    conflictContainer.style.display = 'none';
 }
 
 // Save task changes
@@ -418,13 +606,21 @@ async function saveTaskChanges() {
     const status = document.getElementById('editTaskStatus').value;
     const importance = document.getElementById('editTaskImportance').value;
     const dueDate = document.getElementById('editTaskDueDate').value;
+    const startDate = document.getElementById('editTaskStartDate').value;
+    const reminderOn = document.getElementById('editTaskReminderOn').checked;
+    const reminderInput = document.getElementById('editTaskReminder').value;
     const categoriesInput = document.getElementById('editTaskCategories').value.trim();
+    const notes = document.getElementById('editTaskNotes').value;
     
     // Validate input
     if (!title) {
         showToast("Title cannot be empty", "error");
         return;
     }
+    if (reminderOn && !reminderInput) {
+        showToast("Choose when to be reminded", "error");
+        return;
+    }
     
     // Parse categories
     let categories = [];
@@ -432,23 +628,49 @@ async function saveTaskChanges() {
         categories = categoriesInput.split(',').map(cat => cat.trim()).filter(cat => cat);
     }
     
-    // Prepare task data
+    // Prepare task data, based on the version the modal was showing
     const taskData = {
         title,
         status,
         importance,
         dueDate,
-        categories
+        startDate,
+        reminderOn,
+        reminder: reminderOn ? new Date(reminderInput).toISOString() : '',
+        categories,
+        etag: currentTaskEtag
     };
     
+    // Only send the notes if they were edited, so formatted notes from other apps are kept
+    if (notes !== currentTaskNotes) {
+        taskData.notes = notes;
+    }
+    
+    // Likewise the recurrence, so patterns the form can't show, such as "first Monday", are kept
+    const recurrence = recurrenceFormValues();
+    if (JSON.stringify(recurrence) !== currentRecurrenceForm) {
+        taskData.recurrence = recurrence;
+    }
+    if (recurrence.frequency !== 'none' && !dueDate) {
+        showToast("A repeating task needs a due date", "error");
+        return;
+    }
+    
+    await submitTaskChanges(taskData);
+}
+
+// Send edits to the server, showing the conflict dialog if the task changed since it was opened
+async function submitTaskChanges(taskData) {
     // Show loading overlay
     document.getElementById("loadingOverlay").style.display = "flex";
     
     try {
         // Send update to server
-        const success = await updateTask(currentTaskId, taskData);
+        const result = await updateTask(currentTaskId, taskData);
         
-        if (success) {
+        if (result && result.conflict) {
+            showConflict(taskData, result.conflict);
+        } else if (result) {
             // Refresh task details
             const updatedTask = await fetchTaskDetails(currentTaskId);
             updateModalWithTaskDetails(updatedTask);
@@ -459,7 +681,12 @@ async function saveTaskChanges() {
             // Switch back to view mode
             switchToViewMode();
             
-            showToast("Task updated successfully", "success");
+            if (result.nextOccurrences && result.nextOccurrences.length > 0) {
+                showNextOccurrences(result.nextOccurrences, taskListId(currentTaskId));
+                showToast("Task completed. The next occurrence was added to the board", "success");
+            } else {
+                showToast("Task updated successfully", "success");
+            }
         } else {
             showToast("Failed to update task", "error");
         }
@@ -472,20 +699,52 @@ async function saveTaskChanges() {
     }
 }
 
-// Update task on the server
+// Update task on the server. Returns the server's response on success, which lists any
+// next occurrence of a completed recurring task, or { conflict: task } with the current
+// task if it was changed elsewhere since taskData.etag was read.
 async function updateTask(taskId, taskData) {
     try {
         const params = new URLSearchParams();
-        params.append("listId", listId);
+        params.append("listId", taskListId(taskId));
         params.append("taskId", taskId);
         params.append("title", taskData.title);
         params.append("status", taskData.status);
         params.append("importance", taskData.importance);
+        params.append("clientId", clientId);
+        
+        if (taskData.etag) {
+            params.append("etag", taskData.etag);
+        }
         
         if (taskData.dueDate) {
             params.append("dueDate", taskData.dueDate);
         }
         
+        if (taskData.startDate) {
+            params.append("startDate", taskData.startDate);
+        }
+        
+        if (taskData.reminderOn) {
+            params.append("isReminderOn", "true");
+            params.append("reminderDateTime", taskData.reminder);
+        }
+        
+        if (taskData.notes !== undefined) {
+            params.append("body", taskData.notes);
+        }
+        
+        if (taskData.recurrence !== undefined) {
+            const recurrence = taskData.recurrence;
+            params.append("recurrence", recurrence.frequency);
+            if (recurrence.frequency !== 'none') {
+                params.append("recurrenceInterval", recurrence.interval);
+                params.append("recurrenceDays", recurrence.days.join(','));
+                params.append("recurrenceEnd", recurrence.end);
+                params.append("recurrenceEndDate", recurrence.endDate);
+                params.append("recurrenceCount", recurrence.count);
+            }
+        }
+        
         if (taskData.categories && taskData.categories.length > 0) {
             params.append("categories", JSON.stringify(taskData.categories));
         }
@@ -499,19 +758,82 @@ async function updateTask(taskId, taskData) {
             credentials: "same-origin"
         });
         
+        if (response.status === 412) {
+            const conflict = await response.json();
+            return { conflict: conflict.current };
+        }
+        
         if (!response.ok) {
             const errorText = await response.text();
             console.error("Server error:", errorText);
             throw new Error(errorText || "Server error");
         }
         
-        return true;
+        return await response.json();
     } catch (error) {
         console.error("Error updating task:", error);
         throw error;
     }
 }
 
+// ==================== Edit Conflicts ====================
+
+// Show the user's refused edits next to the task as someone else left it
+function showConflict(taskData, current) {
+    conflictedTaskData = taskData;
+    conflictCurrentTask = current;
+    
+    const rows = [
+        ['Title', taskData.title, current.title],
+        ['Status', statusLabel(taskData.status), statusLabel(editStatusOf(current))],
+        ['Importance', taskData.importance === 'high' ? 'High' : 'Normal', current.importance === 'high' ? 'High' : 'Normal'],
+        ['Due Date', taskData.dueDate || 'None', current.dueDateTimeRaw ? current.dueDateTimeRaw.split('T')[0] : 'None'],
+        ['Start Date', taskData.startDate || 'None', current.startDateTimeRaw ? current.startDateTimeRaw.split('T')[0] : 'None'],
+        ['Reminder',
+            taskData.reminderOn ? new Date(taskData.reminder).toLocaleString() : 'None',
+            current.isReminderOn && current.reminderDateTime ? new Date(current.reminderDateTime).toLocaleString() : 'None'],
+        ['Repeats',
+            taskData.recurrence !== undefined ? recurrenceFormText(taskData.recurrence) : current.recurrenceText || 'Never',
+            current.recurrenceText || 'Never'],
+        ['Categories', taskData.categories.join(', ') || 'None', (current.categories || []).join(', ') || 'None'],
+        ['Notes', taskData.notes !== undefined ? taskData.notes || 'None' : notesText(current) || 'None', notesText(current) || 'None']
+    ];
+    
+    const tbody = document.getElementById('conflictRows');
+    tbody.innerHTML = '';
+    rows.forEach(([label, mine, theirs]) => {
+        const row = document.createElement('tr');
+        if (mine !== theirs) {
+            row.classList.add('conflict-differs');
+        }
+        [label, mine, theirs].forEach(text => {
+            const cell = document.createElement('td');
+            cell.textContent = text;
+            row.appendChild(cell);
+        });
+        tbody.appendChild(row);
+    });
+    
+    // The card should show what is actually saved
+    updateTaskCardInUI(currentTaskId, current);
+    
+    viewModeContainer.style.display = 'none';
+    editModeContainer.style.display = 'none';
+    conflictContainer.style.display = 'block';
+}
+
+// Save the refused edits on top of the current version
+function overwriteWithMyChanges() {
+    const taskData = { ...conflictedTaskData, etag: conflictCurrentTask.etag };
+    submitTaskChanges(taskData);
+}
+
+// Drop the refused edits and show the current version
+function useCurrentTask() {
+    updateModalWithTaskDetails(conflictCurrentTask);
+    showToast("Your changes were discarded", "success");
+}
+
 // Update task card in the UI
 function updateTaskCardInUI(taskId, task) {
     const taskCard = document.querySelector(`[data-task-id="${taskId}"]`);
@@ -540,66 +862,30 @@ function updateTaskCardInUI(taskId, task) {
     }
     
     // Update categories
-    const categoriesElement = taskCard.querySelector('.task-categories');
-    if (categoriesElement) {
-        // Clear existing categories
-        categoriesElement.innerHTML = '';
-        
-        // Add new categories
-        if (task.categories && task.categories.length > 0) {
-            task.categories.forEach(category => {
-                const categoryTag = document.createElement('span');
-                categoryTag.className = 'category-tag';
-                categoryTag.textContent = category;
-                categoriesElement.appendChild(categoryTag);
-            });
-        }
-    } else if (task.categories && task.categories.length > 0) {
-        // Create categories container if it doesn't exist
-        const newCategoriesElement = document.createElement('div');
-        newCategoriesElement.className = 'task-categories';
-        
-        // Add categories
-        task.categories.forEach(category => {
-            const categoryTag = document.createElement('span');
-            categoryTag.className = 'category-tag';
-            categoryTag.textContent = category;
-            newCategoriesElement.appendChild(categoryTag);
-        });
-        
-        taskCard.appendChild(newCategoriesElement);
-    }
+    renderCardCategories(taskCard, task.categories);
     
-    // Update due date
-    let dueDateElement = taskCard.querySelector('.task-due');
-    if (task.dueDateTime) {
-        if (dueDateElement) {
-            dueDateElement.textContent = `Due: ${task.dueDateTime}`;
-        } else {
-            dueDateElement = document.createElement('span');
-            dueDateElement.className = 'task-due';
-            dueDateElement.textContent = `Due: ${task.dueDateTime}`;
-            taskCard.appendChild(dueDateElement);
-        }
-    } else if (dueDateElement) {
-        dueDateElement.remove();
+    // Update dates
+    setCardLine(taskCard, 'task-due', task.dueDateTime ? `Due: ${task.dueDateTime}` : '');
+    setCardLine(taskCard, 'task-start', task.startDateTime ? `Starts: ${task.startDateTime}` : '');
+    setCardLine(taskCard, 'task-completed-date',
+        task.status === 'completed' && task.completedDateTime ? `Completed: ${task.completedDateTime}` : '');
+    setCardLine(taskCard, 'task-recurrence', task.recurrenceText ? `🔁 ${task.recurrenceText}` : '');
+    
+    // Update the notes, reminder and attachment flags
+    renderCardIndicators(taskCard, notesText(task).trim() !== '', !!task.isReminderOn, !!task.hasAttachments);
+    
+    // Update checklist progress
+    if (task.checklistItems) {
+        setCardLine(taskCard, 'task-checklist', checklistProgress(task.checklistItems));
     }
     
-    // If task status changed, move it to the appropriate column
+    // If the task's column changed, move it to the appropriate column
     const currentColumn = taskCard.closest('.kanban-column');
     if (currentColumn) {
         const currentColumnName = currentColumn.getAttribute('data-column');
-        let targetColumnName = '';
+        const targetColumnName = task.column;
         
-        if (task.status === 'completed') {
-            targetColumnName = 'Done';
-        } else if (task.categories && task.categories.includes('Doing')) {
-            targetColumnName = 'Doing';
-        } else {
-            targetColumnName = 'Not Started';
-        }
-        
-        if (currentColumnName !== targetColumnName) {
+        if (targetColumnName && currentColumnName !== targetColumnName) {
             // Find the target column
             const targetColumn = document.querySelector(`.kanban-column[data-column="${targetColumnName}"] .column-content`);
             if (targetColumn) {
@@ -611,8 +897,475 @@ function updateTaskCardInUI(taskId, task) {
                 
                 // Move the task card to the new column
                 targetColumn.appendChild(taskCard);
+                updateColumnCounts();
+            }
+        }
+    }
+}
+
+// Set the text of a line on a card, adding the line if needed and removing it if text is empty
+function setCardLine(taskCard, className, text) {
+    let line = taskCard.querySelector(`.${className}`);
+    if (!text) {
+        if (line) line.remove();
+        return;
+    }
+    if (!line) {
+        line = document.createElement('span');
+        line.className = className;
+        taskCard.appendChild(line);
+    }
+    line.textContent = text;
+}
+
+// Show the notes, reminder and attachment flags on a card
+function renderCardIndicators(taskCard, hasNotes, reminderOn, hasAttachments) {
+    const existing = taskCard.querySelector('.task-indicators');
+    if (existing) existing.remove();
+    if (!hasNotes && !reminderOn && !hasAttachments) return;
+    
+    const indicators = document.createElement('span');
+    indicators.className = 'task-indicators';
+    if (hasNotes) {
+        const notesFlag = document.createElement('span');
+        notesFlag.className = 'task-notes-indicator';
+        notesFlag.title = 'Has notes';
+        notesFlag.textContent = '📝';
+        indicators.appendChild(notesFlag);
+    }
+    if (reminderOn) {
+        const reminderFlag = document.createElement('span');
+        reminderFlag.className = 'task-reminder-indicator';
+        reminderFlag.title = 'Reminder set';
+        reminderFlag.textContent = '🔔';
+        indicators.appendChild(reminderFlag);
+    }
+    if (hasAttachments) {
+        const attachmentsFlag = document.createElement('span');
+        attachmentsFlag.className = 'task-attachments-indicator';
+        attachmentsFlag.title = 'Has attachments';
+        attachmentsFlag.textContent = '📎';
+        indicators.appendChild(attachmentsFlag);
+    }
+    taskCard.appendChild(indicators);
+}
+
+// ==================== Recurrence ====================
+
+// Labels of the edit form's Repeat options
+const recurrenceLabels = {
+    none: "Doesn't repeat",
+    daily: 'Daily',
+    weekly: 'Weekly',
+    monthly: 'Monthly',
+    yearly: 'Yearly'
+};
+
+// Units of the recurrence interval, by frequency
+const recurrenceUnits = {
+    daily: 'day(s)',
+    weekly: 'week(s)',
+    monthly: 'month(s)',
+    yearly: 'year(s)'
+};
+
+// Fill the edit form's Repeat fields from a task's recurrence, as Microsoft Graph describes it
+function populateRecurrenceForm(recurrence) {
+    let frequency = 'none';
+    if (recurrence) {
+        const type = recurrence.pattern.type;
+        frequency = type === 'daily' || type === 'weekly' ? type : type.endsWith('Monthly') ? 'monthly' : 'yearly';
+    }
+    const pattern = recurrence ? recurrence.pattern : {};
+    const range = recurrence ? recurrence.range : {};
+    
+    document.getElementById('editTaskRecurrence').value = frequency;
+    document.getElementById('editTaskRecurrenceInterval').value = pattern.interval || 1;
+    document.querySelectorAll('#recurrenceDays input').forEach(checkbox => {
+        checkbox.checked = frequency === 'weekly' && (pattern.daysOfWeek || []).includes(checkbox.value);
+    });
+    document.getElementById('editTaskRecurrenceEnd').value = range.type === 'endDate' || range.type === 'numbered' ? range.type : 'noEnd';
+    document.getElementById('editTaskRecurrenceEndDate').value = range.type === 'endDate' ? range.endDate : '';
+    document.getElementById('editTaskRecurrenceCount').value = range.numberOfOccurrences || 10;
+    
+    currentRecurrenceForm = JSON.stringify(recurrenceFormValues());
+    updateRecurrenceOptions();
+}
+
+// The Repeat fields of the edit form
+function recurrenceFormValues() {
+    const frequency = document.getElementById('editTaskRecurrence').value;
+    if (frequency === 'none') {
+        return { frequency };
+    }
+    return {
+        frequency,
+        interval: document.getElementById('editTaskRecurrenceInterval').value,
+        days: frequency === 'weekly'
+            ? Array.from(document.querySelectorAll('#recurrenceDays input:checked')).map(checkbox => checkbox.value)
+            : [],
+        end: document.getElementById('editTaskRecurrenceEnd').value,
+        endDate: document.getElementById('editTaskRecurrenceEndDate').value,
+        count: document.getElementById('editTaskRecurrenceCount').value
+    };
+}
+
+// A short description of the Repeat fields, such as "Weekly, every 2 week(s)"
+function recurrenceFormText(recurrence) {
+    let text = recurrenceLabels[recurrence.frequency];
+    if (recurrence.frequency !== 'none' && recurrence.interval > 1) {
+        text += `, every ${recurrence.interval} ${recurrenceUnits[recurrence.frequency]}`;
+    }
+    return text;
+}
+
+// Show only the Repeat fields that apply to the chosen frequency and end
+function updateRecurrenceOptions() {
+    const frequency = document.getElementById('editTaskRecurrence').value;
+    const end = document.getElementById('editTaskRecurrenceEnd').value;
+    
+    document.getElementById('recurrenceOptions').style.display = frequency === 'none' ? 'none' : 'block';
+    document.getElementById('recurrenceUnit').textContent = recurrenceUnits[frequency] || '';
+    document.getElementById('recurrenceDays').style.display = frequency === 'weekly' ? 'flex' : 'none';
+    document.getElementById('editTaskRecurrenceEndDate').style.display = end === 'endDate' ? 'block' : 'none';
+    document.getElementById('editTaskRecurrenceCount').style.display = end === 'numbered' ? 'block' : 'none';
+}
+
+// Add the cards of tasks created by completing a recurring task in taskList
+function showNextOccurrences(taskIds, taskList) {
+    taskIds.forEach(id => refreshTaskCard(id, taskList));
+}
+
+// ==================== Checklist ====================
+
+// Checklist progress such as "☑ 3/5", or '' for an empty checklist
+function checklistProgress(items) {
+    if (items.length === 0) return '';
+    const checked = items.filter(item => item.isChecked).length;
+    return `☑ ${checked}/${items.length}`;
+}
+
+// Show a task's checklist in the modal
+function renderChecklist(items) {
+    document.getElementById('checklistProgress').textContent = checklistProgress(items) || 'None';
+    
+    const list = document.getElementById('checklistItems');
+    list.innerHTML = '';
+    items.forEach(item => {
+        const row = document.createElement('li');
+        if (item.isChecked) {
+            row.classList.add('checked');
+        }
+        
+        const checkbox = document.createElement('input');
+        checkbox.type = 'checkbox';
+        checkbox.checked = item.isChecked;
+        checkbox.addEventListener('change', () => {
+            changeChecklist("/api/toggleChecklistItem", { itemId: item.id, isChecked: checkbox.checked ? "true" : "false" });
+        });
+        
+        const name = document.createElement('span');
+        name.className = 'checklist-name';
+        name.textContent = item.displayName;
+        name.title = 'Click to rename';
+        name.addEventListener('click', () => renameChecklistItem(name, item));
+        
+        const deleteButton = document.createElement('button');
+        deleteButton.className = 'checklist-delete';
+        deleteButton.textContent = '×';
+        deleteButton.title = 'Delete';
+        deleteButton.addEventListener('click', () => {
+            changeChecklist("/api/deleteChecklistItem", { itemId: item.id });
+        });
+        
+        row.appendChild(checkbox);
+        row.appendChild(name);
+        row.appendChild(deleteButton);
+        list.appendChild(row);
+    });
+}
+
+// Edit a checklist item's name in place; Enter or leaving the field saves, Escape cancels
+function renameChecklistItem(nameElement, item) {
+    const input = document.createElement('input');
+    input.type = 'text';
+    input.className = 'form-control';
+    input.value = item.displayName;
+    nameElement.replaceWith(input);
+    input.focus();
+    
+    let done = false;
+    const finish = (save) => {
+        if (done) return;
+        done = true;
+        const displayName = input.value.trim();
+        if (save && displayName && displayName !== item.displayName) {
+            changeChecklist("/api/updateChecklistItem", { itemId: item.id, displayName });
+        } else {
+            input.replaceWith(nameElement);
+        }
+    };
+    input.addEventListener('keydown', (event) => {
+        if (event.key === 'Enter') finish(true);
+        if (event.key === 'Escape') finish(false);
+    });
+    input.addEventListener('blur', () => finish(true));
+}
+
+// Add the step typed in the modal to the checklist
+function addChecklistItem() {
+    const input = document.getElementById('newChecklistItem');
+    const displayName = input.value.trim();
+    if (!displayName) return;
+    
+    changeChecklist("/api/createChecklistItem", { displayName }).then(success => {
+        if (success) {
+            input.value = '';
+        }
+    });
+}
+
+// Send a checklist change for the open task, then show the task as saved.
+// Returns true on success.
+async function changeChecklist(url, values) {
+    const taskId = currentTaskId;
+    try {
+        const params = new URLSearchParams(values);
+        params.append("listId", taskListId(taskId));
+        params.append("taskId", taskId);
+        params.append("clientId", clientId);
+        
+        const response = await fetch(url, {
+            method: "POST",
+            headers: {
+                'Content-Type': 'application/x-www-form-urlencoded',
+            },
+            body: params.toString(),
+            credentials: "same-origin"
+        });
+        
+        if (!response.ok) {
+            const errorText = await response.text();
+            console.error("Server error:", errorText);
+            throw new Error(errorText || "Server error");
+        }
+        
+        // Reload the task so the checklist, the card's progress and the modal's version are current
+        await reloadTask(taskId);
+        return true;
+    } catch (error) {
+        console.error("Error updating checklist:", error);
+        showToast("Failed to update checklist", "error");
+        return false;
+    }
+}
+
+// Reload a task after changing it, so its card and the modal's version are current
+async function reloadTask(taskId) {
+    const task = await fetchTaskDetails(taskId);
+    updateTaskCardInUI(taskId, task);
+    if (currentTaskId === taskId && viewModeContainer.style.display !== "none") {
+        updateModalWithTaskDetails(task);
+    }
+}
+
+// ==================== Links and Attachments ====================
+
+// Largest file Microsoft To Do accepts as an attachment
+const maxAttachmentSize = 25 * 1024 * 1024;
+
+// Load the links and attachments of the task open in the modal
+async function loadTaskResources(taskId) {
+    const params = new URLSearchParams();
+    params.append("listId", taskListId(taskId));
+    params.append("taskId", taskId);
+    
+    try {
+        const [links, attachments] = await Promise.all(['/api/linkedResources', '/api/attachments'].map(async url => {
+            const response = await fetch(`${url}?${params.toString()}`, {
+                method: "GET",
+                credentials: "same-origin"
+            });
+            if (!response.ok) {
+                const errorText = await response.text();
+                throw new Error(errorText || "Server error");
             }
+            return response.json();
+        }));
+        
+        // Ignore the results if another task was opened meanwhile
+        if (currentTaskId !== taskId) return;
+        renderLinks(links);
+        renderAttachments(attachments);
+    } catch (error) {
+        console.error("Error loading links and attachments:", error);
+        showToast("Failed to load links and attachments", "error");
+    }
+}
+
+// Show a task's links in the modal; only web links are clickable
+function renderLinks(links) {
+    const list = document.getElementById('linkedResources');
+    list.innerHTML = '';
+    if (links.length === 0) {
+        list.appendChild(emptyResourceRow());
+    }
+    links.forEach(link => {
+        const row = document.createElement('li');
+        
+        let title;
+        if (/^https?:\/\//i.test(link.webUrl)) {
+            title = document.createElement('a');
+            title.href = link.webUrl;
+            title.target = '_blank';
+            title.rel = 'noopener noreferrer';
+        } else {
+            title = document.createElement('span');
+        }
+        title.textContent = link.displayName || link.webUrl;
+        title.title = link.webUrl;
+        row.appendChild(title);
+        
+        if (link.applicationName) {
+            const app = document.createElement('span');
+            app.className = 'resource-meta';
+            app.textContent = link.applicationName;
+            row.appendChild(app);
+        }
+        
+        row.appendChild(resourceDeleteButton(() => {
+            changeTaskResources("/api/deleteLinkedResource", new URLSearchParams({ resourceId: link.id }));
+        }));
+        list.appendChild(row);
+    });
+}
+
+// Show a task's attachments in the modal as download links
+function renderAttachments(attachments) {
+    const list = document.getElementById('attachments');
+    list.innerHTML = '';
+    if (attachments.length === 0) {
+        list.appendChild(emptyResourceRow());
+    }
+    attachments.forEach(attachment => {
+        const row = document.createElement('li');
+        
+        const params = new URLSearchParams();
+        params.append("listId", taskListId(currentTaskId));
+        params.append("taskId", currentTaskId);
+        params.append("attachmentId", attachment.id);
+        const download = document.createElement('a');
+        download.href = `/api/downloadAttachment?${params.toString()}`;
+        download.download = attachment.name;
+        download.textContent = attachment.name;
+        row.appendChild(download);
+        
+        const size = document.createElement('span');
+        size.className = 'resource-meta';
+        size.textContent = formatFileSize(attachment.size);
+        row.appendChild(size);
+        
+        row.appendChild(resourceDeleteButton(() => {
+            changeTaskResources("/api/deleteAttachment", new URLSearchParams({ attachmentId: attachment.id }));
+        }));
+        list.appendChild(row);
+    });
+}
+
+// A list row saying there is nothing to show
+function emptyResourceRow() {
+    const row = document.createElement('li');
+    row.className = 'resource-meta';
+    row.textContent = 'None';
+    return row;
+}
+
+// A delete button for a link or attachment row
+function resourceDeleteButton(onDelete) {
+    const deleteButton = document.createElement('button');
+    deleteButton.className = 'checklist-delete';
+    deleteButton.textContent = '×';
+    deleteButton.title = 'Remove';
+    deleteButton.addEventListener('click', onDelete);
+    return deleteButton;
+}
+
+// A file size such as "1.4 MB"
+function formatFileSize(bytes) {
+    if (bytes < 1024) return `${bytes} B`;
+    if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+    return `${(bytes / (1024 * 1024)).toFixed(1)} MB`;
+}
+
+// Link the open task to the URL typed in the modal
+function addLink() {
+    const urlInput = document.getElementById('newLinkUrl');
+    const titleInput = document.getElementById('newLinkTitle');
+    const webUrl = urlInput.value.trim();
+    if (!/^https?:\/\/\S+$/i.test(webUrl)) {
+        showToast("Enter a link starting with http:// or https://", "error");
+        return;
+    }
+    
+    const values = new URLSearchParams({ webUrl, displayName: titleInput.value.trim() });
+    changeTaskResources("/api/createLinkedResource", values).then(success => {
+        if (success) {
+            urlInput.value = '';
+            titleInput.value = '';
         }
+    });
+}
+
+// Attach the file chosen in the modal to the open task
+function uploadAttachment() {
+    const fileInput = document.getElementById('attachmentFile');
+    const file = fileInput.files[0];
+    if (!file) return;
+    if (file.size > maxAttachmentSize) {
+        showToast("Attachments can be at most 25 MB", "error");
+        return;
+    }
+    
+    const values = new FormData();
+    values.append("file", file);
+    changeTaskResources("/api/uploadAttachment", values).then(success => {
+        if (success) {
+            fileInput.value = '';
+        }
+    });
+}
+
+// Send a link or attachment change for the open task, then reload them and the task.
+// values is URLSearchParams for a form, or FormData for a file upload. Returns true on success.
+async function changeTaskResources(url, values) {
+    const taskId = currentTaskId;
+    document.getElementById("loadingOverlay").style.display = "flex";
+    try {
+        values.append("listId", taskListId(taskId));
+        values.append("taskId", taskId);
+        values.append("clientId", clientId);
+        
+        // A FormData body is sent as multipart with its own Content-Type
+        const response = await fetch(url, {
+            method: "POST",
+            body: values,
+            credentials: "same-origin"
+        });
+        
+        if (!response.ok) {
+            const errorText = await response.text();
+            console.error("Server error:", errorText);
+            throw new Error(errorText || "Server error");
+        }
+        
+        await Promise.all([loadTaskResources(taskId), reloadTask(taskId)]);
+        return true;
+    } catch (error) {
+        console.error("Error updating links or attachments:", error);
+        showToast("Failed to update links or attachments", "error");
+        return false;
+    } finally {
+        document.getElementById("loadingOverlay").style.display = "none";
     }
 }
 
@@ -641,41 +1394,9 @@ async function addNewTask() {
             // Clear the input
             input.value = '';
             
-            // Refresh the column or add the new task to the UI
-            const columnContent = document.querySelector('.kanban-column[data-column="Not Started"] .column-content');
-            
-            // Remove the "no tasks" message if it exists
-            const noTasksMessage = columnContent.querySelector(".no-tasks");
-            if (noTasksMessage) {
-                noTasksMessage.remove();
-            }
-            
-            // Create a new task card element
-            const taskCard = document.createElement('div');
-            taskCard.className = 'task-card';
-            taskCard.draggable = true;
-            taskCard.setAttribute('ondragstart', 'drag(event)');
-            taskCard.setAttribute('data-task-id', taskId);
-            taskCard.setAttribute('data-importance', 'false');
-            taskCard.setAttribute('onclick', 'openTaskDetails(event, this)');
-            
-            // Create task title element
-            const taskTitle = document.createElement('div');
-            taskTitle.className = 'task-title';
-            taskTitle.textContent = title;
-            
-            // Create importance star element
-            const importanceStar = document.createElement('div');
-            importanceStar.className = 'importance-star';
-            importanceStar.textContent = '★';
-            importanceStar.setAttribute('onclick', 'toggleImportance(event, this.parentElement)');
-            
-            // Add elements to task card
-            taskCard.appendChild(taskTitle);
-            taskCard.appendChild(importanceStar);
-            
-            // Add the task card to the column
-            columnContent.appendChild(taskCard);
+            // Add the new task to the first column of the board
+            const columnContent = document.querySelector('.kanban-column .column-content');
+            addTaskCard(columnContent, taskId, title, listId);
             
             showToast("Task added successfully", "success");
         } else {
@@ -690,12 +1411,63 @@ async function addNewTask() {
     }
 }
 
+// Create a task card for a task in taskList and add it to the bottom of a column
+function addTaskCard(columnContent, taskId, title, taskList) {
+    // Remove the "no tasks" message if it exists
+    const noTasksMessage = columnContent.querySelector(".no-tasks");
+    if (noTasksMessage) {
+        noTasksMessage.remove();
+    }
+    
+    // Create a new task card element
+    const taskCard = document.createElement('div');
+    taskCard.className = 'task-card';
+    taskCard.draggable = true;
+    taskCard.setAttribute('ondragstart', 'drag(event)');
+    taskCard.setAttribute('data-task-id', taskId);
+    taskCard.setAttribute('data-list-id', taskList);
+    taskCard.setAttribute('data-importance', 'false');
+    taskCard.setAttribute('onclick', 'openTaskDetails(event, this)');
+    
+    // Create task title element
+    const taskTitle = document.createElement('div');
+    taskTitle.className = 'task-title';
+    taskTitle.textContent = title;
+    
+    // Create importance star element
+    const importanceStar = document.createElement('div');
+    importanceStar.className = 'importance-star';
+    importanceStar.textContent = '★';
+    importanceStar.setAttribute('onclick', 'toggleImportance(event, this.parentElement)');
+    
+    // Add elements to task card
+    taskCard.appendChild(taskTitle);
+    taskCard.appendChild(importanceStar);
+    
+    // Tag the card with its list on an aggregate board
+    const list = boardLists.find(list => list.id === taskList);
+    if (list) {
+        const listTag = document.createElement('span');
+        listTag.className = 'task-list-tag';
+        listTag.title = 'List';
+        listTag.textContent = list.name;
+        taskCard.appendChild(listTag);
+    }
+    
+    // Add the task card to the column
+    columnContent.appendChild(taskCard);
+    updateColumnCounts();
+    
+    return taskCard;
+}
+
 // Create a new task on the server
 async function createNewTask(title) {
     try {
         const params = new URLSearchParams();
         params.append("listId", listId);
         params.append("title", title);
+        params.append("clientId", clientId);
         
         const response = await fetch("/api/createTask", {
             method: "POST",
@@ -737,6 +1509,7 @@ if (closeBtn) {
     closeBtn.addEventListener('click', () => {
         modal.style.display = "none";
         resetDeleteConfirmation();
+        resetMoveTask();
     });
 }
 
@@ -752,6 +1525,56 @@ if (cancelEditButton) {
     cancelEditButton.addEventListener('click', switchToViewMode);
 }
 
+const addChecklistItemButton = document.getElementById('addChecklistItemButton');
+const newChecklistItemInput = document.getElementById('newChecklistItem');
+
+if (addChecklistItemButton) {
+    addChecklistItemButton.addEventListener('click', addChecklistItem);
+}
+
+if (newChecklistItemInput) {
+    newChecklistItemInput.addEventListener('keyup', (event) => {
+        if (event.key === 'Enter') {
+            addChecklistItem();
+        }
+    });
+}
+
+const addLinkButton = document.getElementById('addLinkButton');
+const newLinkUrlInput = document.getElementById('newLinkUrl');
+const uploadAttachmentButton = document.getElementById('uploadAttachmentButton');
+
+if (addLinkButton) {
+    addLinkButton.addEventListener('click', addLink);
+}
+
+if (newLinkUrlInput) {
+    newLinkUrlInput.addEventListener('keyup', (event) => {
+        if (event.key === 'Enter') {
+            addLink();
+        }
+    });
+}
+
+if (uploadAttachmentButton) {
+    uploadAttachmentButton.addEventListener('click', uploadAttachment);
+}
+
+['editTaskRecurrence', 'editTaskRecurrenceEnd'].forEach(id => {
+    const select = document.getElementById(id);
+    if (select) {
+        select.addEventListener('change', updateRecurrenceOptions);
+    }
+});
+
+if (overwriteTaskButton) {
+    overwriteTaskButton.addEventListener('click', overwriteWithMyChanges);
+}
+
+if (useCurrentTaskButton) {
+    useCurrentTaskButton.addEventListener('click', useCurrentTask);
+}
+
 const deleteTaskButton = document.getElementById('deleteTaskButton');
 const confirmDeleteButton = document.getElementById('confirmDeleteButton');
 const cancelDeleteButton = document.getElementById('cancelDeleteButton');
@@ -774,6 +1597,7 @@ window.addEventListener('click', (event) => {
     if (event.target === modal) {
         modal.style.display = "none";
         resetDeleteConfirmation();
+        resetMoveTask();
     }
 });
 
@@ -815,22 +1639,7 @@ async function performDeleteTask() {
             modal.style.display = "none";
             
             // Remove the task card from the UI
-            const taskCard = document.querySelector(`[data-task-id="${currentTaskId}"]`);
-            if (taskCard) {
-                // Get the column before removing the task
-                const column = taskCard.closest('.column-content');
-                
-                // Remove the task card
-                taskCard.remove();
-                
-                // Check if column is now empty and add "no tasks" message if needed
-                if (column && !column.querySelector('.task-card')) {
-                    const noTasksMessage = document.createElement('div');
-                    noTasksMessage.className = 'no-tasks';
-                    noTasksMessage.innerHTML = '<p>No tasks in this column</p>';
-                    column.appendChild(noTasksMessage);
-                }
-            }
+            removeTaskCard(currentTaskId);
             
             showToast("Task deleted successfully", "success");
         } else {
@@ -847,12 +1656,34 @@ async function performDeleteTask() {
     }
 }
 
+// Remove a task card from the board
+function removeTaskCard(taskId) {
+    const taskCard = document.querySelector(`[data-task-id="${taskId}"]`);
+    if (!taskCard) return;
+    
+    // Get the column before removing the task
+    const column = taskCard.closest('.column-content');
+    
+    // Remove the task card
+    taskCard.remove();
+    updateColumnCounts();
+    
+    // Check if column is now empty and add "no tasks" message if needed
+    if (column && !column.querySelector('.task-card')) {
+        const noTasksMessage = document.createElement('div');
+        noTasksMessage.className = 'no-tasks';
+        noTasksMessage.innerHTML = '<p>No tasks in this column</p>';
+        column.appendChild(noTasksMessage);
+    }
+}
+
 // Delete task from server
 async function deleteTaskFromServer(taskId) {
     try {
         const params = new URLSearchParams();
-        params.append("listId", listId);
+        params.append("listId", taskListId(taskId));
         params.append("taskId", taskId);
+        params.append("clientId", clientId);
         
         const response = await fetch("/api/deleteTask", {
             method: "POST",
@@ -875,3 +1706,223 @@ async function deleteTaskFromServer(taskId) {
         throw error;
     }
 }
+
+// ==================== Move to Another List ====================
+
+const moveTaskButton = document.getElementById('moveTaskButton');
+const moveTaskPicker = document.querySelector('.move-task');
+const moveTaskList = document.getElementById('moveTaskList');
+
+// Show the lists the task can be moved to
+async function showMoveTask() {
+    try {
+        const response = await fetch("/api/lists", { credentials: "same-origin" });
+        if (!response.ok) {
+            throw new Error(await response.text() || "Server error");
+        }
+        const lists = (await response.json()).filter(list => list.id !== taskListId(currentTaskId));
+        if (lists.length === 0) {
+            showToast("There are no other lists to move the task to", "error");
+            return;
+        }
+        
+        moveTaskList.innerHTML = '';
+        lists.forEach(list => {
+            const option = document.createElement('option');
+            option.value = list.id;
+            option.textContent = list.displayName;
+            moveTaskList.appendChild(option);
+        });
+        
+        moveTaskPicker.style.display = 'inline-flex';
+        moveTaskButton.style.display = 'none';
+    } catch (error) {
+        console.error("Error loading lists:", error);
+        showToast("Failed to load lists: " + error.message, "error");
+    }
+}
+
+// Hide the list picker
+function resetMoveTask() {
+    if (moveTaskPicker) {
+        moveTaskPicker.style.display = 'none';
+    }
+    if (moveTaskButton) {
+        moveTaskButton.style.display = '';
+    }
+}
+
+// Move the open task to the chosen list
+async function performMoveTask() {
+    if (!currentTaskId || !moveTaskList.value) return;
+    
+    const targetName = moveTaskList.options[moveTaskList.selectedIndex].textContent;
+    document.getElementById("loadingOverlay").style.display = "flex";
+    
+    try {
+        const params = new URLSearchParams();
+        params.append("listId", taskListId(currentTaskId));
+        params.append("taskId", currentTaskId);
+        params.append("targetListId", moveTaskList.value);
+        params.append("etag", currentTaskEtag);
+        params.append("clientId", clientId);
+        
+        const response = await fetch("/api/moveTask", {
+            method: "POST",
+            headers: {
+                'Content-Type': 'application/x-www-form-urlencoded',
+            },
+            body: params.toString(),
+            credentials: "same-origin"
+        });
+        
+        // The task changed since it was opened; show it as it is now
+        if (response.status === 412) {
+            const conflict = await response.json();
+            updateModalWithTaskDetails(conflict.current);
+            updateTaskCardInUI(currentTaskId, conflict.current);
+            showToast("This task was changed by someone else. Check the changes and move it again.", "error");
+            return;
+        }
+        
+        if (!response.ok) {
+            const errorText = await response.text();
+            console.error("Server error:", errorText);
+            throw new Error(errorText || "Server error");
+        }
+        
+        // An aggregate board showing the destination list keeps the card, now in that list
+        const moved = await response.json();
+        modal.style.display = "none";
+        removeTaskCard(currentTaskId);
+        if (boardLists.some(list => list.id === moved.listId)) {
+            refreshTaskCard(moved.taskId, moved.listId);
+        }
+        showToast(`Task moved to ${targetName}`, "success");
+    } catch (error) {
+        console.error("Error moving task:", error);
+        showToast("Failed to move task: " + error.message, "error");
+    } finally {
+        document.getElementById("loadingOverlay").style.display = "none";
+        resetMoveTask();
+    }
+}
+
+if (moveTaskButton) {
+    moveTaskButton.addEventListener('click', showMoveTask);
+}
+
+const confirmMoveButton = document.getElementById('confirmMoveButton');
+if (confirmMoveButton) {
+    confirmMoveButton.addEventListener('click', performMoveTask);
+}
+
+const cancelMoveButton = document.getElementById('cancelMoveButton');
+if (cancelMoveButton) {
+    cancelMoveButton.addEventListener('click', resetMoveTask);
+}
+
+// ==================== Live Updates ====================
+
+// Bring a card in line with the server after another browser changed it,
+// adding the card if this board doesn't show it yet. taskList is needed for tasks without a card.
+async function refreshTaskCard(taskId, taskList = taskListId(taskId)) {
+    try {
+        if (boardFiltered && !document.querySelector(`[data-task-id="${taskId}"]`)) return;
+        
+        const task = await fetchTaskDetails(taskId, taskList);
+        
+        if (!document.querySelector(`[data-task-id="${taskId}"]`)) {
+            const columnContent = document.querySelector(`.kanban-column[data-column="${task.column}"] .column-content`)
+                || document.querySelector('.kanban-column .column-content');
+            addTaskCard(columnContent, taskId, task.title, taskList);
+        }
+        updateTaskCardInUI(taskId, task);
+        
+        // Keep an open details modal for this task current
+        if (currentTaskId === taskId && modal.style.display === "block" && viewModeContainer.style.display !== "none") {
+            updateModalWithTaskDetails(task);
+            loadTaskResources(taskId);
+        }
+    } catch (error) {
+        console.error("Error refreshing task:", error);
+    }
+}
+
+// Reorder the cards of a column to match the order saved by another browser
+function applyCardOrder(taskId, order) {
+    const taskCard = document.querySelector(`[data-task-id="${taskId}"]`);
+    if (!taskCard) return;
+    
+    const columnContent = taskCard.closest('.column-content');
+    order.forEach(id => {
+        const card = columnContent.querySelector(`[data-task-id="${id}"]`);
+        if (card) {
+            columnContent.appendChild(card);
+        }
+    });
+}
+
+// Apply a change event sent by the server
+function handleBoardEvent(message) {
+    const change = JSON.parse(message.data);
+    
+    // This tab already shows its own changes
+    if (change.source === clientId) return;
+    
+    switch (change.type) {
+        case "taskCreated":
+        case "taskUpdated":
+            refreshTaskCard(change.taskId, change.listId);
+            break;
+        case "taskDeleted":
+            if (currentTaskId === change.taskId && modal.style.display === "block") {
+                modal.style.display = "none";
+                showToast("This task was deleted by someone else", "error");
+            }
+            removeTaskCard(change.taskId);
+            break;
+        case "taskReordered":
+            applyCardOrder(change.taskId, change.order || []);
+            break;
+        case "listDeleted":
+            // An aggregate board just drops the deleted list's cards
+            if (boardLists.length > 0) {
+                document.querySelectorAll(`.task-card[data-list-id="${change.listId}"]`).forEach(card => {
+                    removeTaskCard(card.getAttribute('data-task-id'));
+                });
+                showToast("A list on this board was deleted", "error");
+                break;
+            }
+            showToast("This list was deleted", "error");
+            setTimeout(() => {
+                window.location.href = "/todoLists";
+            }, 2000);
+            break;
+        case "listResync":
+            // Changes were missed, so the cards can't be patched one by one; reload the board,
+            // unless that would throw away what is open in the details modal
+            if (modal.style.display === "block") {
+                showToast("This board changed elsewhere; reload to see the changes", "error");
+                break;
+            }
+            window.location.reload();
+            break;
+    }
+}
+
+// Subscribe to changes made to the board's lists from other browsers
+if (window.EventSource) {
+    const eventParams = new URLSearchParams();
+    (boardLists.length > 0 ? boardLists.map(list => list.id) : [listId]).forEach(id => eventParams.append("listId", id));
+    const boardEvents = new EventSource(`/api/events?${eventParams.toString()}`);
+    ["taskCreated", "taskUpdated", "taskDeleted", "taskReordered", "listDeleted", "listResync"].forEach(type => {
+        boardEvents.addEventListener(type, handleBoardEvent);
+    });
+    boardEvents.onerror = () => {
+        // EventSource reconnects on its own after an interruption; only say so if it has given up
+        if (boardEvents.readyState === EventSource.CLOSED) {
+            showToast("Live updates stopped; reload to see changes made elsewhere", "error");
+        }
+    };
+}
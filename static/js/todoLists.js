@@ -0,0 +1,199 @@
+/**
+ * Copyright (c) 2025 Carlos Oseguera (@coseguera)
+ * This code is licensed under a dual-license model.
+ * See LICENSE.md for more information.
+ */
+
+const todoLists = document.getElementById('todoLists');
+
+// Show a toast notification
+function showToast(message, type) {
+    const toast = document.getElementById("toast");
+    toast.textContent = message;
+    toast.className = "toast " + type;
+    toast.style.display = "block";
+
+    // Hide after 3 seconds
+    setTimeout(() => {
+        toast.style.display = "none";
+    }, 3000);
+}
+
+// Send a list change to the server; returns the response
+async function postListChange(url, params) {
+    const response = await fetch(url, {
+        method: "POST",
+        headers: {
+            'Content-Type': 'application/x-www-form-urlencoded',
+        },
+        body: params.toString(),
+        credentials: "same-origin"
+    });
+
+    if (!response.ok) {
+        const errorText = await response.text();
+        console.error("Server error:", errorText);
+        throw new Error(errorText || "Server error");
+    }
+
+    return response;
+}
+
+// Show or hide the message for when there are no lists
+function updateNoLists() {
+    document.getElementById('noLists').style.display = todoLists.children.length === 0 ? 'block' : 'none';
+}
+
+// Build the row of a list the user just created, matching the rows rendered by the server
+function addListRow(list) {
+    const row = document.createElement('li');
+    row.setAttribute('data-list-id', list.id);
+    row.setAttribute('data-shared', 'false');
+    row.setAttribute('data-owner', 'true');
+
+    const content = document.createElement('div');
+    content.className = 'list-row';
+
+    const select = document.createElement('input');
+    select.type = 'checkbox';
+    select.className = 'list-select';
+    select.name = 'listId';
+    select.value = list.id;
+    select.setAttribute('form', 'boardForm');
+    select.title = 'Include in the board';
+    content.appendChild(select);
+
+    const link = document.createElement('a');
+    link.href = `/list/${encodeURIComponent(list.id)}/tasks`;
+    link.className = 'list-name';
+    link.textContent = list.displayName;
+    content.appendChild(link);
+
+    const actions = document.createElement('span');
+    actions.className = 'list-actions';
+    [['rename-list-button', 'Rename'], ['delete-list-button', 'Delete']].forEach(([className, label]) => {
+        const button = document.createElement('button');
+        button.type = 'button';
+        button.className = 'button ' + className;
+        button.textContent = label;
+        actions.appendChild(button);
+    });
+    content.appendChild(actions);
+
+    row.appendChild(content);
+    todoLists.appendChild(row);
+    updateNoLists();
+}
+
+// Create a list from the name typed in the form
+async function createList(event) {
+    event.preventDefault();
+
+    const input = document.getElementById('newListName');
+    const displayName = input.value.trim();
+    if (!displayName) {
+        showToast("Please enter a list name", "error");
+        return;
+    }
+
+    try {
+        const params = new URLSearchParams();
+        params.append("displayName", displayName);
+
+        const response = await postListChange("/api/createList", params);
+        addListRow(await response.json());
+        input.value = '';
+        showToast("List created", "success");
+    } catch (error) {
+        console.error("Error creating list:", error);
+        showToast("Failed to create list: " + error.message, "error");
+    }
+}
+
+// Replace a list's name with an input to rename it; Enter saves and Escape cancels
+function startRename(row) {
+    const link = row.querySelector('.list-name');
+    if (!link || row.querySelector('.list-rename-input')) return;
+
+    const input = document.createElement('input');
+    input.type = 'text';
+    input.className = 'list-rename-input';
+    input.maxLength = 255;
+    input.value = link.textContent;
+    link.style.display = 'none';
+    link.after(input);
+    input.focus();
+    input.select();
+
+    let done = false;
+    const finish = async (save) => {
+        if (done) return;
+        done = true;
+
+        const displayName = input.value.trim();
+        input.remove();
+        link.style.display = '';
+        if (!save || !displayName || displayName === link.textContent) return;
+
+        try {
+            const params = new URLSearchParams();
+            params.append("listId", row.getAttribute('data-list-id'));
+            params.append("displayName", displayName);
+
+            await postListChange("/api/renameList", params);
+            link.textContent = displayName;
+            showToast("List renamed", "success");
+        } catch (error) {
+            console.error("Error renaming list:", error);
+            showToast("Failed to rename list: " + error.message, "error");
+        }
+    };
+
+    input.addEventListener('keydown', event => {
+        if (event.key === 'Enter') {
+            finish(true);
+        } else if (event.key === 'Escape') {
+            finish(false);
+        }
+    });
+    input.addEventListener('blur', () => finish(true));
+}
+
+// Delete a list and its tasks after confirming
+async function deleteList(row) {
+    const name = row.querySelector('.list-name').textContent;
+    let message = `Delete "${name}" and all of its tasks? This can't be undone.`;
+    if (row.getAttribute('data-shared') === 'true') {
+        message = row.getAttribute('data-owner') === 'true'
+            ? `"${name}" is shared. Deleting it removes it and its tasks for everyone it is shared with. Delete it?`
+            : `"${name}" was shared with you. Remove it from your lists?`;
+    }
+    if (!confirm(message)) return;
+
+    try {
+        const params = new URLSearchParams();
+        params.append("listId", row.getAttribute('data-list-id'));
+
+        await postListChange("/api/deleteList", params);
+        row.remove();
+        updateNoLists();
+        showToast("List deleted", "success");
+    } catch (error) {
+        console.error("Error deleting list:", error);
+        showToast("Failed to delete list: " + error.message, "error");
+    }
+}
+
+document.getElementById('createListForm').addEventListener('submit', createList);
+
+// Rows are added after the page loads, so listen on the list itself
+todoLists.addEventListener('click', event => {
+    const row = event.target.closest('li');
+    if (!row) return;
+
+    if (event.target.closest('.rename-list-button')) {
+        startRename(row);
+    } else if (event.target.closest('.delete-list-button')) {
+        deleteList(row);
+    }
+});